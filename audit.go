@@ -0,0 +1,403 @@
+// audit.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                                DENETİM KAYDI
+// #############################################################################
+// Bu dosya, bota yapılan her komut, callback ve LLM aracı çağrısını yapısal
+// ve yalnızca sona eklenen bir denetim kaydına yazar. Kayıtlar `audit.jsonl`
+// dosyasında her satırda bir JSON nesnesi olacak şekilde tutulur; bu sayede
+// dosya bozulsa bile yalnızca bozuk satır kaybolur. `telegram_bot.log`
+// dosyasındaki serbest metin loglarından farklı olarak LLM modundaki araç
+// çağrıları da buraya kaydedilir (mesaj metninin kendisi hariç).
+
+// Denetim kaydı türleri.
+const (
+	auditKindCommand  = "komut"
+	auditKindCallback = "callback"
+	auditKindTool     = "arac"
+	auditKindInvite   = "davet"
+)
+
+// Denetim kaydı sonuçları.
+const (
+	auditResultOK      = "basarili"
+	auditResultError   = "hata"
+	auditResultDenied  = "reddedildi"
+	auditResultUsage   = "hatali_kullanim"
+	auditResultUnknown = "bilinmeyen"
+)
+
+// auditArgsMaxLen, kayda yazılacak argüman metninin en fazla uzunluğudur (karakter).
+const auditArgsMaxLen = 500
+
+// AuditEntry, denetim kaydındaki tek bir satırı temsil eder.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	UserID     int64     `json:"user_id"`
+	UserName   string    `json:"user_name,omitempty"`
+	ChatID     int64     `json:"chat_id"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	Args       string    `json:"args,omitempty"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// auditMutex, denetim dosyasına eş zamanlı yazmaları sıraya sokar.
+var auditMutex = &sync.Mutex{}
+
+// recordAudit, bir denetim satırını dosyanın sonuna ekler. Yazma hatası
+// botun çalışmasını durdurmaz, yalnızca loglanır.
+func recordAudit(entry AuditEntry) {
+	if config.AuditLogPath == "" {
+		return
+	}
+	entry.Args = truncateForDisplay(entry.Args, auditArgsMaxLen)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Denetim kaydı JSON'a çevrilemedi: %v", err)
+		return
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	file, err := os.OpenFile(config.AuditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("Denetim dosyası açılamadı: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Printf("Denetim kaydı yazılamadı: %v", err)
+	}
+}
+
+// auditMessage, bir mesajdan tetiklenen işlem için denetim satırı yazar.
+func auditMessage(message *tgbotapi.Message, kind, name, args, result string, err error, start time.Time) {
+	entry := AuditEntry{
+		Time:       start,
+		UserID:     message.From.ID,
+		UserName:   message.From.UserName,
+		ChatID:     message.Chat.ID,
+		Kind:       kind,
+		Name:       name,
+		Args:       args,
+		Result:     result,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	recordAudit(entry)
+}
+
+// auditingMessenger, bir işleyiciye verilen Messenger'ı sararak işlemin
+// gerçek sonucunu yakalar. İşleyiciler hatayı kullanıcıya `❌`, yetki
+// reddini `🚫` ile başlayan bir mesajla bildirir; bu tür ilk yanıt denetim
+// kaydına yansıtılır. Gönderim hataları sayılmaz: işleyiciler Markdown
+// hatasında mesajı düz metin olarak yeniden gönderir. İşleyicinin arka
+// planda başlattığı görevlerin sonradan gönderdiği mesajlar kayda girmez.
+type auditingMessenger struct {
+	Messenger
+	mu     sync.Mutex
+	result string
+	err    error
+}
+
+func (m *auditingMessenger) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	sent, err := m.Messenger.Send(c)
+	if err == nil {
+		m.observe(c)
+	}
+	return sent, err
+}
+
+func (m *auditingMessenger) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	resp, err := m.Messenger.Request(c)
+	if err == nil {
+		m.observe(c)
+	}
+	return resp, err
+}
+
+// observe, gönderilen mesajın metnine bakarak sonucu günceller. Yalnızca
+// ilk hata saklanır.
+func (m *auditingMessenger) observe(c tgbotapi.Chattable) {
+	var text string
+	switch v := c.(type) {
+	case tgbotapi.MessageConfig:
+		text = v.Text
+	case tgbotapi.EditMessageTextConfig:
+		text = v.Text
+	case tgbotapi.CallbackConfig:
+		text = v.Text
+	}
+	text = strings.TrimSpace(text)
+	firstLine, _, _ := strings.Cut(text, "\n")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.result != "" {
+		return
+	}
+	switch {
+	case strings.HasPrefix(text, "❌"):
+		m.result, m.err = auditResultError, errors.New(firstLine)
+	case strings.HasPrefix(text, "🚫"):
+		m.result, m.err = auditResultDenied, errors.New(firstLine)
+	}
+}
+
+// outcome, yakalanan sonucu döndürür; hata görülmediyse işlem başarılıdır.
+func (m *auditingMessenger) outcome() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.result == "" {
+		return auditResultOK, nil
+	}
+	return m.result, m.err
+}
+
+// AuditFilter, denetim kaydında arama yapmak için kullanılan ölçütlerdir.
+// Boş alanlar filtre uygulanmadığı anlamına gelir.
+type AuditFilter struct {
+	UserID   int64
+	UserName string
+	Day      time.Time // sıfır değilse yalnızca bu günün kayıtları
+}
+
+// parseAuditFilter, `/denetim [kullanıcı] [tarih]` argümanlarını çözümler.
+// Kullanıcı sayısal ID veya kullanıcı adı (@ ile ya da @ olmadan) olabilir;
+// tarih `YYYY-AA-GG` veya `GG.AA.YYYY` biçimindedir. "*" herkes demektir.
+func parseAuditFilter(args []string) (AuditFilter, error) {
+	var filter AuditFilter
+	for _, arg := range args {
+		if day, err := parseAuditDay(arg); err == nil {
+			filter.Day = day
+			continue
+		}
+		if arg == "*" || strings.EqualFold(arg, "hepsi") {
+			continue
+		}
+		if id, err := parseUserID(arg); err == nil {
+			filter.UserID = id
+			continue
+		}
+		if strings.ContainsAny(arg, "-.") {
+			return filter, fmt.Errorf("geçersiz tarih: %s (YYYY-AA-GG)", arg)
+		}
+		filter.UserName = strings.TrimPrefix(arg, "@")
+	}
+	return filter, nil
+}
+
+func parseAuditDay(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02.01.2006"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("geçersiz tarih: %s", s)
+}
+
+// matches, bir kaydın filtreye uyup uymadığını kontrol eder.
+func (f AuditFilter) matches(entry AuditEntry) bool {
+	if f.UserID != 0 && entry.UserID != f.UserID {
+		return false
+	}
+	if f.UserName != "" && !strings.EqualFold(entry.UserName, f.UserName) {
+		return false
+	}
+	if !f.Day.IsZero() {
+		local := entry.Time.In(time.Local)
+		if local.Before(f.Day) || !local.Before(f.Day.AddDate(0, 0, 1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryAudit, denetim dosyasını baştan sona okuyup filtreye uyan kayıtları
+// eskiden yeniye doğru döndürür. Bozuk satırlar atlanır.
+func queryAudit(filter AuditFilter) ([]AuditEntry, error) {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	file, err := os.Open(config.AuditLogPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("denetim dosyası açılamadı: %w", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// exportAuditCSV, kayıtları başlık satırıyla birlikte CSV biçimine çevirir.
+func exportAuditCSV(entries []AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"time", "user_id", "user_name", "chat_id", "kind", "name", "args", "result", "error", "duration_ms"})
+	for _, e := range entries {
+		writer.Write([]string{
+			e.Time.Format(time.RFC3339),
+			strconv.FormatInt(e.UserID, 10),
+			e.UserName,
+			strconv.FormatInt(e.ChatID, 10),
+			e.Kind,
+			e.Name,
+			e.Args,
+			e.Result,
+			e.Error,
+			strconv.FormatInt(e.DurationMs, 10),
+		})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// auditResultIcon, sonuç türünü listede gösterilecek simgeye çevirir.
+func auditResultIcon(result string) string {
+	switch result {
+	case auditResultOK:
+		return "✅"
+	case auditResultDenied:
+		return "🚫"
+	case auditResultUsage, auditResultUnknown:
+		return "❔"
+	}
+	return "❌"
+}
+
+// auditListLimit, `/denetim` çıktısında gösterilecek en fazla kayıt sayısıdır.
+const auditListLimit = 30
+
+// handleAuditCommand, /denetim komutuyla son denetim kayıtlarını listeler.
+func handleAuditCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	filter, err := parseAuditFilter(strings.Fields(message.CommandArguments()))
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
+		return
+	}
+	entries, err := queryAudit(filter)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Denetim kaydı okunamadı: %v", err)))
+		return
+	}
+	if len(entries) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "📭 Ölçütlere uyan denetim kaydı bulunamadı."))
+		return
+	}
+
+	shown := entries
+	if len(shown) > auditListLimit {
+		shown = shown[len(shown)-auditListLimit:]
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🧾 Denetim Kaydı (son %d / toplam %d):\n\n", len(shown), len(entries)))
+	for i := len(shown) - 1; i >= 0; i-- {
+		e := shown[i]
+		who := strconv.FormatInt(e.UserID, 10)
+		if e.UserName != "" {
+			who = "@" + e.UserName
+		}
+		line := fmt.Sprintf("%s %s %s [%s] %s", auditResultIcon(e.Result), e.Time.In(time.Local).Format("02.01 15:04:05"), who, e.Kind, e.Name)
+		if e.Args != "" {
+			line += " " + truncateForDisplay(e.Args, 60)
+		}
+		line += fmt.Sprintf(" (%d ms)", e.DurationMs)
+		if e.Error != "" {
+			line += "\n   ↳ " + truncateForDisplay(e.Error, 120)
+		}
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString("\n💡 Dışa aktarmak için: /denetim_indir csv|json [kullanıcı] [tarih]")
+	// * Markdown kullanılmıyor: argümanlar ve hata metinleri kullanıcı girdisidir.
+	for _, chunk := range splitMessageSmart(builder.String()) {
+		bot.Send(tgbotapi.NewMessage(chatID, chunk))
+	}
+}
+
+// handleAuditExportCommand, /denetim_indir komutuyla filtrelenen kayıtları
+// CSV veya JSON dosyası olarak gönderir.
+func handleAuditExportCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	parts := strings.Fields(message.CommandArguments())
+	format := strings.ToLower(parts[0])
+	if format != "csv" && format != "json" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Biçim `csv` veya `json` olmalıdır."))
+		return
+	}
+	filter, err := parseAuditFilter(parts[1:])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
+		return
+	}
+	entries, err := queryAudit(filter)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Denetim kaydı okunamadı: %v", err)))
+		return
+	}
+	if len(entries) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "📭 Ölçütlere uyan denetim kaydı bulunamadı."))
+		return
+	}
+
+	var data []byte
+	if format == "csv" {
+		data, err = exportAuditCSV(entries)
+	} else {
+		data, err = json.MarshalIndent(entries, "", "  ")
+	}
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dışa aktarma başarısız: %v", err)))
+		return
+	}
+
+	fileName := fmt.Sprintf("denetim_%s.%s", time.Now().Format("20060102_150405"), format)
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: fileName, Bytes: data})
+	doc.Caption = fmt.Sprintf("🧾 %d denetim kaydı", len(entries))
+	bot.Send(doc)
+}
+
+// truncateForDisplay, uzun metinleri listede gösterim için kısaltır.
+func truncateForDisplay(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
@@ -1,36 +1,151 @@
 // auth.go
 package main
 
+import (
+	"path/filepath"
+	"strings"
+)
+
 // #############################################################################
 // #                             YETKİLENDİRME MANTIĞI                           #
 // #############################################################################
 // Bu dosya, bota gelen isteklerin kim tarafından yapıldığını kontrol eden ve
 // bu kullanıcının belirli komutları çalıştırma yetkisi olup olmadığını
 // belirleyen temel güvenlik fonksiyonlarını içerir.
+//
+// Yetki modeli rol tabanlıdır: her kullanıcının bir rolü vardır ve her rolün
+// bir seviyesi bulunur (izleyici < operator < yonetici). Komutlar ve LLM
+// araçları çalışmak için gereken en düşük rolü bildirir. Buna ek olarak hem
+// rollere hem de tek tek kullanıcılara seviyeden bağımsız komut, araç ve
+// kategori izinleri verilebilir. Kullanıcılar ve roller `users.json`
+// dosyasında saklanır (bkz. `user_store.go`).
+
+// Varsayılan rol adları.
+const (
+	roleViewer   = "izleyici"
+	roleOperator = "operator"
+	roleAdmin    = "yonetici"
+)
+
+// Bir izin listesinde "her şey" anlamına gelen joker değer.
+const permissionWildcard = "*"
+
+// Ana klasördeki (kategorisiz) dosyalar için kullanılan kategori adı.
+const rootCategoryName = "Gelenler"
+
+// categoryDeniedText, kategori izni olmayan kullanıcıya gösterilen mesajdır.
+const categoryDeniedText = "🚫 Bu dosyanın bulunduğu kategoriye erişim yetkiniz yok."
+
+// toolMinRoles, her LLM aracının çalışması için gereken en düşük rolü
+// belirtir. Listede olmayan araçlar yöneticiye özeldir.
+var toolMinRoles = map[string]string{
+	"get_system_status":        roleViewer,
+	"list_files":               roleViewer,
+	"list_files_in_category":   roleViewer,
+	"search_files":             roleViewer,
+	"search_file_contents":     roleViewer,
+	"send_file":                roleViewer,
+	"get_detailed_system_info": roleOperator,
+	"run_speed_test":           roleOperator,
+	"delete_file":              roleOperator,
+	"organize_files":           roleOperator,
+	"download_video_or_audio":  roleOperator,
+	"get_screenshot":           roleAdmin,
+	"start_application":        roleAdmin,
+}
+
 func isUserAdmin(userID int64) bool {
-	// Gelen kullanıcı ID'sini, global yapılandırmadaki yönetici ID'si ile karşılaştırır.
-	return userID == config.AdminChatID
+	// Kullanıcının rol seviyesi yönetici seviyesine ulaşıyor mu?
+	account, role := lookupAccount(userID)
+	return account != nil && role.Level >= roleLevel(roleAdmin)
 }
 
 // isUserAllowed, bir kullanıcının botu kullanma izni olup olmadığını kontrol eder.
-// Bu fonksiyon, botun en temel güvenlik filtresidir. Bir kullanıcı, ya yönetici
-// olmalı ya da .env dosyasındaki `ALLOWED_IDS` listesinde bulunmalıdır.
+// Bu fonksiyon, botun en temel güvenlik filtresidir. Bir kullanıcı, `users.json`
+// dosyasında kayıtlı olmalıdır.
 func isUserAllowed(userID int64) bool {
-	// * Öncelik 1: Kullanıcı yönetici mi?
-	// Eğer kullanıcı yönetici ise, başka bir kontrol yapmaya gerek kalmaz.
-	if isUserAdmin(userID) {
+	account, _ := lookupAccount(userID)
+	return account != nil
+}
+
+// canRunCommand, kullanıcının verilen komutu çalıştırıp çalıştıramayacağını
+// belirler. Rol seviyesi yeterliyse veya komut role ya da kullanıcıya ayrıca
+// verilmişse izin verilir.
+func canRunCommand(userID int64, spec *CommandSpec) bool {
+	account, role := lookupAccount(userID)
+	if account == nil {
+		return false
+	}
+	if role.Level >= roleLevel(spec.Role) {
 		return true
 	}
+	return hasPermission(role.Commands, spec.Name) || hasPermission(account.Commands, spec.Name)
+}
 
-	// * Öncelik 2: Kullanıcı izin verilenler listesinde mi değil mi?
-	// Yönetici değilse, global yapılandırmadaki `AllowedIDs` dilimini (slice) döngüye al.
-	for _, allowedID := range config.AllowedIDs {
-		// Eğer kullanıcının ID'si listedeki bir ID ile eşleşirse, izin verilir.
-		if userID == allowedID {
-			return true
+// canUseTool, kullanıcının LLM modunda verilen aracı kullanıp kullanamayacağını belirler.
+func canUseTool(userID int64, toolName string) bool {
+	account, role := lookupAccount(userID)
+	if account == nil {
+		return false
+	}
+	minRole, known := toolMinRoles[toolName]
+	if !known {
+		minRole = roleAdmin
+	}
+	if role.Level >= roleLevel(minRole) {
+		return true
+	}
+	return hasPermission(role.Tools, toolName) || hasPermission(account.Tools, toolName)
+}
+
+// canAccessCategory, kullanıcının verilen kategori klasöründeki dosyalara
+// erişip erişemeyeceğini belirler.
+func canAccessCategory(userID int64, category string) bool {
+	account, role := lookupAccount(userID)
+	if account == nil {
+		return false
+	}
+	return hasPermission(role.Categories, category) || hasPermission(account.Categories, category)
+}
+
+// canAccessPath, `BaseDir` altındaki bir dosya yolunun ait olduğu kategoriye
+// (ana klasörün ilk alt klasörü) kullanıcının erişimi olup olmadığını belirler.
+func canAccessPath(userID int64, path string) bool {
+	return canAccessCategory(userID, categoryOfPath(path))
+}
+
+// filterAccessibleDescriptions, göreli yol -> açıklama haritasından kullanıcının
+// erişemediği kategorilerdeki dosyaları çıkarır. Kategori yoldan belirlendiği
+// için diskte artık bulunmayan dosyalar da filtrelenir.
+func filterAccessibleDescriptions(userID int64, descriptions map[string]string) {
+	for key := range descriptions {
+		if filePath, ok := pathOfKey(key); ok && !canAccessPath(userID, filePath) {
+			delete(descriptions, key)
 		}
 	}
+}
 
+// categoryOfPath, bir dosyanın `BaseDir` altındaki ilk klasörünü kategori
+// olarak döndürür. Ana klasördeki dosyalar için `rootCategoryName` döner.
+func categoryOfPath(path string) string {
+	rel, err := filepath.Rel(config.BaseDir, path)
+	if err != nil {
+		return rootCategoryName
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 2 {
+		return rootCategoryName
+	}
+	return parts[0]
+}
 
+// hasPermission, bir izin listesinde verilen değerin (veya jokerin) olup
+// olmadığını büyük/küçük harf duyarsız olarak kontrol eder.
+func hasPermission(list []string, value string) bool {
+	for _, item := range list {
+		if item == permissionWildcard || strings.EqualFold(item, value) {
+			return true
+		}
+	}
 	return false
-}
\ No newline at end of file
+}
@@ -0,0 +1,220 @@
+// callback_router.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                           DÜĞME YÖNLENDİRİCİSİ
+// #############################################################################
+// Bu dosya, satır içi düğmelerden gelen geri çağrıların (callback) tek giriş
+// noktasıdır. Her düğme verisi `<önek>_<geri kalan>` biçimindedir; önek,
+// `init` içinde kaydedilen bir işleyiciye eşlenir. Kayıt, düğmeye basmak için
+// çalıştırılabilmesi gereken komutu da bildirir. Böylece bir düğmeye yalnızca
+// ilgili komutu kullanma yetkisi olan kullanıcılar basabilir (örn. `/gorevler`
+// düğmeleri yalnızca yöneticiler içindir).
+//
+// Telegram düğme verisini 64 bayt ile sınırlar. Dosya yolu gibi uzun veya
+// serbest metinli değerler düğmeye yazılmaz; sunucuda kısa bir kimlikle
+// saklanır (`newCallbackPayload`). Saklanan değer, düğmeyi oluşturan
+// kullanıcıya bağlıdır ve süresi dolunca silinir. Düğmelere basıldıkça
+// durumu değişen mesajlar (`/gezgin`, `/kopyalar`) kendi oturumlarını tutar.
+
+// maxCallbackData, Telegram'ın kabul ettiği en uzun düğme verisidir (bayt).
+const maxCallbackData = 64
+
+// callbackPayloadTTL, sunucuda saklanan düğme değerlerinin geçerli kaldığı süredir.
+const callbackPayloadTTL = 15 * time.Minute
+
+// CallbackSpec, bir düğme önekinin bildirimini tutar.
+type CallbackSpec struct {
+	Prefix string
+	// Command, düğmeye basabilmek için çalıştırılabilmesi gereken komuttur.
+	// Boş bırakılırsa tüm kayıtlı kullanıcılar basabilir; bu durumda
+	// işleyici kendi kontrolünü yapmalıdır.
+	Command string
+	Handler func(bot Messenger, callbackQuery *tgbotapi.CallbackQuery)
+}
+
+// callbackIndex, düğme öneklerini bildirimlerine eşler.
+var callbackIndex = make(map[string]*CallbackSpec)
+
+// registerCallback, bir düğme önekini kayda ekler. Aynı önek iki kez
+// kaydedilirse program başlarken çöker; bu bir programlama hatasıdır.
+func registerCallback(spec *CallbackSpec) {
+	if _, exists := callbackIndex[spec.Prefix]; exists {
+		log.Panicf("Düğme öneki iki kez kaydedildi: %s", spec.Prefix)
+	}
+	callbackIndex[spec.Prefix] = spec
+}
+
+func init() {
+	registerCallback(&CallbackSpec{Prefix: "sil", Command: "sil", Handler: handleDeleteCallback})
+	// Seçim, komutu normal akıştan yeniden çalıştırır; yetki orada kontrol edilir.
+	registerCallback(&CallbackSpec{Prefix: "dosya", Handler: handleFileChoiceCallback})
+	registerCallback(&CallbackSpec{Prefix: "kopya", Command: "kopyalar", Handler: handleDuplicatesCallback})
+	registerCallback(&CallbackSpec{Prefix: "gez", Command: "gezgin", Handler: handleBrowserCallback})
+	registerCallback(&CallbackSpec{Prefix: "parca", Command: "getir", Handler: handleSplitCallback})
+	// Dosya gönderen her kullanıcı sorulur; düğme yalnızca gönderene aittir.
+	registerCallback(&CallbackSpec{Prefix: "gelen", Handler: handleIncomingCallback})
+	registerCallback(&CallbackSpec{Prefix: "surum", Command: "surum_getir", Handler: handleVersionCallback})
+	registerCallback(&CallbackSpec{Prefix: "cop", Command: "cop_bosalt", Handler: handleTrashCallback})
+	registerCallback(&CallbackSpec{Prefix: "erisim", Command: "kullanici_sil", Handler: handleRevokeAccessCallback})
+	registerCallback(&CallbackSpec{Prefix: "gorevler", Command: "gorevler", Handler: handleProcessListCallback})
+}
+
+// callbackData, parçaları `_` ile birleştirerek düğme verisi üretir. Sonuç
+// Telegram'ın sınırını aşarsa uyarı loglanır; bu bir programlama hatasıdır
+// ve uzun değerler `newCallbackPayload` ile saklanmalıdır.
+func callbackData(prefix string, parts ...string) string {
+	data := strings.Join(append([]string{prefix}, parts...), "_")
+	if len(data) > maxCallbackData {
+		log.Printf("Uyarı: düğme verisi %d baytı aşıyor (%d bayt): %s", maxCallbackData, len(data), data)
+	}
+	return data
+}
+
+// handleCallbackQuery, düğme verisinin önekine göre kayıtlı işleyiciyi
+// bulur, kullanıcının yetkisini kontrol eder ve işleyiciyi çalıştırır. Her
+// basış denetim kaydına yazılır.
+func handleCallbackQuery(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	data := callbackQuery.Data
+	prefix, _, _ := strings.Cut(data, "_")
+	user := callbackQuery.From
+	start := time.Now()
+
+	result := auditResultOK
+	var handlerErr error
+	defer func() {
+		r := recover()
+		if r != nil {
+			result, handlerErr = auditResultError, fmt.Errorf("panik: %v", r)
+		}
+		entry := AuditEntry{
+			Time:       start,
+			UserID:     user.ID,
+			UserName:   user.UserName,
+			ChatID:     callbackQuery.Message.Chat.ID,
+			Kind:       auditKindCallback,
+			Name:       prefix,
+			Args:       data,
+			Result:     result,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if handlerErr != nil {
+			entry.Error = handlerErr.Error()
+		}
+		recordAudit(entry)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	spec, found := callbackIndex[prefix]
+	if !found {
+		log.Printf("Bilinmeyen düğme verisi: %s", data)
+		result = auditResultUnknown
+		bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+		return
+	}
+	if spec.Command != "" {
+		command, known := lookupCommand(spec.Command)
+		if !known || !canRunCommand(user.ID, command) {
+			log.Printf("⚠️ YETKİSİZ DÜĞME DENEMESİ! Kullanıcı: %s (%d), Düğme: %s", user.UserName, user.ID, data)
+			result = auditResultDenied
+			text := "🚫 Bu düğmeyi kullanma yetkiniz bulunmuyor."
+			if known && command.Role == roleAdmin {
+				text = "🚫 Bu düğmeyi sadece yönetici kullanabilir."
+			}
+			bot.Request(tgbotapi.NewCallbackWithAlert(callbackQuery.ID, text))
+			return
+		}
+	}
+
+	bot.Request(tgbotapi.NewCallback(callbackQuery.ID, ""))
+	watcher := &auditingMessenger{Messenger: bot}
+	spec.Handler(watcher, callbackQuery)
+	result, handlerErr = watcher.outcome()
+}
+
+// #############################################################################
+// #                          SAKLANAN DÜĞME DEĞERLERİ
+// #############################################################################
+
+// callbackPayload, bir düğmenin sunucuda saklanan değeridir.
+type callbackPayload struct {
+	userID  int64 // Düğmeyi kullanabilecek tek kullanıcı
+	value   string
+	expires time.Time
+}
+
+var (
+	callbackPayloads     = make(map[string]*callbackPayload)
+	callbackPayloadMutex = &sync.Mutex{}
+)
+
+var (
+	// errPayloadExpired, değerin hiç olmadığını veya süresinin dolduğunu belirtir.
+	errPayloadExpired = errors.New("düğmenin süresi dolmuş")
+	// errPayloadForeign, düğmeye onu oluşturan kullanıcıdan başkasının bastığını belirtir.
+	errPayloadForeign = errors.New("düğme başka bir kullanıcıya ait")
+)
+
+// newCallbackPayload, değeri kullanıcıya bağlı olarak saklar ve düğme
+// verisinde kullanılacak 12 karakterlik kimliği döndürür.
+func newCallbackPayload(userID int64, value string) string {
+	id := randomToken()[:12]
+	callbackPayloadMutex.Lock()
+	defer callbackPayloadMutex.Unlock()
+	now := time.Now()
+	for key, payload := range callbackPayloads {
+		if now.After(payload.expires) {
+			delete(callbackPayloads, key)
+		}
+	}
+	callbackPayloads[id] = &callbackPayload{userID: userID, value: value, expires: now.Add(callbackPayloadTTL)}
+	return id
+}
+
+// takeCallbackPayload, kimliğe karşılık gelen değeri döndürür ve siler.
+// Düğmeye başka bir kullanıcı bastıysa değer silinmez ve `errPayloadForeign`
+// döner; böylece düğme sahibi için geçerli kalır.
+func takeCallbackPayload(id string, userID int64) (string, error) {
+	callbackPayloadMutex.Lock()
+	defer callbackPayloadMutex.Unlock()
+	payload, found := callbackPayloads[id]
+	if !found || time.Now().After(payload.expires) {
+		delete(callbackPayloads, id)
+		return "", errPayloadExpired
+	}
+	if payload.userID != userID {
+		return "", errPayloadForeign
+	}
+	delete(callbackPayloads, id)
+	return payload.value, nil
+}
+
+// peekCallbackPayload, `takeCallbackPayload` gibidir ancak değeri silmez.
+// Düğmelerine birden çok kez basılabilen listeler kullanır; değer süresi
+// dolunca silinir.
+func peekCallbackPayload(id string, userID int64) (string, error) {
+	callbackPayloadMutex.Lock()
+	defer callbackPayloadMutex.Unlock()
+	payload, found := callbackPayloads[id]
+	if !found || time.Now().After(payload.expires) {
+		delete(callbackPayloads, id)
+		return "", errPayloadExpired
+	}
+	if payload.userID != userID {
+		return "", errPayloadForeign
+	}
+	return payload.value, nil
+}
@@ -2,12 +2,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -22,76 +25,47 @@ import (
 //   - Gerekli diğer fonksiyonları (dosya arama, sistem bilgisi alma vb.) çağırmak.
 //   - Sonucu kullanıcıya düzgün bir şekilde formatlayıp göndermek.
 
-// handleGeneralCommands, /start, /help, /durum gibi basit metin tabanlı komutları yönetir.
-func handleGeneralCommands(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(message.Chat.ID, "")
+// handleStartCommand, /start komutuna karşılama mesajıyla cevap verir.
+func handleStartCommand(bot Messenger, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, "*Hoş geldin*\n\n"+
+		"Bu sistem; dosya erişimi, medya yönetimi ve sistem denetimi gibi işlemleri Telegram üzerinden kontrol edebilmen için optimize edildi.\n\n"+
+		"Tüm komutları listelemek için `/help` komutunu kullanabilirsin.")
 	msg.ParseMode = "Markdown"
-	command := message.Command()
-
-	switch command {
-	case "start":
-		msg.Text = "*Hoş geldin*\n\n" +
-			"Bu sistem; dosya erişimi, medya yönetimi ve sistem denetimi gibi işlemleri Telegram üzerinden kontrol edebilmen için optimize edildi.\n\n" +
-			"Tüm komutları listelemek için `/help` komutunu kullanabilirsin."
-
-	case "help":
-		msg.Text = "*-Komut Seti –*\n\n" +
-			"^^ *Akıllı Asistan (Sentinel):*\n" +
-			"`/llm` – Yapay zeka ile sohbet modunu başlatır\n" +
-			"`/llm_kapat` – Aktif sohbet modunu sonlandırır\n\n" +
-			"== *Dosya Yönetimi:*\n" +
-			"`/getir <dosya>` – Dosyayı gönder\n" +
-			"`/sil <dosya>` – Dosyayı sil (onaylı)\n" +
-			"`/yenidenadlandir <eski> <yeni>` – Dosyayı yeniden adlandır\n" +
-			"`/tasi <dosya> <klasör>` – Dosyayı taşı\n\n" +
-			"oo *Arama ve Listeleme:*\n" +
-			"`/ara <kelime>` – Dosya adlarında ara\n" +
-			"`/liste` – Ana klasördeki dosyaları göster\n" +
-			"`/klasor <kategori>` – Kategori klasörünü listele\n\n" +
-			":: *Açıklama Yönetimi:*\n" +
-			"`/aciklama_ekle <dosya> <açıklama>`\n" +
-			"`/aciklama_sil <dosya>`\n" +
-			"`/aciklamalar` – Tüm açıklamaları listele\n" +
-			"`/aciklama_ara <kelime>` – Açıklamalarda ara\n\n" +
-			"//  *İndirme ve Medya İşleme:*\n" +
-			"`/indir <URL> [kalite] [format]` – Video/dosya indir\n" +
-			"`/indir_ses <URL> [format]` – Sadece sesi indir\n" +
-			"`/kes <dosya> <baş> <bitiş>` – Video kes\n" +
-			"`/gif_yap <dosya> <bitiş>` – GIF üret\n\n" +
-			"==| *Sistem ve İşlem Yönetimi:*\n" +
-			"`/gorevler` – İnteraktif görev yöneticisi (Yönetici)\n" +
-			"`/kapat <PID>` – Çalışan işlemi durdur (Yönetici)\n" +
-			"`/durum` – Temel sistem durumu\n" +
-			"`/sistem_bilgisi` – Ayrıntılı sistem bilgisi (Yönetici)\n" +
-			"`/hiz_testi` – İndirme/yükleme hızı ve ping ölçümü\n" +
-			"`/portlar` – İzlenen port durumları\n" +
-			"`/ss` – Ekran görüntüsü al (Yönetici)\n" +
-			"`/kayit_al`, `/kayit_durdur` – Ekran kaydı (Yönetici)\n" +
-			"`/duzenle` – Dosyaları otomatik kategorilere ayır\n" +
-			"`/izle` – Ağ bağlantısını izlemeye başla/durdur\n\n" +
-			"++ *Uygulama & Betik Çalıştırma (Yönetici):*\n" +
-			"`/calistir <yol> <süre>` – Betik çalıştır ve çıktısını al\n" +
-			"`/uygulama_calistir <kısayol>` – Önceden tanımlı uygulamayı başlat\n" +
-			"`/calistir_dosya <yol>` – Dosya yolu ile uygulama başlat"
-
-	case "duzenle":
-		count := organizeFiles()
-		msg.Text = fmt.Sprintf("🗂️ Dosyalar kategorilere göre yeniden düzenlendi.\nTaşınan dosya sayısı: *%d*", count)
-
-	case "sistem_bilgisi":
-		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "📊 Sistem bilgileri getiriliyor..."))
-		msg.Text = getSystemInfoText(true)
-
-	case "durum":
-		msg.Text = getSystemInfoText(false)
-	}
-	if msg.Text != "" {
-		bot.Send(msg)
-	}
+	bot.Send(msg)
+}
+
+// handleHelpCommand, komut kaydından üretilen yardım metnini gönderir.
+func handleHelpCommand(bot Messenger, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, buildHelpText(message.From.ID))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// handleOrganizeCommand, /duzenle komutuyla dosyaları kategorilere ayırır.
+func handleOrganizeCommand(bot Messenger, message *tgbotapi.Message) {
+	count := organizeFiles(bot, message.From.ID)
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("🗂️ Dosyalar kategorilere göre yeniden düzenlendi.\nTaşınan dosya sayısı: *%d*", count))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// handleSystemInfoCommand, /sistem_bilgisi komutuyla ayrıntılı sistem raporu gönderir.
+func handleSystemInfoCommand(bot Messenger, message *tgbotapi.Message) {
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, "📊 Sistem bilgileri getiriliyor..."))
+	msg := tgbotapi.NewMessage(message.Chat.ID, getSystemInfoText(true))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// handleStatusCommand, /durum komutuyla özet sistem durumunu gönderir.
+func handleStatusCommand(bot Messenger, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, getSystemInfoText(false))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
 }
 
 // handleSpeedTestCommand, /hiz_testi komutunu asenkron olarak işler.
-func handleSpeedTestCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleSpeedTestCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	statusMsg, err := bot.Send(tgbotapi.NewMessage(chatID, "⏳ Bağlantı testi başlatılıyor... Bu işlem 30 saniye kadar sürebilir."))
 	if err != nil {
@@ -100,7 +74,7 @@ func handleSpeedTestCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	}
 
 	// Hız testi uzun sürebileceği için botu bloklamamak adına ayrı bir goroutine'de çalıştırılır.
-	go func() {
+	goBackground(func() {
 		var finalText string
 		speedTestResult, err := runSpeedTest()
 
@@ -125,11 +99,11 @@ func handleSpeedTestCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		editMsg := tgbotapi.NewEditMessageText(chatID, statusMsg.MessageID, finalText)
 		editMsg.ParseMode = "Markdown"
 		bot.Request(editMsg)
-	}()
+	})
 }
 
 // handleToggleInternetMonitorCommand, internet kesinti izleyicisini açar veya kapatır.
-func handleToggleInternetMonitorCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleToggleInternetMonitorCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	monitorMutex.Lock()
 	defer monitorMutex.Unlock()
@@ -152,7 +126,7 @@ func handleToggleInternetMonitorCommand(bot *tgbotapi.BotAPI, message *tgbotapi.
 }
 
 // handleMoveFileCommand, bir dosyayı belirtilen klasöre taşır.
-func handleMoveFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleMoveFileCommand(bot Messenger, message *tgbotapi.Message) {
 	args := message.CommandArguments()
 	chatID := message.Chat.ID
 	parts := strings.SplitN(args, " ", 2)
@@ -162,40 +136,69 @@ func handleMoveFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		return
 	}
 	sourceFileName, targetFolderPath := parts[0], parts[1]
-	sourcePath, found := findFile(sourceFileName)
-	if !found {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Taşınacak dosya bulunamadı: `%s`", sourceFileName)))
+	sourcePath, ok := resolveFileForCommand(bot, message, sourceFileName, " "+targetFolderPath,
+		fmt.Sprintf("❌ Taşınacak dosya bulunamadı: `%s`", sourceFileName))
+	if !ok {
 		return
 	}
-
-	// Güvenlik: Üst dizinlere ("..") çıkışı engeller.
-	cleanTarget := filepath.Clean(targetFolderPath)
-	if strings.HasPrefix(cleanTarget, "..") {
-		bot.Send(tgbotapi.NewMessage(chatID, "❌ Geçersiz hedef klasör! Üst dizinlere çıkılamaz."))
+	targetPath, err := moveFileToFolder(message.From.ID, sourcePath, targetFolderPath)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
 		return
 	}
+	reply := fmt.Sprintf("✅ Dosya başarıyla taşındı.\n\n📄 `%s`\n⬇️\n📁 `%s`", fileKey(sourcePath), fileKey(targetPath))
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
 
-	absoluteTargetDir := filepath.Join(config.BaseDir, cleanTarget)
+// moveFileToFolder, bir dosyayı ana klasöre göre verilen klasöre taşır,
+// işlemi günlüğe yazar ve açıklamasını yeni konuma aktarır. Hata mesajları
+// kullanıcıya gösterilecek biçimdedir. `/tasi` ve dosya gezgini kullanır.
+func moveFileToFolder(userID int64, sourcePath, targetFolder string) (string, error) {
+	baseName := filepath.Base(sourcePath)
+
+	// Güvenlik: Klasör adları temizlenir; ana klasörün dışına (`..`, mutlak
+	// yol veya sembolik bağlantı ile) çıkılamaz.
+	cleanTarget, err := safeRelativePath(targetFolder)
+	if errors.Is(err, errOutsideBaseDir) {
+		return "", fmt.Errorf("Geçersiz hedef klasör! Üst dizinlere çıkılamaz.")
+	} else if err != nil {
+		return "", fmt.Errorf("Geçersiz hedef klasör adı: %v", err)
+	}
+	targetPath, err := resolveInBase(path.Join(cleanTarget, baseName))
+	if err != nil {
+		return "", fmt.Errorf("Geçersiz hedef klasör! Üst dizinlere çıkılamaz.")
+	}
+
+	absoluteTargetDir := filepath.Dir(targetPath)
+	if !canAccessPath(userID, targetPath) {
+		return "", fmt.Errorf("Hedef kategoriye erişim yetkiniz yok.")
+	}
 	if err := os.MkdirAll(absoluteTargetDir, os.ModePerm); err != nil {
 		log.Printf("Hedef klasör oluşturulamadı: %v", err)
-		bot.Send(tgbotapi.NewMessage(chatID, "❌ Hedef klasör oluşturulurken bir hata oluştu."))
-		return
+		return "", fmt.Errorf("Hedef klasör oluşturulurken bir hata oluştu.")
 	}
 
-	targetPath := filepath.Join(absoluteTargetDir, sourceFileName)
-	err := os.Rename(sourcePath, targetPath)
-	if err != nil {
+	if _, err := os.Stat(targetPath); err == nil {
+		return "", fmt.Errorf("Hedef klasörde aynı adda bir dosya zaten var: `%s`", fileKey(targetPath))
+	}
+	if err := os.Rename(sourcePath, targetPath); err != nil {
 		log.Printf("Dosya taşınamadı: %v", err)
-		bot.Send(tgbotapi.NewMessage(chatID, "❌ Dosya taşınırken bir hata oluştu."))
-		return
+		return "", fmt.Errorf("Dosya taşınırken bir hata oluştu.")
 	}
 	log.Printf("Dosya taşındı: %s -> %s", sourcePath, targetPath)
-	reply := fmt.Sprintf("✅ Dosya başarıyla taşındı.\n\n📄 `%s`\n⬇️\n📁 `%s`", sourceFileName, cleanTarget)
-	bot.Send(tgbotapi.NewMessage(chatID, reply))
+	recordMove(userID, operationMove, sourcePath, targetPath)
+	// Varsa, dosya açıklaması da yeni konuma taşınır.
+	if err := moveFileMetadata(fileKey(sourcePath), fileKey(targetPath)); err != nil {
+		log.Printf("Açıklama yeni konuma taşınamadı: %v", err)
+	}
+	if err := moveFileVersions(fileKey(sourcePath), fileKey(targetPath)); err != nil {
+		log.Printf("Sürümler yeni konuma taşınamadı: %v", err)
+	}
+	return targetPath, nil
 }
 
 // handleGetFileCommand, sunucudaki bir dosyayı kullanıcıya gönderir.
-func handleGetFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleGetFileCommand(bot Messenger, message *tgbotapi.Message) {
 	args := message.CommandArguments()
 	chatID := message.Chat.ID
 	if args == "" {
@@ -203,20 +206,26 @@ func handleGetFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		return
 	}
 	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔍 `%s` dosyası aranıyor...", args)))
-	filePath, found := findFile(args)
-	if !found {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` dosyası bulunamadı!", args)))
+	filePath, ok := resolveFileForCommand(bot, message, args, "", fmt.Sprintf("❌ `%s` dosyası bulunamadı!", args))
+	if !ok {
 		return
 	}
+	sendStoredFile(bot, chatID, message.From.ID, filePath)
+}
+
+// sendStoredFile, ana klasördeki bir dosyayı açıklaması ve etiketleriyle
+// birlikte gönderir. Gönderme limitini aşan dosyalar için parçalı gönderim
+// önerilir. `/getir` ve dosya gezgini kullanır.
+func sendStoredFile(bot Messenger, chatID, userID int64, filePath string) {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dosya bilgileri okunamadı: `%s`", args)))
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dosya bilgileri okunamadı: `%s`", fileKey(filePath))))
 		return
 	}
 
-	// Telegram'ın 50MB'lık dosya gönderme limitini uygular.
-	if fileInfo.Size() > 50*1024*1024 {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dosya çok büyük! (%.1f MB). Limit 50 MB.", float64(fileInfo.Size())/1024/1024)))
+	// Telegram'ın dosya gönderme limitini aşan dosyalar için parçalı gönderim önerilir.
+	if fileInfo.Size() > uploadLimit() {
+		offerSplitSend(bot, chatID, userID, filePath, fileInfo.Size())
 		return
 	}
 
@@ -225,9 +234,12 @@ func handleGetFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	captionBuilder.WriteString(fmt.Sprintf("📄 *%s*", filepath.Base(filePath)))
 
 	// Varsa, dosyanın açıklamasını da gönderir.
-	if description, ok := getDescription(args); ok {
+	if description, ok := getDescription(fileKey(filePath)); ok {
 		captionBuilder.WriteString(fmt.Sprintf("\n\n📝 *Açıklama:*\n%s", description))
 	}
+	if tags := getTags(fileKey(filePath)); len(tags) > 0 {
+		captionBuilder.WriteString(fmt.Sprintf("\n\n🏷️ `%s`", formatTags(tags)))
+	}
 
 	doc.Caption = captionBuilder.String()
 	doc.ParseMode = "Markdown"
@@ -237,7 +249,7 @@ func handleGetFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 }
 
 // handleAddDescriptionCommand, bir dosyaya açıklama metni ekler.
-func handleAddDescriptionCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleAddDescriptionCommand(bot Messenger, message *tgbotapi.Message) {
 	args := message.CommandArguments()
 	chatID := message.Chat.ID
 	parts := strings.SplitN(args, " ", 2)
@@ -247,53 +259,66 @@ func handleAddDescriptionCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 	}
 	filename := parts[0]
 	description := parts[1]
-	if _, found := findFile(filename); !found {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` dosyası bulunamadı!", filename)))
+	filePath, ok := resolveFileForCommand(bot, message, filename, " "+description, fmt.Sprintf("❌ `%s` dosyası bulunamadı!", filename))
+	if !ok {
 		return
 	}
-	if err := addDescription(filename, description); err != nil {
+	key := fileKey(filePath)
+	if err := addDescription(key, description); err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, "❌ Açıklama eklenirken bir hata oluştu."))
 	} else {
-		reply := fmt.Sprintf("✅ *Açıklama eklendi!*\n\n📄 *Dosya:* `%s`\n📝 *Açıklama:* %s", filename, description)
+		reply := fmt.Sprintf("✅ *Açıklama eklendi!*\n\n📄 *Dosya:* `%s`\n📝 *Açıklama:* %s", key, description)
 		bot.Send(tgbotapi.NewMessage(chatID, reply))
 	}
 }
 
 // handleRemoveDescriptionCommand, bir dosyanın açıklamasını siler.
-func handleRemoveDescriptionCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleRemoveDescriptionCommand(bot Messenger, message *tgbotapi.Message) {
 	filename := message.CommandArguments()
 	chatID := message.Chat.ID
 	if filename == "" {
 		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/aciklama_sil <dosya_adı>`"))
 		return
 	}
-	if err := removeDescription(filename); err != nil {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` için açıklama bulunamadı veya silinemedi.", filename)))
+	// Dosya artık diskte yoksa açıklama doğrudan verilen anahtarla silinir.
+	key := filename
+	if len(findFileMatches(filename)) > 0 {
+		filePath, ok := resolveFileForCommand(bot, message, filename, "", "")
+		if !ok {
+			return
+		}
+		key = fileKey(filePath)
+	} else if path, ok := pathOfKey(key); ok && !canAccessPath(message.From.ID, path) {
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return
+	}
+	if err := removeDescription(key); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` için açıklama bulunamadı veya silinemedi.", key)))
 	} else {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ `%s` dosyasının açıklaması silindi.", filename)))
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ `%s` dosyasının açıklaması silindi.", key)))
 	}
 }
 
 // handleListDescriptionsCommand, kayıtlı tüm dosya açıklamalarını listeler.
-func handleListDescriptionsCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleListDescriptionsCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
-	metadataMutex.Lock()
-	defer metadataMutex.Unlock()
-	if len(fileMetadata) == 0 {
+	visible := listDescriptions()
+	filterAccessibleDescriptions(message.From.ID, visible)
+	if len(visible) == 0 {
 		bot.Send(tgbotapi.NewMessage(chatID, "📝 Henüz hiçbir dosyaya açıklama eklenmemiş!"))
 		return
 	}
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("📝 *Tüm Dosya Açıklamaları (%d adet):*\n\n", len(fileMetadata)))
-	for filename, meta := range fileMetadata {
-		builder.WriteString(fmt.Sprintf("📄 `%s`\n   💬 _%s_\n\n", filename, meta.Description))
+	builder.WriteString(fmt.Sprintf("📝 *Tüm Dosya Açıklamaları (%d adet):*\n\n", len(visible)))
+	for filename, description := range visible {
+		builder.WriteString(fmt.Sprintf("📄 `%s`\n   💬 _%s_\n\n", filename, description))
 	}
 	builder.WriteString("💡 *Dosya almak için:* `/getir dosya_adı.uzantı`")
 	bot.Send(tgbotapi.NewMessage(chatID, builder.String()))
 }
 
 // handleSearchDescriptionsCommand, açıklamalarda ve dosya adlarında arama yapar.
-func handleSearchDescriptionsCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleSearchDescriptionsCommand(bot Messenger, message *tgbotapi.Message) {
 	keyword := message.CommandArguments()
 	chatID := message.Chat.ID
 	if keyword == "" {
@@ -301,6 +326,7 @@ func handleSearchDescriptionsCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Mes
 		return
 	}
 	results := searchDescriptions(keyword)
+	filterAccessibleDescriptions(message.From.ID, results)
 	if len(results) == 0 {
 		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Açıklamalarda `%s` ile eşleşen sonuç bulunamadı.", keyword)))
 		return
@@ -315,8 +341,18 @@ func handleSearchDescriptionsCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Mes
 }
 
 // handleListFilesCommand, ana dizindeki dosyaları listeler.
-func handleListFilesCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleListFilesCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
+	if !canAccessCategory(message.From.ID, rootCategoryName) {
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return
+	}
+	_, tagExpression := splitTagFilter(message.CommandArguments())
+	filter, err := newTagFilter(tagExpression)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Etiket ifadesi hatalı: %v", err)))
+		return
+	}
 	files, err := os.ReadDir(config.BaseDir)
 	if err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, "❌ Ana klasördeki dosyalar okunurken bir hata oluştu."))
@@ -324,11 +360,15 @@ func handleListFilesCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	}
 	var fileNames []string
 	for _, file := range files {
-		if !file.IsDir() {
+		if !file.IsDir() && !isIncomingTemp(file.Name()) && filter.Match(filepath.Join(config.BaseDir, file.Name())) {
 			fileNames = append(fileNames, file.Name())
 		}
 	}
 	if len(fileNames) == 0 {
+		if filter != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📁 Ana klasörde `%s` etiket ifadesiyle eşleşen dosya yok.", tagExpression)))
+			return
+		}
 		bot.Send(tgbotapi.NewMessage(chatID, "📁 Ana klasör boş!"))
 		return
 	}
@@ -348,31 +388,47 @@ func handleListFilesCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 }
 
 // handleListCategoryCommand, belirli bir kategori klasöründeki dosyaları listeler.
-func handleListCategoryCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	category := message.CommandArguments()
+func handleListCategoryCommand(bot Messenger, message *tgbotapi.Message) {
+	category, tagExpression := splitTagFilter(message.CommandArguments())
 	chatID := message.Chat.ID
 	if category == "" {
 		var cats []string
-		for k := range kategoriler {
+		for k := range fileCategories() {
 			cats = append(cats, k)
 		}
 		sort.Strings(cats)
 		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Kategori belirtmediniz.\nMevcut Kategoriler:\n`%s`", strings.Join(cats, "`, `"))))
 		return
 	}
-	categoryDir := filepath.Join(config.BaseDir, category)
-	files, err := os.ReadDir(categoryDir)
+	if !canAccessCategory(message.From.ID, category) {
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return
+	}
+	filter, err := newTagFilter(tagExpression)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Etiket ifadesi hatalı: %v", err)))
+		return
+	}
+	categoryDir, err := resolveInBase(category)
+	var files []os.DirEntry
+	if err == nil {
+		files, err = os.ReadDir(categoryDir)
+	}
 	if err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` kategorisi bulunamadı veya okunamadı.", category)))
 		return
 	}
 	var fileNames []string
 	for _, file := range files {
-		if !file.IsDir() {
+		if !file.IsDir() && filter.Match(filepath.Join(categoryDir, file.Name())) {
 			fileNames = append(fileNames, file.Name())
 		}
 	}
 	if len(fileNames) == 0 {
+		if filter != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📁 `%s` klasöründe `%s` etiket ifadesiyle eşleşen dosya yok.", category, tagExpression)))
+			return
+		}
 		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📁 `%s` klasörü boş.", category)))
 		return
 	}
@@ -391,11 +447,16 @@ func handleListCategoryCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 }
 
 // handleSearchFilesCommand, tüm klasörlerde dosya adı araması yapar.
-func handleSearchFilesCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
-	keyword := message.CommandArguments()
+func handleSearchFilesCommand(bot Messenger, message *tgbotapi.Message) {
+	keyword, tagExpression := splitTagFilter(message.CommandArguments())
 	chatID := message.Chat.ID
-	if keyword == "" {
-		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/ara <anahtar_kelime>`"))
+	if keyword == "" && tagExpression == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/ara <anahtar_kelime> [#etiket...]`"))
+		return
+	}
+	filter, err := newTagFilter(tagExpression)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Etiket ifadesi hatalı: %v", err)))
 		return
 	}
 	type FoundFile struct {
@@ -408,49 +469,61 @@ func handleSearchFilesCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.Contains(strings.ToLower(info.Name()), strings.ToLower(keyword)) {
+		if !info.IsDir() && strings.Contains(strings.ToLower(info.Name()), strings.ToLower(keyword)) && canAccessPath(message.From.ID, path) && filter.Match(path) {
 			relPath, _ := filepath.Rel(config.BaseDir, path)
 			foundFiles = append(foundFiles, FoundFile{Name: info.Name(), Path: relPath})
 		}
 		return nil
 	})
+	query := strings.TrimSpace(keyword + " " + tagExpression)
 	if len(foundFiles) == 0 {
+		if filter != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` ile eşleşen dosya bulunamadı.", query)))
+			return
+		}
 		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Adında `%s` geçen dosya bulunamadı.", keyword)))
 		return
 	}
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("🔍 *Arama Sonuçları: `%s` (%d adet)*\n\n", keyword, len(foundFiles)))
+	builder.WriteString(fmt.Sprintf("🔍 *Arama Sonuçları: `%s` (%d adet)*\n\n", query, len(foundFiles)))
 	for _, file := range foundFiles {
-		builder.WriteString(fmt.Sprintf("📄 `%s`\n   _Konum: %s_\n\n", file.Name, filepath.Dir(file.Path)))
+		builder.WriteString(fmt.Sprintf("📄 `%s`\n   _Konum: %s_\n\n", file.Name, filepath.ToSlash(filepath.Dir(file.Path))))
 	}
-	builder.WriteString("💡 *Dosya almak için:* `/getir <dosya_adı>`")
+	builder.WriteString("💡 *Dosya almak için:* `/getir <dosya_adı>` veya `/getir <klasör/dosya_adı>`")
 	msg := tgbotapi.NewMessage(chatID, builder.String())
 	msg.ParseMode = "Markdown"
 	bot.Send(msg)
 }
 
 // handleDeleteFileCommand, bir dosyayı silmek için kullanıcıya onay butonları gönderir.
-func handleDeleteFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleDeleteFileCommand(bot Messenger, message *tgbotapi.Message) {
 	filename := message.CommandArguments()
 	chatID := message.Chat.ID
 	if filename == "" {
 		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/sil <dosya_adı>`"))
 		return
 	}
-	_, found := findFile(filename)
-	if !found {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Silinecek dosya bulunamadı: `%s`", filename)))
+	filePath, ok := resolveFileForCommand(bot, message, filename, "", fmt.Sprintf("❌ Silinecek dosya bulunamadı: `%s`", filename))
+	if !ok {
 		return
 	}
+	sendDeleteConfirmation(bot, chatID, message.From.ID, filePath)
+}
+
+// sendDeleteConfirmation, dosyayı çöp kutusuna taşımadan önce onay düğmeleri
+// gönderir. `/sil` ve dosya gezgini kullanır.
+func sendDeleteConfirmation(bot Messenger, chatID, userID int64, filePath string) {
 	// Silme işlemi tehlikeli olduğu için inline keyboard ile onay istenir.
-	text := fmt.Sprintf("⚠️ *Emin misiniz?*\n\n`%s` dosyası kalıcı olarak silinecek. Bu işlem geri alınamaz.", filename)
-	yesButtonData := fmt.Sprintf("sil_evet_%s", filename)
-	noButtonData := fmt.Sprintf("sil_iptal_%s", filename)
+	// Göreli yol düğme verisine sığmayabileceği için sunucuda saklanır ve
+	// onayı yalnızca komutu gönderen kullanıcı verebilir.
+	key := fileKey(filePath)
+	text := fmt.Sprintf("⚠️ *Emin misiniz?*\n\n`%s` dosyası çöp kutusuna taşınacak. Saklama süresi dolana kadar `/geri_al` ile geri alabilirsiniz.", key)
+	id := newCallbackPayload(userID, key)
 
 	var keyboard = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ Evet, Sil", yesButtonData),
-			tgbotapi.NewInlineKeyboardButtonData("❌ İptal", noButtonData),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Evet, Sil", callbackData("sil", "evet", id)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ İptal", callbackData("sil", "iptal", id)),
 		),
 	)
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -459,8 +532,53 @@ func handleDeleteFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	bot.Send(msg)
 }
 
+// handleDeleteCallback, silme onayı düğmelerini işler. Onaylanan dosya çöp
+// kutusuna taşınır ve işlem günlüğüne yazılır.
+func handleDeleteCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	userID := callbackQuery.From.ID
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 {
+		return
+	}
+	action, id := parts[1], parts[2]
+
+	key, err := takeCallbackPayload(id, userID)
+	if errors.Is(err, errPayloadForeign) {
+		// * Onayı yalnızca silme isteğini gönderen kullanıcı verebilir.
+		return
+	}
+	var newText string
+	if action != "evet" {
+		newText = "👍 Silme işlemi iptal edildi."
+	} else if err != nil {
+		newText = "⌛ Bu onayın süresi dolmuş. Lütfen `/sil` komutunu yeniden gönderin."
+	} else {
+		filePath, found := pathOfKey(key)
+		if found {
+			_, statErr := os.Stat(filePath)
+			found = statErr == nil
+		}
+		if !found {
+			newText = fmt.Sprintf("❌ Hata: `%s` dosyası zaten silinmiş veya taşınmış.", key)
+		} else if !canAccessPath(userID, filePath) {
+			newText = categoryDeniedText
+		} else if entry, err := moveToTrash(userID, filePath); err != nil {
+			log.Printf("Dosya çöp kutusuna taşınamadı (%s): %v", filePath, err)
+			newText = fmt.Sprintf("❌ `%s` dosyası silinirken bir hata oluştu.", key)
+		} else {
+			recordOperation(userID, operationDelete, OperationStep{From: key, TrashID: entry.ID})
+			newText = fmt.Sprintf("🗑️ `%s` çöp kutusuna taşındı.\n♻️ Geri almak için: `/geri_al %d`", key, entry.ID)
+		}
+	}
+	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+}
+
 // handleRenameFileCommand, bir dosyanın adını değiştirir.
-func handleRenameFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleRenameFileCommand(bot Messenger, message *tgbotapi.Message) {
 	args := message.CommandArguments()
 	chatID := message.Chat.ID
 	parts := strings.SplitN(args, " ", 2)
@@ -470,37 +588,69 @@ func handleRenameFileCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	}
 	oldName := parts[0]
 	newName := parts[1]
-	oldPath, found := findFile(oldName)
-	if !found {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dosya bulunamadı: `%s`", oldName)))
+	// Yeni ad yalnızca bir dosya adı olabilir; taşımak için `/tasi` kullanılır.
+	if isPathQuery(newName) || newName == "." || newName == ".." {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Yeni ad klasör içeremez. Taşımak için `/tasi` komutunu kullanın."))
 		return
 	}
-	newPath := filepath.Join(filepath.Dir(oldPath), newName)
-	err := os.Rename(oldPath, newPath)
+	oldPath, ok := resolveFileForCommand(bot, message, oldName, " "+newName, fmt.Sprintf("❌ Dosya bulunamadı: `%s`", oldName))
+	if !ok {
+		return
+	}
+	newPath, err := renameFileTo(message.From.ID, oldPath, newName)
 	if err != nil {
-		log.Printf("Dosya yeniden adlandırılamadı: %v", err)
-		bot.Send(tgbotapi.NewMessage(chatID, "❌ Dosya yeniden adlandırılırken bir hata oluştu."))
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
 		return
 	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Dosya yeniden adlandırıldı:\n`%s` -> `%s`", fileKey(oldPath), fileKey(newPath))))
+}
+
+// renameFileTo, bir dosyayı aynı klasörde yeniden adlandırır, işlemi
+// günlüğe yazar ve açıklamasını yeni ada aktarır. Hata mesajları kullanıcıya
+// gösterilecek biçimdedir. `/yenidenadlandir` ve dosya gezgini kullanır.
+func renameFileTo(userID int64, oldPath, newName string) (string, error) {
+	// Yeni ad yalnızca bir dosya adı olabilir; taşımak için `/tasi` kullanılır.
+	if isPathQuery(newName) || newName == "." || newName == ".." {
+		return "", fmt.Errorf("Yeni ad klasör içeremez. Taşımak için `/tasi` komutunu kullanın.")
+	}
+	newName, err := sanitizeFileName(newName)
+	if err != nil {
+		return "", fmt.Errorf("Geçersiz dosya adı.")
+	}
+	newPath := filepath.Join(filepath.Dir(oldPath), newName)
+	if !isInsideBase(newPath) {
+		return "", fmt.Errorf("Geçersiz dosya adı.")
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return "", fmt.Errorf("Bu klasörde `%s` adında bir dosya zaten var.", newName)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		log.Printf("Dosya yeniden adlandırılamadı: %v", err)
+		return "", fmt.Errorf("Dosya yeniden adlandırılırken bir hata oluştu.")
+	}
+	recordMove(userID, operationRename, oldPath, newPath)
 
 	// Varsa, dosya açıklamasını da yeni dosyaya taşır.
-	if desc, ok := getDescription(oldName); ok {
-		removeDescription(oldName)
-		addDescription(newName, desc)
+	if err := moveFileMetadata(fileKey(oldPath), fileKey(newPath)); err != nil {
+		log.Printf("Açıklama yeni dosya adına taşınamadı: %v", err)
 	}
-	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Dosya yeniden adlandırıldı:\n`%s` -> `%s`", oldName, newName)))
+	if err := moveFileVersions(fileKey(oldPath), fileKey(newPath)); err != nil {
+		log.Printf("Sürümler yeni dosya adına taşınamadı: %v", err)
+	}
+	return newPath, nil
 }
 
 // handlePortsCommand, yapılandırmada belirtilen portların durumunu kontrol eder.
-func handlePortsCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handlePortsCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
-	if len(config.MonitoredPorts) == 0 {
-		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ İzlenecek port listesi .env dosyasında ayarlanmamış veya boş."))
+	ports := monitoredPorts()
+	if len(ports) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ İzlenecek port listesi yapılandırmada ayarlanmamış veya boş."))
 		return
 	}
 
 	var portsToCheck []int
-	for port := range config.MonitoredPorts {
+	for port := range ports {
 		portsToCheck = append(portsToCheck, port)
 	}
 
@@ -517,7 +667,7 @@ func handlePortsCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	sort.Ints(sortedPorts)
 
 	for _, port := range sortedPorts {
-		serviceName := config.MonitoredPorts[port]
+		serviceName := ports[port]
 		if info, ok := activePorts[port]; ok {
 			builder.WriteString(fmt.Sprintf("🟢 *%s* (Port %d): KULLANIMDA\n   - `%s` (PID: %d)\n", serviceName, port, info.Name, info.PID))
 		} else {
@@ -539,19 +689,44 @@ type ProcessDetail struct {
 const processesPerPage = 15
 
 // handleListProcessesCommand, interaktif görev yöneticisini başlatır.
-func handleListProcessesCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleListProcessesCommand(bot Messenger, message *tgbotapi.Message) {
 	msg := createProcessListMessage(message.Chat.ID, "cpu", "desc", 1)
 	bot.Send(msg)
 }
 
+// handleProcessListCallback, görev yöneticisinin sıralama ve sayfa
+// düğmelerini işler. Düğme verisi `gorevler_<işlem>_<alan>_<yön>_<sayfa>`
+// biçimindedir.
+func handleProcessListCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	parts := strings.Split(callbackQuery.Data, "_")
+	if len(parts) != 5 {
+		return
+	}
+	action, sortKey, sortDir := parts[1], parts[2], parts[3]
+	page, _ := strconv.Atoi(parts[4])
+	if action == "sirala" {
+		page = 1
+	}
+
+	updatedMessage := createProcessListMessage(chatID, sortKey, sortDir, page)
+	editMsg := tgbotapi.NewEditMessageText(chatID, callbackQuery.Message.MessageID, updatedMessage.Text)
+	editMsg.ParseMode = "Markdown"
+	if updatedMessage.ReplyMarkup != nil {
+		markup := updatedMessage.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+		editMsg.ReplyMarkup = &markup
+	}
+	bot.Request(editMsg)
+}
+
 // handleRunApplicationCommand, .env dosyasında tanımlı bir uygulamayı kısayol adıyla başlatır.
-func handleRunApplicationCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleRunApplicationCommand(bot Messenger, message *tgbotapi.Message) {
 	appName := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
 	chatID := message.Chat.ID
 
 	if appName == "" {
 		var availableApps []string
-		for name := range config.Uygulamalar {
+		for name := range applications() {
 			availableApps = append(availableApps, name)
 		}
 		sort.Strings(availableApps)
@@ -561,7 +736,7 @@ func handleRunApplicationCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 		return
 	}
 
-	appPath, found := config.Uygulamalar[appName]
+	appPath, found := applications()[appName]
 	if !found {
 		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` adında bir uygulama kısayolu bulunamadı!", appName)))
 		return
@@ -586,7 +761,7 @@ func handleRunApplicationCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 }
 
 // handleRunPathCommand, tam dosya yolu belirtilerek bir uygulama başlatır.
-func handleRunPathCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleRunPathCommand(bot Messenger, message *tgbotapi.Message) {
 	path := message.CommandArguments()
 	chatID := message.Chat.ID
 
@@ -0,0 +1,390 @@
+// command_registry.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                             KOMUT KAYDI
+// #############################################################################
+// Bu dosya, botun tanıdığı tüm komutların tek kaynağıdır. Her komut; adını,
+// takma adlarını, argüman şemasını, yetki seviyesini, yardım metnini ve
+// işleyicisini burada bildirir. `/help` metni, yetki kontrolleri, argüman
+// doğrulaması ve Telegram'ın komut menüsü (`setMyCommands`) bu listeden
+// otomatik olarak üretilir. Yeni bir komut eklemek için `init` içindeki
+// listeye bir kayıt eklemek yeterlidir.
+
+// CommandArg, bir komutun tek bir argümanını tanımlar.
+type CommandArg struct {
+	Name     string
+	Optional bool
+	// Rest, argümanın komutun geri kalan tüm metnini (boşluklar dahil)
+	// kapsadığını belirtir. Sadece son argüman için kullanılabilir.
+	Rest bool
+}
+
+// CommandSpec, bir komutun bildirimini (deklarasyonunu) tutar.
+type CommandSpec struct {
+	Name    string
+	Aliases []string
+	Args    []CommandArg
+	// Role, komutu çalıştırmak için gereken en düşük roldür (bkz. `auth.go`).
+	// Boş bırakılırsa tüm kayıtlı kullanıcılar (izleyici ve üstü) çalıştırabilir.
+	Role  string
+	Group string
+	Help  string
+	// Hidden, komutun `/help` listesinde gösterilmemesini sağlar.
+	// Telegram menüsünde yine de yer alır.
+	Hidden  bool
+	Handler func(bot Messenger, message *tgbotapi.Message)
+}
+
+// Yardım metnindeki bölüm başlıkları, gösterilecekleri sırayla.
+const (
+	groupAssistant = "^^ *Akıllı Asistan (Sentinel):*"
+	groupFiles     = "== *Dosya Yönetimi:*"
+	groupSearch    = "oo *Arama ve Listeleme:*"
+	groupNotes     = ":: *Açıklama ve Etiket Yönetimi:*"
+	groupMedia     = "//  *İndirme ve Medya İşleme:*"
+	groupSystem    = "==| *Sistem ve İşlem Yönetimi:*"
+	groupExec      = "++ *Uygulama & Betik Çalıştırma (Yönetici):*"
+	groupUsers     = "## *Kullanıcı ve Yetki Yönetimi (Yönetici):*"
+)
+
+var helpGroups = []string{groupAssistant, groupFiles, groupSearch, groupNotes, groupMedia, groupSystem, groupExec, groupUsers}
+
+var (
+	// commandList, komutları kayıt sırasıyla tutar (yardım ve menü sırası).
+	commandList []*CommandSpec
+	// commandIndex, komut adlarını ve takma adlarını bildirimlere eşler.
+	commandIndex = make(map[string]*CommandSpec)
+)
+
+// registerCommand, bir komutu kayda ekler. Aynı ad iki kez kaydedilirse
+// program başlarken çöker; bu bir programlama hatasıdır.
+func registerCommand(spec *CommandSpec) {
+	for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+		if _, exists := commandIndex[name]; exists {
+			log.Panicf("Komut iki kez kaydedildi: /%s", name)
+		}
+		commandIndex[name] = spec
+	}
+	commandList = append(commandList, spec)
+}
+
+// lookupCommand, bir komut adını veya takma adını bildirimine çevirir.
+func lookupCommand(name string) (*CommandSpec, bool) {
+	spec, found := commandIndex[strings.ToLower(name)]
+	return spec, found
+}
+
+// Usage, komutun argüman şemasından `/komut <zorunlu> [isteğe_bağlı]`
+// biçiminde bir kullanım satırı üretir.
+func (spec *CommandSpec) Usage() string {
+	var builder strings.Builder
+	builder.WriteString("/" + spec.Name)
+	for _, arg := range spec.Args {
+		if arg.Optional {
+			builder.WriteString(fmt.Sprintf(" [%s]", arg.Name))
+		} else {
+			builder.WriteString(fmt.Sprintf(" <%s>", arg.Name))
+		}
+	}
+	return builder.String()
+}
+
+// validateArgs, komutla gelen argümanların şemaya uyup uymadığını kontrol eder.
+func (spec *CommandSpec) validateArgs(args string) error {
+	fields := strings.Fields(args)
+	required := 0
+	takesRest := false
+	for _, arg := range spec.Args {
+		if !arg.Optional {
+			required++
+		}
+		if arg.Rest {
+			takesRest = true
+		}
+	}
+	if len(fields) < required {
+		return fmt.Errorf("eksik argüman")
+	}
+	if !takesRest && len(fields) > len(spec.Args) {
+		return fmt.Errorf("fazla argüman")
+	}
+	return nil
+}
+
+// buildHelpText, kayıtlı komutlardan gruplanmış `/help` metnini üretir.
+// Sadece kullanıcının çalıştırabileceği komutlar listelenir.
+func buildHelpText(userID int64) string {
+	var builder strings.Builder
+	builder.WriteString("*-Komut Seti –*")
+	for _, group := range helpGroups {
+		var lines []string
+		for _, spec := range commandList {
+			if spec.Hidden || spec.Group != group || !canRunCommand(userID, spec) {
+				continue
+			}
+			names := []string{"`" + spec.Usage() + "`"}
+			for _, alias := range spec.Aliases {
+				names = append(names, "`/"+alias+"`")
+			}
+			line := fmt.Sprintf("%s – %s", strings.Join(names, ", "), spec.Help)
+			if spec.Role == roleAdmin && group != groupExec && group != groupUsers {
+				line += " (Yönetici)"
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		builder.WriteString("\n\n" + group + "\n")
+		builder.WriteString(strings.Join(lines, "\n"))
+	}
+	return builder.String()
+}
+
+// menuCommands, gizli olmayan ve `allowed` tarafından kabul edilen komutları
+// Telegram menüsü girdilerine çevirir.
+func menuCommands(allowed func(spec *CommandSpec) bool) []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, spec := range commandList {
+		if spec.Hidden || !allowed(spec) {
+			continue
+		}
+		commands = append(commands, tgbotapi.BotCommand{Command: spec.Name, Description: spec.Help})
+	}
+	return commands
+}
+
+// registerBotMenu, kayıtlı komutları Telegram'ın komut menüsüne (`setMyCommands`) yükler.
+// Varsayılan menüde yalnızca en düşük rolün komutları bulunur; her kullanıcı
+// kendi sohbetinde çalıştırabildiği komutları `refreshUserMenu` ile görür.
+func registerBotMenu(bot Messenger) {
+	commands := menuCommands(func(spec *CommandSpec) bool {
+		return roleLevel(spec.Role) <= roleLevel(roleViewer)
+	})
+	if _, err := bot.Request(tgbotapi.NewSetMyCommands(commands...)); err != nil {
+		log.Printf("Komut menüsü Telegram'a yüklenemedi: %v", err)
+		return
+	}
+	log.Printf("%d komut Telegram menüsüne yüklendi.", len(commands))
+	refreshAllUserMenus(bot)
+}
+
+// refreshUserMenu, kullanıcının sohbetine özel komut menüsünü yetkilerine göre
+// günceller. Erişimi kalmayan kullanıcının özel menüsü silinir ve varsayılan
+// menüye döner.
+func refreshUserMenu(bot Messenger, userID int64) {
+	scope := tgbotapi.NewBotCommandScopeChat(userID)
+	if account, _ := lookupAccount(userID); account == nil {
+		if _, err := bot.Request(tgbotapi.NewDeleteMyCommandsWithScope(scope)); err != nil {
+			log.Printf("%d kullanıcısının komut menüsü silinemedi: %v", userID, err)
+		}
+		return
+	}
+	commands := menuCommands(func(spec *CommandSpec) bool { return canRunCommand(userID, spec) })
+	if _, err := bot.Request(tgbotapi.NewSetMyCommandsWithScope(scope, commands...)); err != nil {
+		log.Printf("%d kullanıcısının komut menüsü yüklenemedi: %v", userID, err)
+	}
+}
+
+// refreshAllUserMenus, kayıtlı tüm kullanıcıların menüsünü günceller. Bir
+// rolün izinleri değiştiğinde hangi kullanıcıların etkilendiği ayrıca
+// hesaplanmaz.
+func refreshAllUserMenus(bot Messenger) {
+	for _, account := range listAccounts() {
+		refreshUserMenu(bot, account.ID)
+	}
+}
+
+func init() {
+	// --- Akıllı Asistan ---
+	registerCommand(&CommandSpec{Name: "start", Group: groupAssistant, Hidden: true,
+		Help: "Botu başlatır ve karşılama mesajını gösterir", Handler: handleStartCommand})
+	registerCommand(&CommandSpec{Name: "help", Aliases: []string{"yardim"}, Group: groupAssistant, Hidden: true,
+		Help: "Tüm komutları listeler", Handler: handleHelpCommand})
+	registerCommand(&CommandSpec{Name: "llm", Group: groupAssistant,
+		Help: "Yapay zeka ile sohbet modunu başlatır", Handler: handleLlmOnCommand})
+	registerCommand(&CommandSpec{Name: "llm_kapat", Group: groupAssistant,
+		Help: "Aktif sohbet modunu sonlandırır", Handler: func(bot Messenger, message *tgbotapi.Message) {
+			if !isUserInLlmMode(message.From.ID) {
+				bot.Send(tgbotapi.NewMessage(message.Chat.ID, "ℹ️ Zaten LLM modunda değilsiniz."))
+				return
+			}
+			handleLlmOffCommand(bot, message)
+		}})
+
+	// --- Dosya Yönetimi ---
+	registerCommand(&CommandSpec{Name: "getir", Group: groupFiles,
+		Args: []CommandArg{{Name: "dosya", Rest: true}},
+		Help: "Dosyayı gönder", Handler: handleGetFileCommand})
+	registerCommand(&CommandSpec{Name: "sil", Role: roleOperator, Group: groupFiles,
+		Args: []CommandArg{{Name: "dosya", Rest: true}},
+		Help: "Dosyayı çöp kutusuna taşı (onaylı)", Handler: handleDeleteFileCommand})
+	registerCommand(&CommandSpec{Name: "yenidenadlandir", Role: roleOperator, Group: groupFiles,
+		Args: []CommandArg{{Name: "eski"}, {Name: "yeni", Rest: true}},
+		Help: "Dosyayı yeniden adlandır", Handler: handleRenameFileCommand})
+	registerCommand(&CommandSpec{Name: "tasi", Role: roleOperator, Group: groupFiles,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "klasör", Rest: true}},
+		Help: "Dosyayı taşı", Handler: handleMoveFileCommand})
+	registerCommand(&CommandSpec{Name: "cop", Group: groupFiles,
+		Help: "Çöp kutusundaki dosyaları göster", Handler: handleTrashListCommand})
+	registerCommand(&CommandSpec{Name: "geri_al", Role: roleOperator, Group: groupFiles,
+		Args: []CommandArg{{Name: "id"}},
+		Help: "Silinen dosyayı çöp kutusundan geri al", Handler: handleTrashRestoreCommand})
+	registerCommand(&CommandSpec{Name: "cop_bosalt", Role: roleAdmin, Group: groupFiles,
+		Help: "Çöp kutusunu kalıcı olarak boşalt (onaylı)", Handler: handleTrashPurgeCommand})
+	registerCommand(&CommandSpec{Name: "islemler", Role: roleOperator, Group: groupFiles,
+		Args: []CommandArg{{Name: "kullanıcı_id", Optional: true}},
+		Help: "Son dosya işlemlerini göster", Handler: handleOperationsCommand})
+	registerCommand(&CommandSpec{Name: "geri_al_son", Role: roleOperator, Group: groupFiles,
+		Args: []CommandArg{{Name: "sayı", Optional: true}},
+		Help: "Son dosya işlemlerini geri al", Handler: handleUndoCommand})
+	registerCommand(&CommandSpec{Name: "surumler", Group: groupFiles,
+		Args: []CommandArg{{Name: "dosya", Rest: true}},
+		Help: "Dosyanın saklanan eski sürümlerini listele", Handler: handleVersionsCommand})
+	registerCommand(&CommandSpec{Name: "surum_getir", Group: groupFiles,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "no", Optional: true}},
+		Help: "Dosyanın eski bir sürümünü gönder (varsayılan: en yenisi)", Handler: handleVersionGetCommand})
+	registerCommand(&CommandSpec{Name: "kopyalar", Role: roleOperator, Group: groupFiles,
+		Help: "Aynı içerikli dosyaları listele ve temizle", Handler: handleDuplicatesCommand})
+
+	// --- Arama ve Listeleme ---
+	registerCommand(&CommandSpec{Name: "ara", Group: groupSearch,
+		Args: []CommandArg{{Name: "kelime"}, {Name: "#etiket", Optional: true, Rest: true}},
+		Help: "Dosya adlarında ara (etikete göre süzülebilir)", Handler: handleSearchFilesCommand})
+	registerCommand(&CommandSpec{Name: "liste", Group: groupSearch,
+		Args: []CommandArg{{Name: "#etiket", Optional: true, Rest: true}},
+		Help: "Ana klasördeki dosyaları göster", Handler: handleListFilesCommand})
+	registerCommand(&CommandSpec{Name: "gezgin", Group: groupSearch,
+		Args: []CommandArg{{Name: "klasör", Optional: true, Rest: true}},
+		Help: "Klasörleri düğmelerle gez ve dosya işlemleri yap", Handler: handleBrowserCommand})
+	registerCommand(&CommandSpec{Name: "klasor", Group: groupSearch,
+		Args: []CommandArg{{Name: "kategori", Optional: true}, {Name: "#etiket", Optional: true, Rest: true}},
+		Help: "Kategori klasörünü listele", Handler: handleListCategoryCommand})
+	registerCommand(&CommandSpec{Name: "icerik_ara", Group: groupSearch,
+		Args: []CommandArg{{Name: "sorgu", Rest: true}},
+		Help: "Belgelerin içeriğinde ara (pdf, docx, odt, txt, md, kod)", Handler: handleContentSearchCommand})
+
+	// --- Açıklama ve Etiket Yönetimi ---
+	registerCommand(&CommandSpec{Name: "aciklama_ekle", Role: roleOperator, Group: groupNotes,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "açıklama", Rest: true}},
+		Help: "Dosyaya açıklama ekle", Handler: handleAddDescriptionCommand})
+	registerCommand(&CommandSpec{Name: "aciklama_sil", Role: roleOperator, Group: groupNotes,
+		Args: []CommandArg{{Name: "dosya", Rest: true}},
+		Help: "Dosyanın açıklamasını sil", Handler: handleRemoveDescriptionCommand})
+	registerCommand(&CommandSpec{Name: "aciklamalar", Group: groupNotes,
+		Help: "Tüm açıklamaları listele", Handler: handleListDescriptionsCommand})
+	registerCommand(&CommandSpec{Name: "aciklama_ara", Group: groupNotes,
+		Args: []CommandArg{{Name: "kelime", Rest: true}},
+		Help: "Açıklamalarda ara", Handler: handleSearchDescriptionsCommand})
+	registerCommand(&CommandSpec{Name: "etiket_ekle", Role: roleOperator, Group: groupNotes,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "etiketler", Optional: true, Rest: true}},
+		Help: "Dosyaya etiket ekle (etiketsiz: öneri göster)", Handler: handleAddTagsCommand})
+	registerCommand(&CommandSpec{Name: "etiket_sil", Role: roleOperator, Group: groupNotes,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "etiketler", Rest: true}},
+		Help: "Dosyadan etiket kaldır", Handler: handleRemoveTagsCommand})
+	registerCommand(&CommandSpec{Name: "etiketler", Group: groupNotes,
+		Args: []CommandArg{{Name: "dosya|ifade", Optional: true, Rest: true}},
+		Help: "Etiketleri, bir dosyanın etiketlerini veya ifadeyle eşleşen dosyaları listele", Handler: handleListTagsCommand})
+
+	// --- İndirme ve Medya İşleme ---
+	registerCommand(&CommandSpec{Name: "indir", Role: roleOperator, Group: groupMedia,
+		Args: []CommandArg{{Name: "URL"}, {Name: "kalite", Optional: true}, {Name: "format", Optional: true}},
+		Help: "Video/dosya indir", Handler: handleDownloadCommand})
+	registerCommand(&CommandSpec{Name: "indir_ses", Role: roleOperator, Group: groupMedia,
+		Args: []CommandArg{{Name: "URL"}, {Name: "format", Optional: true}, {Name: "kalite", Optional: true}},
+		Help: "Sadece sesi indir", Handler: handleAudioDownloadCommand})
+	registerCommand(&CommandSpec{Name: "kes", Role: roleOperator, Group: groupMedia,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "baş"}, {Name: "bitiş"}},
+		Help: "Video kes", Handler: handleClipCommand})
+	registerCommand(&CommandSpec{Name: "gif_yap", Role: roleOperator, Group: groupMedia,
+		Args: []CommandArg{{Name: "dosya"}, {Name: "baş"}, {Name: "bitiş"}},
+		Help: "GIF üret", Handler: handleGifCommand})
+
+	// --- Sistem ve İşlem Yönetimi ---
+	registerCommand(&CommandSpec{Name: "gorevler", Group: groupSystem, Role: roleAdmin,
+		Help: "İnteraktif görev yöneticisi", Handler: handleListProcessesCommand})
+	registerCommand(&CommandSpec{Name: "kapat", Group: groupSystem, Role: roleAdmin,
+		Args: []CommandArg{{Name: "PID"}},
+		Help: "Çalışan işlemi durdur", Handler: handleKillCommand})
+	registerCommand(&CommandSpec{Name: "durum", Group: groupSystem,
+		Help: "Temel sistem durumu", Handler: handleStatusCommand})
+	registerCommand(&CommandSpec{Name: "sistem_bilgisi", Group: groupSystem, Role: roleAdmin,
+		Help: "Ayrıntılı sistem bilgisi", Handler: handleSystemInfoCommand})
+	registerCommand(&CommandSpec{Name: "hiz_testi", Role: roleOperator, Group: groupSystem,
+		Help: "İndirme/yükleme hızı ve ping ölçümü", Handler: handleSpeedTestCommand})
+	registerCommand(&CommandSpec{Name: "portlar", Group: groupSystem,
+		Help: "İzlenen port durumları", Handler: handlePortsCommand})
+	registerCommand(&CommandSpec{Name: "ss", Group: groupSystem, Role: roleAdmin,
+		Help: "Ekran görüntüsü al", Handler: handleScreenshotCommand})
+	registerCommand(&CommandSpec{Name: "kayit_al", Group: groupSystem, Role: roleAdmin,
+		Help: "Ekran kaydını başlat", Handler: handleStartRecordingCommand})
+	registerCommand(&CommandSpec{Name: "kayit_durdur", Group: groupSystem, Role: roleAdmin,
+		Help: "Ekran kaydını durdur ve gönder", Handler: handleStopRecordingCommand})
+	registerCommand(&CommandSpec{Name: "duzenle", Role: roleOperator, Group: groupSystem,
+		Help: "Dosyaları kurallara ve kategorilere göre düzenle", Handler: handleOrganizeCommand})
+	registerCommand(&CommandSpec{Name: "kurallar", Role: roleOperator, Group: groupSystem,
+		Args: []CommandArg{{Name: "test|deneme", Optional: true}, {Name: "dosya", Optional: true, Rest: true}},
+		Help: "Düzenleme kurallarını listele, bir dosyada dene veya kuru çalıştır", Handler: handleRulesCommand})
+	registerCommand(&CommandSpec{Name: "izle", Role: roleOperator, Group: groupSystem,
+		Help: "Ağ bağlantısını izlemeye başla/durdur", Handler: handleToggleInternetMonitorCommand})
+	registerCommand(&CommandSpec{Name: "config_dogrula", Group: groupSystem, Role: roleAdmin,
+		Help: "config.yaml dosyasını doğrula ve hataları raporla", Handler: handleConfigValidateCommand})
+
+	// --- Uygulama & Betik Çalıştırma ---
+	registerCommand(&CommandSpec{Name: "calistir", Group: groupExec, Role: roleAdmin,
+		Args: []CommandArg{{Name: "yol"}, {Name: "süre"}},
+		Help: "Betik çalıştır ve çıktısını al", Handler: handleRunCommand})
+	registerCommand(&CommandSpec{Name: "uygulama_calistir", Group: groupExec, Role: roleAdmin,
+		Args: []CommandArg{{Name: "kısayol", Optional: true}},
+		Help: "Önceden tanımlı uygulamayı başlat", Handler: handleRunApplicationCommand})
+	registerCommand(&CommandSpec{Name: "calistir_dosya", Group: groupExec, Role: roleAdmin,
+		Args: []CommandArg{{Name: "yol", Rest: true}},
+		Help: "Dosya yolu ile uygulama başlat", Handler: handleRunPathCommand})
+
+	// --- Kullanıcı ve Yetki Yönetimi ---
+	registerCommand(&CommandSpec{Name: "kullanicilar", Role: roleAdmin, Group: groupUsers,
+		Help: "Kayıtlı kullanıcıları ve izinlerini listele", Handler: handleListUsersCommand})
+	registerCommand(&CommandSpec{Name: "kullanici_ekle", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "id"}, {Name: "rol", Optional: true}, {Name: "ad", Optional: true, Rest: true}},
+		Help: "Yeni kullanıcı ekle (varsayılan rol: izleyici)", Handler: handleAddUserCommand})
+	registerCommand(&CommandSpec{Name: "kullanici_sil", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "id"}},
+		Help: "Kullanıcının erişimini kaldır", Handler: handleRemoveUserCommand})
+	registerCommand(&CommandSpec{Name: "rol_ver", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "id"}, {Name: "rol"}},
+		Help: "Kullanıcının rolünü değiştir", Handler: handleSetRoleCommand})
+	registerCommand(&CommandSpec{Name: "roller", Role: roleAdmin, Group: groupUsers,
+		Help: "Tanımlı rolleri listele", Handler: handleListRolesCommand})
+	registerCommand(&CommandSpec{Name: "rol_ekle", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "ad"}, {Name: "şablon_rol"}},
+		Help: "Mevcut bir rolden yeni rol oluştur", Handler: handleAddRoleCommand})
+	registerCommand(&CommandSpec{Name: "yetki_ver", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "id|rol"}, {Name: "izin"}},
+		Help: "Komut, araç veya kategori izni ver (örn. `arac:delete_file`)", Handler: handleGrantCommand})
+	registerCommand(&CommandSpec{Name: "yetki_al", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "id|rol"}, {Name: "izin"}},
+		Help: "Verilen bir izni geri al", Handler: handleRevokeCommand})
+	registerCommand(&CommandSpec{Name: "davet", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "rol", Optional: true}, {Name: "kod_süresi", Optional: true}, {Name: "erişim_süresi", Optional: true}, {Name: "kullanım", Optional: true}},
+		Help: "Davet kodu oluştur (varsayılan: izleyici, 24h, kalıcı, tek kullanım)", Handler: handleCreateInviteCommand})
+	registerCommand(&CommandSpec{Name: "davetler", Role: roleAdmin, Group: groupUsers,
+		Help: "Aktif davet kodlarını listele", Handler: handleListInvitesCommand})
+	registerCommand(&CommandSpec{Name: "davet_iptal", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "kod"}},
+		Help: "Bir davet kodunu iptal et", Handler: handleRevokeInviteCommand})
+	registerCommand(&CommandSpec{Name: "denetim", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "kullanıcı", Optional: true}, {Name: "tarih", Optional: true}},
+		Help: "Komut, callback ve araç çağrılarının denetim kaydını göster", Handler: handleAuditCommand})
+	registerCommand(&CommandSpec{Name: "denetim_indir", Role: roleAdmin, Group: groupUsers,
+		Args: []CommandArg{{Name: "csv|json"}, {Name: "kullanıcı", Optional: true}, {Name: "tarih", Optional: true}},
+		Help: "Denetim kaydını CSV veya JSON olarak dışa aktar", Handler: handleAuditExportCommand})
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,19 +15,136 @@ import (
 
 type Config struct {
 	BotToken         string
+	BotAPIURL        string
+	BotAPILocal      bool
+	BotAPIServerDir  string
+	BotAPILocalDir   string
 	BaseDir          string
 	MetadataFilePath string
+	MetadataDBPath   string
+	MetadataBackend  string
+	ContentIndexPath string
+	HashCachePath    string
+	TrashDir         string
+	TrashRetention   time.Duration
+	PartsDir         string
+	SplitPartSize    int64
+	VersionsDir      string
+	VersionsKeep     int
+	VersionsRetention time.Duration
+	IncomingCollision string
+	JournalPath      string
+	UsersFilePath    string
+	AuditLogPath     string
 	MonitoredPorts   map[int]string
 	AdminChatID      int64
 	AllowedIDs       []int64
 	GeminiAPIKey     string
 	WorkerIntervalInternet time.Duration
 	WorkerIntervalPort     time.Duration
+	DispatcherWorkers      int
+	DispatcherUserLimit    int
+	ShutdownTimeout        time.Duration
 	Uygulamalar map[string]string
+
+	// `config.yaml` ile gelen ayarlar (bkz. `config_file.go`).
+	ConfigFilePath   string
+	Categories       map[string][]string
+	OrganizeRules    []*organizeRule
+	LLMModels        []string
+	SystemPromptFile string
+
+	// Webhook ayarları. `WebhookURL` boşsa uzun yoklama kullanılır.
+	WebhookURL            string
+	WebhookListen         string
+	WebhookPathSecret     string
+	WebhookSecretToken    string
+	WebhookCertFile       string
+	WebhookKeyFile        string
+	WebhookUploadCert     bool
+	WebhookMaxConnections int
 }
 
 var config Config
 
+// configMutex, çalışma anında yeniden yüklenebilen alanları (portlar,
+// uygulamalar, kategoriler, worker aralıkları, LLM ayarları) korur. Bu
+// alanlar doğrudan değil, aşağıdaki fonksiyonlar üzerinden okunmalıdır.
+var configMutex = &sync.RWMutex{}
+
+// monitoredPorts, izlenen port -> servis adı haritasını döndürür.
+// Harita yeniden yüklemede yenisiyle değiştirildiği için okunması güvenlidir.
+func monitoredPorts() map[int]string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.MonitoredPorts
+}
+
+// applications, uygulama kısayolu -> yol haritasını döndürür.
+func applications() map[string]string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.Uygulamalar
+}
+
+// fileCategories, kategori -> uzantılar haritasını döndürür.
+func fileCategories() map[string][]string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.Categories
+}
+
+// organizeRules, sırayla denenecek düzenleme kurallarını döndürür.
+func organizeRules() []*organizeRule {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.OrganizeRules
+}
+
+// llmModels, sırayla denenecek Gemini modellerini döndürür.
+func llmModels() []string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.LLMModels
+}
+
+// systemPromptFile, LLM sistem prompt'unun okunacağı dosyanın yolunu döndürür.
+func systemPromptFile() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.SystemPromptFile
+}
+
+// workerIntervals, internet ve port kontrolü aralıklarını döndürür.
+func workerIntervals() (internet, port time.Duration) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.WorkerIntervalInternet, config.WorkerIntervalPort
+}
+
+// shutdownTimeout, kapanışta devam eden işler için beklenecek süreyi döndürür.
+func shutdownTimeout() time.Duration {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.ShutdownTimeout
+}
+
+// trashRetention, çöp kutusundaki dosyaların kalıcı olarak silinmeden önce
+// saklanacağı süreyi döndürür.
+func trashRetention() time.Duration {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.TrashRetention
+}
+
+// versionRetention, dosya başına saklanacak en fazla sürüm sayısını ve
+// sürümlerin saklanacağı en uzun süreyi döndürür. Sıfır, sınır yok demektir.
+func versionRetention() (int, time.Duration) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return config.VersionsKeep, config.VersionsRetention
+}
+
 func loadConfig() error {
 	if err := godotenv.Load(); err != nil {
 		log.Println("Uyarı: .env dosyası bulunamadı.")
@@ -37,6 +155,18 @@ func loadConfig() error {
 		return fmt.Errorf("BOT_TOKEN .env dosyasında bulunamadı")
 	}
 
+	// Kendi Bot API sunucunuz (isteğe bağlı). Ayrıntılar için `messenger.go`.
+	config.BotAPIURL = strings.TrimRight(os.Getenv("BOT_API_URL"), "/")
+	config.BotAPILocal, _ = strconv.ParseBool(os.Getenv("BOT_API_LOCAL"))
+	config.BotAPIServerDir = os.Getenv("BOT_API_SERVER_DIR")
+	config.BotAPILocalDir = os.Getenv("BOT_API_LOCAL_DIR")
+	if config.BotAPIURL != "" && !strings.HasPrefix(config.BotAPIURL, "http://") && !strings.HasPrefix(config.BotAPIURL, "https://") {
+		return fmt.Errorf("BOT_API_URL http:// veya https:// ile başlamalıdır: %s", config.BotAPIURL)
+	}
+	if config.BotAPILocal && config.BotAPIURL == "" {
+		log.Println("Uyarı: BOT_API_LOCAL yalnızca BOT_API_URL ile birlikte kullanılabilir, yok sayılıyor.")
+	}
+
 	config.GeminiAPIKey = os.Getenv("GEMINI_API_KEY")
 	if config.GeminiAPIKey == "" {
 		log.Println("Uyarı: GEMINI_API_KEY .env dosyasında bulunamadı. LLM özelliği çalışmayacak.")
@@ -58,9 +188,11 @@ func loadConfig() error {
 		parts := strings.Split(allowedIDsStr, ",")
 		for _, part := range parts {
 			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
-			if err == nil {
-				config.AllowedIDs = append(config.AllowedIDs, id)
+			if err != nil {
+				log.Printf("Uyarı: ALLOWED_IDS içindeki değer geçersiz, atlanıyor: '%s'", part)
+				continue
 			}
+			config.AllowedIDs = append(config.AllowedIDs, id)
 		}
 		log.Printf("%d adet izinli kullanıcı yüklendi.", len(config.AllowedIDs))
 	}
@@ -74,6 +206,58 @@ func loadConfig() error {
 	log.Printf("Ana çalışma klasörü ayarlandı: %s", config.BaseDir)
 	
 	config.MetadataFilePath = "metadata.json"
+	config.MetadataDBPath = "metadata.db"
+	// Açıklamaların saklanacağı arka uç: "bolt" (varsayılan) veya "json".
+	config.MetadataBackend = strings.ToLower(os.Getenv("METADATA_BACKEND"))
+	if config.MetadataBackend == "" {
+		config.MetadataBackend = metadataBackendBolt
+	}
+	config.ContentIndexPath = "content_index.gob"
+	config.HashCachePath = "file_hashes.gob"
+	config.UsersFilePath = "users.json"
+	config.JournalPath = "operations.json"
+
+	// Silinen dosyalar bu klasöre taşınır (bkz. `recycle_bin.go`). Ana klasörün
+	// dışında olmalıdır; aksi halde silinen dosyalar aramalarda görünür.
+	config.TrashDir = os.Getenv("TRASH_DIR")
+	if config.TrashDir == "" {
+		config.TrashDir = "Cop"
+	}
+	trashDays, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || trashDays <= 0 { trashDays = 30 }
+	config.TrashRetention = time.Duration(trashDays) * 24 * time.Hour
+	config.AuditLogPath = "audit.jsonl"
+
+	// Parçalı gönderim (bkz. `file_split.go`): parça boyutu (MB) ve bota
+	// gönderilen parçaların birleştirilene kadar bekletildiği klasör.
+	config.PartsDir = os.Getenv("PARTS_DIR")
+	if config.PartsDir == "" {
+		config.PartsDir = "Parcalar"
+	}
+	partSizeMB, err := strconv.Atoi(os.Getenv("SPLIT_PART_SIZE_MB"))
+	if err != nil || partSizeMB <= 0 { partSizeMB = 45 }
+	config.SplitPartSize = int64(partSizeMB) * 1024 * 1024
+
+	// Gelen dosyalar (bkz. `incoming_files.go`): aynı adda dosya varsa ne
+	// yapılacağı ve üzerine yazılan dosyaların eski hallerinin saklandığı
+	// klasör (bkz. `file_versions.go`). Sürüm klasörü ana klasörün dışında olmalıdır.
+	config.IncomingCollision = strings.ToLower(strings.TrimSpace(os.Getenv("INCOMING_COLLISION")))
+	if config.IncomingCollision == "" {
+		config.IncomingCollision = collisionRename
+	} else if !validCollisionPolicy(config.IncomingCollision) {
+		log.Printf("Uyarı: INCOMING_COLLISION geçersiz ('%s'), 'rename' kullanılacak. Geçerli değerler: rename, overwrite, ask, version", config.IncomingCollision)
+		config.IncomingCollision = collisionRename
+	}
+	config.VersionsDir = os.Getenv("VERSIONS_DIR")
+	if config.VersionsDir == "" {
+		config.VersionsDir = "Surumler"
+	}
+	versionsKeep, err := strconv.Atoi(os.Getenv("VERSIONS_KEEP"))
+	if err != nil || versionsKeep < 0 { versionsKeep = 10 }
+	config.VersionsKeep = versionsKeep
+	versionDays, _ := strconv.Atoi(os.Getenv("VERSIONS_RETENTION_DAYS"))
+	if versionDays < 0 { versionDays = 0 }
+	config.VersionsRetention = time.Duration(versionDays) * 24 * time.Hour
 
 	config.MonitoredPorts = make(map[int]string)
 	portsStr := os.Getenv("MONITORED_PORTS")
@@ -81,10 +265,16 @@ func loadConfig() error {
 		services := strings.Split(portsStr, ",")
 		for _, service := range services {
 			parts := strings.SplitN(strings.TrimSpace(service), ":", 2)
-			if len(parts) != 2 { continue }
+			if len(parts) != 2 {
+				log.Printf("Uyarı: MONITORED_PORTS içindeki tanım geçersiz, atlanıyor: '%s'", service)
+				continue
+			}
 			name := parts[0]
 			port, err := strconv.Atoi(parts[1])
-			if err != nil { continue }
+			if err != nil || port < 1 || port > 65535 {
+				log.Printf("Uyarı: MONITORED_PORTS içindeki port geçersiz, atlanıyor: '%s'", service)
+				continue
+			}
 			config.MonitoredPorts[port] = name
 		}
 	}
@@ -97,6 +287,21 @@ func loadConfig() error {
 	if err != nil || portInterval <= 0 { portInterval = 5 }
 	config.WorkerIntervalPort = time.Duration(portInterval) * time.Second
 
+	// Güncelleme dağıtıcısı: eş zamanlı worker sayısı ve kullanıcı başına
+	// bekleyen/işlenen en fazla istek sayısı (bkz. `dispatcher.go`).
+	dispatcherWorkers, err := strconv.Atoi(os.Getenv("DISPATCHER_WORKERS"))
+	if err != nil || dispatcherWorkers <= 0 { dispatcherWorkers = 8 }
+	config.DispatcherWorkers = dispatcherWorkers
+
+	dispatcherUserLimit, err := strconv.Atoi(os.Getenv("DISPATCHER_USER_LIMIT"))
+	if err != nil || dispatcherUserLimit <= 0 { dispatcherUserLimit = 5 }
+	config.DispatcherUserLimit = dispatcherUserLimit
+
+	// Kapanışta devam eden işler için beklenecek en uzun süre (saniye).
+	shutdownTimeout, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT"))
+	if err != nil || shutdownTimeout <= 0 { shutdownTimeout = 30 }
+	config.ShutdownTimeout = time.Duration(shutdownTimeout) * time.Second
+
 	config.Uygulamalar = make(map[string]string)
 	uygulamalarStr := os.Getenv("UYGULAMALAR")
 	if uygulamalarStr != "" {
@@ -113,6 +318,41 @@ func loadConfig() error {
 		}
 	}
 
+	// Kategoriler ve LLM ayarları için varsayılanlar; `config.yaml` varsa
+	// bunların ve yukarıdaki değerlerin üzerine yazılır.
+	config.Categories = defaultCategories()
+	config.LLMModels = []string{"gemini-2.5-flash", "gemini-2.0-flash", "gemini-2.5-flash-lite"}
+	config.SystemPromptFile = "system_prompt.txt"
+	config.ConfigFilePath = os.Getenv("CONFIG_FILE")
+	if config.ConfigFilePath == "" {
+		config.ConfigFilePath = "config.yaml"
+	}
+
+	// Webhook modu (isteğe bağlı). Ayrıntılar için `update_source.go`.
+	config.WebhookURL = os.Getenv("WEBHOOK_URL")
+	config.WebhookListen = os.Getenv("WEBHOOK_LISTEN")
+	if config.WebhookListen == "" {
+		config.WebhookListen = ":8443"
+	}
+	config.WebhookPathSecret = strings.Trim(os.Getenv("WEBHOOK_PATH_SECRET"), "/")
+	config.WebhookSecretToken = os.Getenv("WEBHOOK_SECRET_TOKEN")
+	config.WebhookCertFile = os.Getenv("WEBHOOK_TLS_CERT")
+	config.WebhookKeyFile = os.Getenv("WEBHOOK_TLS_KEY")
+	config.WebhookUploadCert, _ = strconv.ParseBool(os.Getenv("WEBHOOK_UPLOAD_CERT"))
+	config.WebhookMaxConnections, _ = strconv.Atoi(os.Getenv("WEBHOOK_MAX_CONNECTIONS"))
+	if config.WebhookURL != "" {
+		if !strings.HasPrefix(config.WebhookURL, "https://") {
+			return fmt.Errorf("WEBHOOK_URL https:// ile başlamalıdır: %s", config.WebhookURL)
+		}
+		if (config.WebhookCertFile == "") != (config.WebhookKeyFile == "") {
+			return fmt.Errorf("WEBHOOK_TLS_CERT ve WEBHOOK_TLS_KEY birlikte ayarlanmalıdır")
+		}
+	}
+
+	// Yapılandırma dosyası .env değerlerini geçersiz kılar.
+	if err := loadFileConfig(); err != nil {
+		return err
+	}
 
 	log.Println("Yapılandırma başarıyla yüklendi.")
 	return nil
@@ -0,0 +1,458 @@
+// config_file.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// #############################################################################
+// #                        YAPILANDIRMA DOSYASI (config.yaml)
+// #############################################################################
+// Bu dosya, `config.yaml` dosyasının okunmasını, doğrulanmasını ve bot
+// çalışırken değiştiğinde yeniden yüklenmesini (hot reload) yönetir.
+// Gizli bilgiler (BOT_TOKEN, GEMINI_API_KEY, ADMIN_CHAT_ID) `.env` içinde
+// kalır; izlenen portlar, uygulama kısayolları, kategoriler, worker
+// aralıkları, düzenleme kuralları ve LLM ayarları bu dosyada tanımlanır. Dosya yoksa `.env`
+// içindeki eski değişkenler (MONITORED_PORTS, UYGULAMALAR vb.) kullanılır.
+//
+// Bazı ayarlar (base_dir, dispatcher, trash.dir) çalışma anında değiştirilemez;
+// bunlar dosyada değişirse yeniden başlatma gerektiği bildirilir.
+
+// FileConfig, `config.yaml` dosyasının yapısını temsil eder.
+type FileConfig struct {
+	BaseDir         string               `yaml:"base_dir"`
+	AllowedIDs      []int64              `yaml:"allowed_ids"`     // yalnızca users.json ilk kez oluşturulurken
+	MonitoredPorts  map[string]int       `yaml:"monitored_ports"` // servis adı -> port
+	Applications    map[string]string    `yaml:"applications"`    // kısayol -> uygulama yolu
+	Categories      map[string][]string  `yaml:"categories"`      // kategori -> uzantılar
+	Rules           []OrganizeRuleConfig `yaml:"rules"`           // bkz. organizer_rules.go
+	ShutdownTimeout time.Duration        `yaml:"shutdown_timeout"`
+	Workers         struct {
+		InternetInterval time.Duration `yaml:"internet_interval"`
+		PortInterval     time.Duration `yaml:"port_interval"`
+	} `yaml:"workers"`
+	Dispatcher struct {
+		Workers   int `yaml:"workers"`
+		UserLimit int `yaml:"user_limit"`
+	} `yaml:"dispatcher"`
+	Trash struct {
+		Dir       string        `yaml:"dir"`
+		Retention time.Duration `yaml:"retention"`
+	} `yaml:"trash"`
+	Versions struct {
+		Keep      *int          `yaml:"keep"` // dosya başına sürüm sayısı; 0 sınırsız
+		Retention time.Duration `yaml:"retention"`
+	} `yaml:"versions"`
+	LLM struct {
+		Models           []string `yaml:"models"`
+		SystemPromptFile string   `yaml:"system_prompt_file"`
+	} `yaml:"llm"`
+}
+
+// configIssues, doğrulama sırasında bulunan hataları ve uyarıları tutar.
+// Hatalar dosyanın uygulanmasını engeller, uyarılar engellemez.
+type configIssues struct {
+	Errors   []string
+	Warnings []string
+}
+
+func (c *configIssues) errorf(format string, args ...any) {
+	c.Errors = append(c.Errors, fmt.Sprintf(format, args...))
+}
+
+func (c *configIssues) warnf(format string, args ...any) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
+}
+
+// configFileMutex, yeniden yükleme işlemlerinin üst üste binmesini engeller.
+var configFileMutex = &sync.Mutex{}
+
+// readFileConfig, yapılandırma dosyasını okur ve çözümler. Bilinmeyen
+// alanlar (yazım hataları) da hata olarak raporlanır.
+func readFileConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fc FileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("YAML çözümlenemedi: %w", err)
+	}
+	return &fc, nil
+}
+
+// validateFileConfig, dosyadaki değerleri kontrol eder. Hatalı girdiler
+// eskisi gibi sessizce atlanmaz, tamamı raporlanır.
+func validateFileConfig(fc *FileConfig) configIssues {
+	var issues configIssues
+
+	if fc.BaseDir != "" && strings.TrimSpace(fc.BaseDir) == "" {
+		issues.errorf("base_dir boş olamaz")
+	}
+
+	seenPorts := make(map[int]string)
+	for name, port := range fc.MonitoredPorts {
+		if strings.TrimSpace(name) == "" {
+			issues.errorf("monitored_ports: servis adı boş olamaz (port %d)", port)
+		}
+		if port < 1 || port > 65535 {
+			issues.errorf("monitored_ports.%s: geçersiz port %d (1-65535)", name, port)
+			continue
+		}
+		if other, dup := seenPorts[port]; dup {
+			issues.errorf("monitored_ports: %d portu hem `%s` hem `%s` için tanımlı", port, other, name)
+		}
+		seenPorts[port] = name
+	}
+
+	for shortcut, path := range fc.Applications {
+		if strings.TrimSpace(shortcut) == "" || strings.ContainsAny(shortcut, " \t") {
+			issues.errorf("applications: geçersiz kısayol `%s` (boşluk içeremez)", shortcut)
+		}
+		if strings.TrimSpace(path) == "" {
+			issues.errorf("applications.%s: uygulama yolu boş", shortcut)
+		} else if _, err := os.Stat(path); err != nil {
+			issues.warnf("applications.%s: `%s` bulunamadı", shortcut, path)
+		}
+	}
+
+	seenExt := make(map[string]string)
+	for category, extensions := range fc.Categories {
+		if strings.TrimSpace(category) == "" || strings.ContainsAny(category, `/\:*?"<>|`) {
+			issues.errorf("categories: geçersiz kategori adı `%s`", category)
+		}
+		if category == otherCategoryName || category == rootCategoryName {
+			issues.errorf("categories: `%s` ayrılmış bir addır", category)
+		}
+		if len(extensions) == 0 {
+			issues.warnf("categories.%s: hiç uzantı tanımlanmamış", category)
+		}
+		for _, ext := range extensions {
+			if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+				issues.errorf("categories.%s: uzantı `%s` nokta ile başlamalı (örn. .pdf)", category, ext)
+				continue
+			}
+			ext = strings.ToLower(ext)
+			if other, dup := seenExt[ext]; dup && other != category {
+				issues.errorf("categories: `%s` uzantısı hem `%s` hem `%s` kategorisinde", ext, other, category)
+			}
+			seenExt[ext] = category
+		}
+	}
+
+	_, ruleProblems := compileOrganizeRules(fc.Rules)
+	for _, problem := range ruleProblems {
+		issues.errorf("%s", problem)
+	}
+
+	if fc.Workers.InternetInterval < 0 || (fc.Workers.InternetInterval > 0 && fc.Workers.InternetInterval < time.Second) {
+		issues.errorf("workers.internet_interval en az 1s olmalı")
+	}
+	if fc.Workers.PortInterval < 0 || (fc.Workers.PortInterval > 0 && fc.Workers.PortInterval < time.Second) {
+		issues.errorf("workers.port_interval en az 1s olmalı")
+	}
+	if fc.ShutdownTimeout < 0 {
+		issues.errorf("shutdown_timeout negatif olamaz")
+	}
+	if fc.Dispatcher.Workers < 0 || fc.Dispatcher.Workers > 256 {
+		issues.errorf("dispatcher.workers 1-256 arasında olmalı")
+	}
+	if fc.Dispatcher.UserLimit < 0 {
+		issues.errorf("dispatcher.user_limit negatif olamaz")
+	}
+	if fc.Trash.Dir != "" && strings.TrimSpace(fc.Trash.Dir) == "" {
+		issues.errorf("trash.dir boş olamaz")
+	}
+	if fc.Trash.Retention < 0 || (fc.Trash.Retention > 0 && fc.Trash.Retention < time.Hour) {
+		issues.errorf("trash.retention en az 1h olmalı")
+	}
+	if fc.Versions.Keep != nil && *fc.Versions.Keep < 0 {
+		issues.errorf("versions.keep negatif olamaz")
+	}
+	if fc.Versions.Retention < 0 || (fc.Versions.Retention > 0 && fc.Versions.Retention < time.Hour) {
+		issues.errorf("versions.retention en az 1h olmalı")
+	}
+
+	for i, model := range fc.LLM.Models {
+		if strings.TrimSpace(model) == "" {
+			issues.errorf("llm.models[%d]: model adı boş", i)
+		}
+	}
+	if fc.LLM.SystemPromptFile != "" {
+		if _, err := os.Stat(fc.LLM.SystemPromptFile); err != nil {
+			issues.warnf("llm.system_prompt_file: `%s` bulunamadı", fc.LLM.SystemPromptFile)
+		}
+	}
+
+	sort.Strings(issues.Errors)
+	sort.Strings(issues.Warnings)
+	return issues
+}
+
+// applyFileConfig, doğrulanmış dosya değerlerini `config` yapısına yazar.
+// `initial` false ise (yeniden yükleme) çalışma anında değiştirilemeyen
+// ayarlar uygulanmaz ve değiştiklerinde geri dönen listede bildirilir.
+func applyFileConfig(fc *FileConfig, initial bool) (needsRestart []string) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	if fc.BaseDir != "" {
+		if initial {
+			config.BaseDir = fc.BaseDir
+		} else if fc.BaseDir != config.BaseDir {
+			needsRestart = append(needsRestart, "base_dir")
+		}
+	}
+	if fc.Trash.Dir != "" {
+		if initial {
+			config.TrashDir = fc.Trash.Dir
+		} else if fc.Trash.Dir != config.TrashDir {
+			needsRestart = append(needsRestart, "trash.dir")
+		}
+	}
+	if initial && len(fc.AllowedIDs) > 0 {
+		config.AllowedIDs = fc.AllowedIDs
+	}
+	if fc.Dispatcher.Workers > 0 {
+		if initial {
+			config.DispatcherWorkers = fc.Dispatcher.Workers
+		} else if fc.Dispatcher.Workers != config.DispatcherWorkers {
+			needsRestart = append(needsRestart, "dispatcher.workers")
+		}
+	}
+	if fc.Dispatcher.UserLimit > 0 {
+		if initial {
+			config.DispatcherUserLimit = fc.Dispatcher.UserLimit
+		} else if fc.Dispatcher.UserLimit != config.DispatcherUserLimit {
+			needsRestart = append(needsRestart, "dispatcher.user_limit")
+		}
+	}
+
+	// * Haritalar hiçbir zaman yerinde değiştirilmez, her yüklemede yenisiyle
+	// * değiştirilir. Böylece getter'lardan alınan eski harita güvenle okunabilir.
+	if fc.MonitoredPorts != nil {
+		ports := make(map[int]string, len(fc.MonitoredPorts))
+		for name, port := range fc.MonitoredPorts {
+			ports[port] = name
+		}
+		config.MonitoredPorts = ports
+	}
+	if fc.Applications != nil {
+		apps := make(map[string]string, len(fc.Applications))
+		for shortcut, path := range fc.Applications {
+			apps[strings.ToLower(shortcut)] = path
+		}
+		config.Uygulamalar = apps
+	}
+	if fc.Categories != nil {
+		categories := make(map[string][]string, len(fc.Categories))
+		for category, extensions := range fc.Categories {
+			for _, ext := range extensions {
+				categories[category] = append(categories[category], strings.ToLower(ext))
+			}
+		}
+		config.Categories = categories
+	}
+	if fc.Rules != nil {
+		config.OrganizeRules, _ = compileOrganizeRules(fc.Rules)
+	}
+	if fc.Workers.InternetInterval > 0 {
+		config.WorkerIntervalInternet = fc.Workers.InternetInterval
+	}
+	if fc.Workers.PortInterval > 0 {
+		config.WorkerIntervalPort = fc.Workers.PortInterval
+	}
+	if fc.ShutdownTimeout > 0 {
+		config.ShutdownTimeout = fc.ShutdownTimeout
+	}
+	if fc.Trash.Retention > 0 {
+		config.TrashRetention = fc.Trash.Retention
+	}
+	if fc.Versions.Keep != nil {
+		config.VersionsKeep = *fc.Versions.Keep
+	}
+	if fc.Versions.Retention > 0 {
+		config.VersionsRetention = fc.Versions.Retention
+	}
+	if len(fc.LLM.Models) > 0 {
+		config.LLMModels = append([]string(nil), fc.LLM.Models...)
+	}
+	if fc.LLM.SystemPromptFile != "" {
+		config.SystemPromptFile = fc.LLM.SystemPromptFile
+	}
+	return needsRestart
+}
+
+// loadFileConfig, başlangıçta yapılandırma dosyasını yükler. Dosya yoksa
+// sessizce `.env` değerleriyle devam edilir; dosya hatalıysa bot başlamaz.
+func loadFileConfig() error {
+	fc, err := readFileConfig(config.ConfigFilePath)
+	if os.IsNotExist(err) {
+		log.Printf("%s bulunamadı, yalnızca .env değerleri kullanılacak.", config.ConfigFilePath)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	issues := validateFileConfig(fc)
+	for _, w := range issues.Warnings {
+		log.Printf("Yapılandırma uyarısı: %s", w)
+	}
+	if len(issues.Errors) > 0 {
+		return fmt.Errorf("%s geçersiz:\n - %s", config.ConfigFilePath, strings.Join(issues.Errors, "\n - "))
+	}
+	applyFileConfig(fc, true)
+	log.Printf("%s yüklendi.", config.ConfigFilePath)
+	return nil
+}
+
+// reloadFileConfig, dosyayı yeniden okur; geçerliyse uygular. Hatalıysa
+// mevcut yapılandırma korunur.
+func reloadFileConfig() (configIssues, []string, error) {
+	configFileMutex.Lock()
+	defer configFileMutex.Unlock()
+
+	fc, err := readFileConfig(config.ConfigFilePath)
+	if err != nil {
+		return configIssues{}, nil, err
+	}
+	issues := validateFileConfig(fc)
+	if len(issues.Errors) > 0 {
+		return issues, nil, nil
+	}
+	needsRestart := applyFileConfig(fc, false)
+	ensureDirectories()
+	if err := loadSystemPrompt(); err != nil {
+		log.Printf("Sistem prompt'u yeniden yüklenemedi: %v", err)
+	}
+	return issues, needsRestart, nil
+}
+
+// configReloadDebounce, art arda gelen dosya olaylarının tek bir yeniden
+// yüklemeye indirgenmesi için beklenen süredir (editörler dosyayı birkaç
+// adımda yazar).
+const configReloadDebounce = 500 * time.Millisecond
+
+// watchConfigFile, yapılandırma dosyasını izler ve değiştiğinde yeniden
+// yükler. Sonuç yöneticiye bildirilir. `ctx` iptal edildiğinde durur.
+func watchConfigFile(ctx context.Context, bot Messenger) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Yapılandırma izleyicisi oluşturulamadı: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	// * Editörler dosyayı silip yeniden oluşturabildiği için dosyanın kendisi
+	// * değil, bulunduğu klasör izlenir.
+	absPath, _ := filepath.Abs(config.ConfigFilePath)
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		log.Printf("Yapılandırma klasörü izlenemiyor: %v", err)
+		return
+	}
+	log.Printf("Yapılandırma dosyası değişiklikler için izleniyor: %s", absPath)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if eventPath, _ := filepath.Abs(event.Name); eventPath != absPath {
+				continue
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				debounce = time.After(configReloadDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Yapılandırma izleyici hatası: %v", err)
+		case <-debounce:
+			debounce = nil
+			notifyConfigReload(bot)
+		}
+	}
+}
+
+// notifyConfigReload, dosyayı yeniden yükler ve sonucu loglayıp yöneticiye bildirir.
+func notifyConfigReload(bot Messenger) {
+	issues, needsRestart, err := reloadFileConfig()
+	var text string
+	switch {
+	case os.IsNotExist(err):
+		return
+	case err != nil:
+		log.Printf("Yapılandırma yeniden yüklenemedi: %v", err)
+		text = fmt.Sprintf("⚠️ %s yeniden yüklenemedi, eski ayarlar geçerli.\n\n%v", config.ConfigFilePath, err)
+	case len(issues.Errors) > 0:
+		log.Printf("Yapılandırma geçersiz, değişiklikler uygulanmadı: %s", strings.Join(issues.Errors, "; "))
+		text = fmt.Sprintf("⚠️ %s geçersiz, değişiklikler uygulanmadı:\n\n%s", config.ConfigFilePath, formatConfigIssues(issues))
+	default:
+		log.Printf("%s yeniden yüklendi.", config.ConfigFilePath)
+		text = fmt.Sprintf("🔄 %s yeniden yüklendi.", config.ConfigFilePath)
+		if len(issues.Warnings) > 0 {
+			text += "\n\n" + formatConfigIssues(issues)
+		}
+		if len(needsRestart) > 0 {
+			text += "\n\n♻️ Şu ayarlar yeniden başlatınca geçerli olacak: " + strings.Join(needsRestart, ", ")
+		}
+	}
+	if config.AdminChatID != 0 {
+		// * Markdown kullanılmıyor: hata metinleri dosya içeriğinden gelir.
+		bot.Send(tgbotapi.NewMessage(config.AdminChatID, text))
+	}
+}
+
+// formatConfigIssues, hata ve uyarıları madde listesi olarak biçimlendirir.
+func formatConfigIssues(issues configIssues) string {
+	var builder strings.Builder
+	for _, e := range issues.Errors {
+		builder.WriteString("❌ " + e + "\n")
+	}
+	for _, w := range issues.Warnings {
+		builder.WriteString("⚠️ " + w + "\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// handleConfigValidateCommand, /config_dogrula komutuyla yapılandırma
+// dosyasını uygulamadan doğrular ve bulunan sorunları raporlar.
+func handleConfigValidateCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	fc, err := readFileConfig(config.ConfigFilePath)
+	if os.IsNotExist(err) {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ %s bulunamadı; bot .env değerleriyle çalışıyor.", config.ConfigFilePath)))
+		return
+	} else if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %s okunamadı:\n\n%v", config.ConfigFilePath, err)))
+		return
+	}
+	issues := validateFileConfig(fc)
+	if len(issues.Errors) == 0 && len(issues.Warnings) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ %s geçerli, sorun bulunamadı.", config.ConfigFilePath)))
+		return
+	}
+	header := fmt.Sprintf("🧪 %s: %d hata, %d uyarı\n\n", config.ConfigFilePath, len(issues.Errors), len(issues.Warnings))
+	bot.Send(tgbotapi.NewMessage(chatID, header+formatConfigIssues(issues)))
+}
@@ -0,0 +1,193 @@
+// content_extract.go
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// #############################################################################
+// #                            METİN ÇIKARMA
+// #############################################################################
+// Bu dosya, içerik indeksinin (bkz. `content_index.go`) dosyalardan düz metin
+// elde etmesini sağlar. Düz metin ve kaynak kod dosyaları doğrudan okunur;
+// `.docx` ve `.odt` birer ZIP arşivi olduğu için içlerindeki XML çözümlenir;
+// `.pdf` dosyaları için saf Go ile yazılmış bir PDF okuyucu kullanılır.
+
+// maxExtractedTextBytes, bir dosyadan alınacak en fazla metin miktarıdır.
+// Çok büyük dosyaların yalnızca başı indekslenir.
+const maxExtractedTextBytes = 2 * 1024 * 1024
+
+// plainTextExtensions, doğrudan metin olarak okunan uzantılardır.
+var plainTextExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true, ".log": true, ".csv": true,
+	".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".xml": true,
+	".html": true, ".htm": true, ".css": true, ".sql": true,
+	".go": true, ".py": true, ".js": true, ".ts": true, ".jsx": true, ".tsx": true,
+	".java": true, ".kt": true, ".c": true, ".h": true, ".cpp": true, ".hpp": true,
+	".cs": true, ".rs": true, ".rb": true, ".php": true, ".swift": true, ".lua": true,
+	".sh": true, ".bat": true, ".cmd": true, ".ps1": true,
+}
+
+// isIndexableFile, dosyanın uzantısına göre içeriğinin indekslenip
+// indekslenemeyeceğini belirler.
+func isIndexableFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".pdf", ".docx", ".odt":
+		return true
+	}
+	return plainTextExtensions[ext]
+}
+
+// extractText, dosyanın türüne göre uygun çıkarıcıyı çağırır.
+func extractText(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return extractPDFText(path)
+	case ".docx":
+		return extractZippedXMLText(path, "word/document.xml", docxSeparators)
+	case ".odt":
+		return extractZippedXMLText(path, "content.xml", odtSeparators)
+	}
+	return extractPlainText(path)
+}
+
+// extractPlainText, metin dosyasını okur. İlk bölümünde NUL baytı bulunan
+// dosyalar ikili (binary) kabul edilir ve atlanır.
+func extractPlainText(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxExtractedTextBytes))
+	if err != nil {
+		return "", err
+	}
+	head := data
+	if len(head) > 8192 {
+		head = head[:8192]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "", fmt.Errorf("ikili dosya")
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		return strings.ToValidUTF8(string(data), " "), nil
+	}
+	return string(data), nil
+}
+
+// XML belgelerinde kapanırken araya ayraç eklenen etiketler. Boş etiketler
+// (`<w:tab/>`, `<text:s/>`) de çözümleyicide bir bitiş etiketi üretir.
+var (
+	docxSeparators = map[string]string{"p": "\n", "tr": "\n", "br": "\n", "tab": " "}
+	odtSeparators  = map[string]string{"p": "\n", "h": "\n", "table-row": "\n", "line-break": "\n", "tab": " ", "s": " "}
+)
+
+// extractZippedXMLText, ZIP arşivindeki bir XML belgesinin metin içeriğini
+// toplar. Paragrafların birbirine yapışmaması için `separators` içindeki
+// etiketler kapanırken araya boşluk veya satır sonu eklenir.
+func extractZippedXMLText(path, member string, separators map[string]string) (string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer archive.Close()
+
+	var entry *zip.File
+	for _, f := range archive.File {
+		if f.Name == member {
+			entry = f
+			break
+		}
+	}
+	if entry == nil {
+		return "", fmt.Errorf("%s bulunamadı", member)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var builder strings.Builder
+	decoder := xml.NewDecoder(io.LimitReader(rc, 4*maxExtractedTextBytes))
+	for builder.Len() < maxExtractedTextBytes {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return builder.String(), fmt.Errorf("XML çözümlenemedi: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			builder.Write(t)
+		case xml.EndElement:
+			builder.WriteString(separators[t.Name.Local])
+		}
+	}
+	return builder.String(), nil
+}
+
+// extractPDFText, PDF dosyasındaki metni sayfa sayfa okur. Okuyucu bozuk
+// dosyalarda panik üretebildiği için bu durum hataya çevrilir.
+func extractPDFText(path string) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PDF okunamadı: %v", r)
+		}
+	}()
+
+	file, reader, err := pdf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var builder strings.Builder
+	for i := 1; i <= reader.NumPage() && builder.Len() < maxExtractedTextBytes; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		writePDFPageText(&builder, page.Content().Text)
+		builder.WriteByte('\n')
+	}
+	return builder.String(), nil
+}
+
+// writePDFPageText, sayfadaki harfleri konumlarına göre birleştirir. PDF'lerde
+// kelime arası boşluklar çoğu zaman karakter olarak değil, harfler arasındaki
+// mesafe olarak bulunur; bu yüzden boşluk ve satır sonları konumdan çıkarılır.
+func writePDFPageText(builder *strings.Builder, glyphs []pdf.Text) {
+	for i, glyph := range glyphs {
+		if i > 0 {
+			prev := glyphs[i-1]
+			size := math.Max(prev.FontSize, 1)
+			gap := glyph.X - (prev.X + prev.W)
+			switch {
+			case math.Abs(glyph.Y-prev.Y) > size*0.5:
+				builder.WriteByte('\n')
+			case gap > size*0.15 && !strings.HasSuffix(prev.S, " ") && !strings.HasPrefix(glyph.S, " "):
+				builder.WriteByte(' ')
+			}
+		}
+		builder.WriteString(pdfLigatures.Replace(glyph.S))
+	}
+}
+
+// pdfLigatures, dizgide tek karakter olarak kullanılan bitişik harfleri açar.
+var pdfLigatures = strings.NewReplacer("ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl")
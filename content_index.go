@@ -0,0 +1,720 @@
+// content_index.go
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                              İÇERİK İNDEKSİ
+// #############################################################################
+// Bu dosya, ana klasördeki belgelerin içeriğinde tam metin araması yapılmasını
+// sağlar. Arka planda çalışan indeksleyici:
+//   - Başlangıçta `content_index.gob` dosyasındaki önceki indeksi yükler ve
+//     yalnızca değişen (boyutu veya değiştirilme zamanı farklı) dosyaları
+//     yeniden okur.
+//   - Ana klasörü ve tüm alt klasörleri fsnotify ile izler; dosya eklendiğinde,
+//     değiştiğinde, taşındığında veya silindiğinde indeksi günceller.
+//   - Kaçırılan olaylara karşı saatte bir tüm ağacı yeniden karşılaştırır.
+//   - Aynı taramada kopya tespiti için dosyaların içerik özetlerini de
+//     günceller (bkz. `duplicate_files.go`).
+// Arama, ters indeks (kelime -> dosyalar) üzerinde yapılır ve sonuçlar BM25
+// puanına göre sıralanır. Kelimeler küçük harfe çevrilir ve Türkçe karakterler
+// sadeleştirilir; böylece "ozet" araması "Özet" kelimesini de bulur.
+
+const (
+	contentIndexVersion        = 1
+	maxIndexFileSize           = 50 * 1024 * 1024 // Daha büyük dosyalar indekslenmez.
+	maxSnippetSourceBytes      = 256 * 1024       // Özet için saklanan metin miktarı.
+	contentIndexDebounce       = 2 * time.Second
+	contentIndexSaveInterval   = 5 * time.Minute
+	contentIndexRescanInterval = 1 * time.Hour
+	maxContentResults          = 10
+	minTermLength              = 2
+	maxTermLength              = 64
+)
+
+// indexExcludedDirs, ana klasör altında içeriği indekslenmeyen klasörlerdir.
+var indexExcludedDirs = map[string]bool{
+	"TelegramaGonder": true, // Magic Folder: dosyalar gönderildikten sonra silinir.
+}
+
+// indexedDocument, indekslenmiş tek bir dosyanın kaydıdır.
+type indexedDocument struct {
+	ModTime int64
+	Size    int64
+	Length  int            // Toplam kelime sayısı (BM25 için).
+	Terms   map[string]int // Kelime -> geçme sayısı.
+	Text    string         // Arama sonucu özetleri için metnin başı.
+}
+
+// contentIndexSnapshot, indeksin diske yazılan biçimidir. Ters indeks
+// yüklenirken belgelerden yeniden kurulur.
+type contentIndexSnapshot struct {
+	Version int
+	Docs    map[string]*indexedDocument
+}
+
+// contentIndex, belgeleri ve ters indeksi tutar.
+type contentIndex struct {
+	mu          sync.RWMutex
+	docs        map[string]*indexedDocument // Göreli yol -> belge
+	postings    map[string]map[string]int   // Kelime -> göreli yol -> geçme sayısı
+	totalLength int
+	ready       bool // İlk tarama tamamlandı mı?
+	dirty       bool // Son kayıttan sonra değişiklik var mı?
+}
+
+var searchIndex = &contentIndex{
+	docs:     make(map[string]*indexedDocument),
+	postings: make(map[string]map[string]int),
+}
+
+// #############################################################################
+// #                               KELİMELER
+// #############################################################################
+
+// termFolder, Türkçe karakterleri ASCII karşılıklarına indirger.
+var termFolder = strings.NewReplacer("ç", "c", "ğ", "g", "ı", "i", "ö", "o", "ş", "s", "ü", "u", "â", "a", "î", "i", "û", "u")
+
+// foldTerm, bir kelimeyi indekste ve sorguda kullanılan biçime çevirir.
+func foldTerm(word string) string {
+	return termFolder.Replace(lowerTagWord(word))
+}
+
+// scanTerms, metindeki harf ve rakamlardan oluşan kelimeleri bayt
+// konumlarıyla birlikte sırayla `fn` fonksiyonuna verir.
+func scanTerms(text string, fn func(term string, start, end int)) {
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			emitTerm(text, start, i, fn)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		emitTerm(text, start, len(text), fn)
+	}
+}
+
+func emitTerm(text string, start, end int, fn func(term string, start, end int)) {
+	if n := utf8.RuneCountInString(text[start:end]); n >= minTermLength && n <= maxTermLength {
+		fn(foldTerm(text[start:end]), start, end)
+	}
+}
+
+// newIndexedDocument, çıkarılan metinden bir belge kaydı oluşturur.
+func newIndexedDocument(text string, info os.FileInfo) *indexedDocument {
+	doc := &indexedDocument{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Terms:   make(map[string]int),
+	}
+	scanTerms(text, func(term string, _, _ int) {
+		doc.Terms[term]++
+		doc.Length++
+	})
+	if len(text) > maxSnippetSourceBytes {
+		cut := maxSnippetSourceBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	doc.Text = text
+	return doc
+}
+
+// #############################################################################
+// #                            İNDEKS İŞLEMLERİ
+// #############################################################################
+
+// put, bir belgeyi ekler veya eski kaydının yerine koyar.
+func (idx *contentIndex) put(key string, doc *indexedDocument) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(key)
+	idx.docs[key] = doc
+	idx.totalLength += doc.Length
+	for term, count := range doc.Terms {
+		posting := idx.postings[term]
+		if posting == nil {
+			posting = make(map[string]int)
+			idx.postings[term] = posting
+		}
+		posting[key] = count
+	}
+	idx.dirty = true
+}
+
+// remove, bir belgeyi indeksten çıkarır.
+func (idx *contentIndex) remove(key string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(key)
+}
+
+// removeTree, bir yolu ve (klasörse) altındaki tüm belgeleri indeksten çıkarır.
+func (idx *contentIndex) removeTree(key string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(key)
+	prefix := key + "/"
+	for other := range idx.docs {
+		if strings.HasPrefix(other, prefix) {
+			idx.removeLocked(other)
+		}
+	}
+}
+
+func (idx *contentIndex) removeLocked(key string) {
+	doc, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for term := range doc.Terms {
+		if posting := idx.postings[term]; posting != nil {
+			delete(posting, key)
+			if len(posting) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+	}
+	idx.totalLength -= doc.Length
+	delete(idx.docs, key)
+	idx.dirty = true
+}
+
+// isCurrent, dosyanın indeksteki kaydının güncel olup olmadığını bildirir.
+func (idx *contentIndex) isCurrent(key string, info os.FileInfo) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	doc, ok := idx.docs[key]
+	return ok && doc.Size == info.Size() && doc.ModTime == info.ModTime().UnixNano()
+}
+
+// status, indeksteki belge sayısını ve ilk taramanın bitip bitmediğini döndürür.
+func (idx *contentIndex) status() (int, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs), idx.ready
+}
+
+// isIndexExcluded, yolun indekslenmeyen bir klasörde olup olmadığını belirler.
+func isIndexExcluded(path string) bool {
+	first := strings.SplitN(fileKey(path), "/", 2)[0]
+	return indexExcludedDirs[first] || isIncomingTemp(filepath.Base(path))
+}
+
+// indexFile, dosya değiştiyse metnini çıkarıp indekse ekler. Metni
+// çıkarılamayan dosyalar da boş olarak kaydedilir; böylece her taramada
+// yeniden denenmezler.
+func (idx *contentIndex) indexFile(path string, info os.FileInfo) {
+	key := fileKey(path)
+	if !isIndexableFile(path) || info.Size() > maxIndexFileSize {
+		idx.remove(key)
+		return
+	}
+	if idx.isCurrent(key, info) {
+		return
+	}
+	text, err := extractText(path)
+	if err != nil {
+		log.Printf("[İçerik İndeksi] %s okunamadı: %v", key, err)
+	}
+	idx.put(key, newIndexedDocument(text, info))
+}
+
+// scanTree, bir klasörü ve alt klasörlerini tarar, klasörleri izleyiciye
+// ekler ve değişen dosyaları indeksler. Bulunan dosyaların anahtarlarını
+// `seen` içine yazar. Tarama iptal edilirse `false` döner.
+func (idx *contentIndex) scanTree(ctx context.Context, root string, watcher *fsnotify.Watcher, seen map[string]bool) bool {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != config.BaseDir && isIndexExcluded(path) {
+				return filepath.SkipDir
+			}
+			if watcher != nil {
+				if err := watcher.Add(path); err != nil {
+					log.Printf("[İçerik İndeksi] %s izlenemiyor: %v", path, err)
+				}
+			}
+			return nil
+		}
+		if isIncomingTemp(info.Name()) {
+			return nil
+		}
+		idx.indexFile(path, info)
+		if _, err := fileHashes.update(path, info); err != nil {
+			log.Printf("[Kopyalar] %s özeti hesaplanamadı: %v", fileKey(path), err)
+		}
+		if seen != nil {
+			seen[fileKey(path)] = true
+		}
+		return nil
+	})
+	return err == nil
+}
+
+// reconcile, tüm ana klasörü indeksle karşılaştırır ve artık var olmayan
+// dosyaları indeksten çıkarır.
+func (idx *contentIndex) reconcile(ctx context.Context, watcher *fsnotify.Watcher) {
+	seen := make(map[string]bool)
+	if !idx.scanTree(ctx, config.BaseDir, watcher, seen) {
+		return
+	}
+	idx.mu.Lock()
+	for key := range idx.docs {
+		if !seen[key] {
+			idx.removeLocked(key)
+		}
+	}
+	idx.ready = true
+	idx.mu.Unlock()
+	fileHashes.prune(seen)
+}
+
+// refreshPath, izleyiciden gelen bir olaydan sonra yolu yeniden değerlendirir.
+func (idx *contentIndex) refreshPath(ctx context.Context, path string, watcher *fsnotify.Watcher) {
+	if isIndexExcluded(path) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		// * Silinen veya taşınan dosya/klasör; taşındığı yer ayrıca bir olay üretir.
+		idx.removeTree(fileKey(path))
+		fileHashes.removeTree(fileKey(path))
+		return
+	}
+	if info.IsDir() {
+		idx.scanTree(ctx, path, watcher, nil)
+		return
+	}
+	idx.indexFile(path, info)
+	if _, err := fileHashes.update(path, info); err != nil {
+		log.Printf("[Kopyalar] %s özeti hesaplanamadı: %v", fileKey(path), err)
+	}
+}
+
+// #############################################################################
+// #                              KALICI KAYIT
+// #############################################################################
+
+// load, önceki çalışmada kaydedilen indeksi yükler.
+func (idx *contentIndex) load(path string) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		log.Printf("[İçerik İndeksi] %s açılamadı: %v", path, err)
+		return
+	}
+	defer file.Close()
+
+	var snapshot contentIndexSnapshot
+	if err := gob.NewDecoder(file).Decode(&snapshot); err != nil || snapshot.Version != contentIndexVersion {
+		log.Printf("[İçerik İndeksi] %s kullanılamıyor, indeks yeniden oluşturulacak.", path)
+		return
+	}
+	for key, doc := range snapshot.Docs {
+		idx.put(key, doc)
+	}
+	idx.mu.Lock()
+	idx.dirty = false
+	idx.mu.Unlock()
+	log.Printf("[İçerik İndeksi] %d belge yüklendi.", len(snapshot.Docs))
+}
+
+// save, indeks değiştiyse geçici bir dosyaya yazıp asıl dosyanın yerine koyar.
+func (idx *contentIndex) save(path string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.dirty {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	snapshot := contentIndexSnapshot{Version: contentIndexVersion, Docs: idx.docs}
+	if err := gob.NewEncoder(tmp).Encode(&snapshot); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	idx.dirty = false
+	return nil
+}
+
+// runContentIndexer, indeksi yükler, ana klasörü tarar ve değişiklikleri
+// izler. `ctx` iptal edildiğinde indeksi kaydedip döner.
+func runContentIndexer(ctx context.Context) {
+	searchIndex.load(config.ContentIndexPath)
+	fileHashes.load(config.HashCachePath)
+
+	// * İzleyici oluşturulamazsa indeks yalnızca saatlik taramalarla güncellenir.
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[İçerik İndeksi] Dosya izleyici oluşturulamadı: %v", err)
+		watcher = nil
+	} else {
+		defer watcher.Close()
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	started := time.Now()
+	searchIndex.reconcile(ctx, watcher)
+	if count, ready := searchIndex.status(); ready {
+		log.Printf("[İçerik İndeksi] %d belge indekslendi (%s).", count, time.Since(started).Round(time.Millisecond))
+	}
+
+	debounce := time.NewTicker(time.Second)
+	defer debounce.Stop()
+	saveTicker := time.NewTicker(contentIndexSaveInterval)
+	defer saveTicker.Stop()
+	rescan := time.NewTicker(contentIndexRescanInterval)
+	defer rescan.Stop()
+
+	// * Bir dosya yazılırken art arda çok sayıda olay gelir; yol ancak
+	// * `contentIndexDebounce` boyunca sessiz kaldığında yeniden okunur.
+	pending := make(map[string]time.Time)
+	for {
+		select {
+		case <-ctx.Done():
+			if err := searchIndex.save(config.ContentIndexPath); err != nil {
+				log.Printf("[İçerik İndeksi] Kaydedilemedi: %v", err)
+			}
+			if err := fileHashes.save(config.HashCachePath); err != nil {
+				log.Printf("[Kopyalar] Özetler kaydedilemedi: %v", err)
+			}
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.Op != fsnotify.Chmod {
+				pending[event.Name] = time.Now()
+			}
+
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			log.Printf("[İçerik İndeksi] İzleyici hatası: %v", err)
+
+		case now := <-debounce.C:
+			for path, at := range pending {
+				if now.Sub(at) >= contentIndexDebounce {
+					delete(pending, path)
+					searchIndex.refreshPath(ctx, path, watcher)
+				}
+			}
+
+		case <-saveTicker.C:
+			if err := searchIndex.save(config.ContentIndexPath); err != nil {
+				log.Printf("[İçerik İndeksi] Kaydedilemedi: %v", err)
+			}
+			if err := fileHashes.save(config.HashCachePath); err != nil {
+				log.Printf("[Kopyalar] Özetler kaydedilemedi: %v", err)
+			}
+
+		case <-rescan.C:
+			searchIndex.reconcile(ctx, watcher)
+		}
+	}
+}
+
+// #############################################################################
+// #                                 ARAMA
+// #############################################################################
+
+// contentHit, bir arama sonucudur.
+type contentHit struct {
+	Key   string
+	Score float64
+	text  string
+}
+
+// contentQueryTerm, sorgudaki bir kelimedir. `*` ile biten kelimeler önek
+// olarak aranır (örn. `fatura*` -> fatura, faturası, faturalar).
+type contentQueryTerm struct {
+	term   string
+	prefix bool
+}
+
+func (q contentQueryTerm) matches(term string) bool {
+	if q.prefix {
+		return strings.HasPrefix(term, q.term)
+	}
+	return term == q.term
+}
+
+// parseContentQuery, sorguyu kelimelere ayırır.
+func parseContentQuery(query string) []contentQueryTerm {
+	var terms []contentQueryTerm
+	for _, field := range strings.Fields(query) {
+		prefix := strings.HasSuffix(field, "*")
+		scanTerms(field, func(term string, _, _ int) {
+			terms = append(terms, contentQueryTerm{term: term})
+		})
+		if prefix && len(terms) > 0 {
+			terms[len(terms)-1].prefix = true
+		}
+	}
+	return terms
+}
+
+// BM25 parametreleri.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// searchContent, sorgudaki tüm kelimeleri içeren ve kullanıcının erişebildiği
+// dosyaları puanına göre sıralı döndürür. İkinci değer toplam sonuç sayısıdır.
+func searchContent(userID int64, query string, limit int) ([]contentHit, int, error) {
+	terms := parseContentQuery(query)
+	if len(terms) == 0 {
+		return nil, 0, fmt.Errorf("sorguda aranabilir kelime yok (en az %d karakter)", minTermLength)
+	}
+
+	idx := searchIndex
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.docs) == 0 {
+		return nil, 0, nil
+	}
+	avgLength := float64(idx.totalLength) / float64(len(idx.docs))
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	scores := make(map[string]float64)
+	for i, q := range terms {
+		// Önekli kelimeler indeksteki tüm eşleşen kelimelere genişletilir.
+		var expanded []string
+		if q.prefix {
+			for term := range idx.postings {
+				if q.matches(term) {
+					expanded = append(expanded, term)
+				}
+			}
+		} else if _, ok := idx.postings[q.term]; ok {
+			expanded = []string{q.term}
+		}
+
+		termScores := make(map[string]float64)
+		for _, term := range expanded {
+			posting := idx.postings[term]
+			df := float64(len(posting))
+			idf := math.Log(1 + (float64(len(idx.docs))-df+0.5)/(df+0.5))
+			for key, count := range posting {
+				if i > 0 {
+					if _, ok := scores[key]; !ok {
+						continue
+					}
+				}
+				tf := float64(count)
+				norm := 1 - bm25B + bm25B*float64(idx.docs[key].Length)/avgLength
+				termScores[key] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			}
+		}
+
+		// * Kelimeler VE ile bağlanır: yalnızca tüm kelimeleri içeren dosyalar kalır.
+		next := make(map[string]float64, len(termScores))
+		for key, score := range termScores {
+			next[key] = scores[key] + score
+		}
+		scores = next
+		if len(scores) == 0 {
+			return nil, 0, nil
+		}
+	}
+
+	var hits []contentHit
+	for key, score := range scores {
+		if path, ok := pathOfKey(key); ok && canAccessPath(userID, path) {
+			hits = append(hits, contentHit{Key: key, Score: score, text: idx.docs[key].Text})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key < hits[j].Key
+	})
+	total := len(hits)
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, total, nil
+}
+
+// markdownEscaper, Telegram'ın Markdown biçiminde özel anlamı olan karakterleri kaçırır.
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// buildSnippet, metinde sorgu kelimelerinin ilk geçtiği yerin çevresinden
+// kısa bir özet çıkarır. `markdown` açıksa eşleşen kelimeler kalın yazılır.
+func buildSnippet(text string, terms []contentQueryTerm, markdown bool) string {
+	const before, after = 60, 140
+
+	matchAt := -1
+	scanTerms(text, func(term string, start, _ int) {
+		if matchAt >= 0 {
+			return
+		}
+		for _, q := range terms {
+			if q.matches(term) {
+				matchAt = start
+				return
+			}
+		}
+	})
+
+	start, end := 0, len(text)
+	if matchAt > before {
+		start = matchAt - before
+	}
+	if end > start+before+after {
+		end = start + before + after
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	window := strings.Join(strings.Fields(text[start:end]), " ")
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	last := 0
+	scanTerms(window, func(term string, s, e int) {
+		for _, q := range terms {
+			if q.matches(term) {
+				if markdown {
+					builder.WriteString(markdownEscaper.Replace(window[last:s]))
+					builder.WriteString("*" + window[s:e] + "*")
+				} else {
+					builder.WriteString(window[last:s])
+					builder.WriteString(window[s:e])
+				}
+				last = e
+				return
+			}
+		}
+	})
+	if markdown {
+		builder.WriteString(markdownEscaper.Replace(window[last:]))
+	} else {
+		builder.WriteString(window[last:])
+	}
+	if end < len(text) {
+		builder.WriteString("…")
+	}
+	return builder.String()
+}
+
+// handleContentSearchCommand, /icerik_ara komutuyla belgelerin içeriğinde arama yapar.
+func handleContentSearchCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	query := strings.TrimSpace(message.CommandArguments())
+	count, ready := searchIndex.status()
+	if query == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Kullanım: `/icerik_ara <sorgu>`\nÖrnek: `/icerik_ara kira sözleşme*`\n\n📚 İndekslenen belge: %d", count)))
+		return
+	}
+
+	hits, total, err := searchContent(message.From.ID, query, maxContentResults)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	if total == 0 {
+		text := fmt.Sprintf("❌ İçeriğinde `%s` geçen belge bulunamadı.", query)
+		if !ready {
+			text += "\n⏳ İndeksleme henüz tamamlanmadı, daha sonra tekrar deneyin."
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	terms := parseContentQuery(query)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🔎 *İçerik Arama: `%s` (%d sonuç)*\n\n", query, total))
+	for i, hit := range hits {
+		builder.WriteString(fmt.Sprintf("%d. 📄 `%s`\n   %s\n\n", i+1, hit.Key, buildSnippet(hit.text, terms, true)))
+	}
+	if total > len(hits) {
+		builder.WriteString(fmt.Sprintf("_… ve %d sonuç daha. Aramayı daraltmak için kelime ekleyin._\n", total-len(hits)))
+	}
+	if !ready {
+		builder.WriteString("⏳ _İndeksleme sürüyor, sonuçlar eksik olabilir._\n")
+	}
+	builder.WriteString("💡 *Dosya almak için:* `/getir <klasör/dosya_adı>`")
+
+	msg := tgbotapi.NewMessage(chatID, builder.String())
+	msg.ParseMode = "Markdown"
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("İçerik arama sonuçları gönderilirken Markdown hatası (fallback denenecek): %v", err)
+		msg.ParseMode = ""
+		bot.Send(msg)
+	}
+}
+
+// searchContentText, içerik aramasının sonuçlarını LLM aracı için düz metin olarak döndürür.
+func searchContentText(userID int64, query string) (string, error) {
+	hits, total, err := searchContent(userID, query, maxContentResults)
+	if err != nil {
+		return "", err
+	}
+	if total == 0 {
+		return fmt.Sprintf("İçeriğinde '%s' geçen belge bulunamadı.", query), nil
+	}
+	terms := parseContentQuery(query)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("'%s' için %d belge bulundu (en alakalı %d tanesi):\n", query, total, len(hits)))
+	for _, hit := range hits {
+		builder.WriteString(fmt.Sprintf("- %s: %s\n", hit.Key, buildSnippet(hit.text, terms, false)))
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,150 @@
+// dispatcher.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                          GÜNCELLEME DAĞITICISI
+// #############################################################################
+// Bu dosya, gelen güncellemeleri sınırlı sayıda worker goroutine'ine dağıtan
+// yapıyı içerir. Amaç, yavaş bir işlemin (örneğin uzun bir `/ara` taraması
+// veya bir LLM sorgusu) diğer kullanıcıları bekletmesini önlemektir.
+// Kurallar:
+//   - Aynı anda en fazla `workers` kadar güncelleme işlenir.
+//   - Aynı sohbete ait güncellemeler geliş sırasıyla (FIFO) ve birbiri
+//     ardına işlenir; farklı sohbetler paralel ilerler.
+//   - Bir kullanıcının kuyrukta bekleyen + işlenen güncelleme sayısı
+//     `perUserLimit` değerini aşamaz; aşan güncellemeler reddedilir.
+//   - İşleyicilerde oluşan panikler yakalanır, loglanır ve kullanıcıya
+//     bildirilir; süreç çökmez.
+
+// dispatchedUpdate, kuyruğa alınan bir güncellemeyi ve sahibini tutar.
+type dispatchedUpdate struct {
+	update tgbotapi.Update
+	chatID int64
+	userID int64
+}
+
+// updateDispatcher, sohbet bazında sıralı ve sınırlı eş zamanlı işleme sağlar.
+type updateDispatcher struct {
+	bot          Messenger
+	handle       func(tgbotapi.Update)
+	perUserLimit int
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queues   map[int64][]dispatchedUpdate // sohbet ID -> bekleyen güncellemeler
+	ready    []int64                      // işlenmeyi bekleyen (meşgul olmayan) sohbetler
+	busy     map[int64]bool               // şu anda bir worker tarafından işlenen sohbetler
+	inFlight map[int64]int                // kullanıcı ID -> bekleyen + işlenen güncelleme sayısı
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// newUpdateDispatcher, `workers` adet worker başlatır ve dağıtıcıyı döndürür.
+func newUpdateDispatcher(bot Messenger, workers, perUserLimit int, handle func(tgbotapi.Update)) *updateDispatcher {
+	if workers <= 0 {
+		workers = 1
+	}
+	d := &updateDispatcher{
+		bot:          bot,
+		handle:       handle,
+		perUserLimit: perUserLimit,
+		queues:       make(map[int64][]dispatchedUpdate),
+		busy:         make(map[int64]bool),
+		inFlight:     make(map[int64]int),
+	}
+	d.cond = sync.NewCond(&d.mu)
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	return d
+}
+
+// Submit, bir güncellemeyi sohbetinin kuyruğuna ekler. Kullanıcının eş
+// zamanlı istek sınırı dolmuşsa güncelleme eklenmez ve `false` döner.
+func (d *updateDispatcher) Submit(update tgbotapi.Update, chatID, userID int64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	if d.perUserLimit > 0 && d.inFlight[userID] >= d.perUserLimit {
+		return false
+	}
+	d.inFlight[userID]++
+	// * Sohbetin kuyruğu boşsa ve işlenmiyorsa sohbet hazır listesine girer.
+	// * Aksi halde mevcut worker işini bitirince sıradakini kendisi alır.
+	if len(d.queues[chatID]) == 0 && !d.busy[chatID] {
+		d.ready = append(d.ready, chatID)
+	}
+	d.queues[chatID] = append(d.queues[chatID], dispatchedUpdate{update: update, chatID: chatID, userID: userID})
+	d.cond.Signal()
+	return true
+}
+
+// Close, yeni güncelleme kabulünü durdurur ve kuyruktaki tüm işler
+// bitene kadar bekler.
+func (d *updateDispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// worker, hazır bir sohbetin sıradaki güncellemesini alıp işler.
+func (d *updateDispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		d.mu.Lock()
+		for len(d.ready) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.ready) == 0 && d.closed {
+			d.mu.Unlock()
+			return
+		}
+		chatID := d.ready[0]
+		d.ready = d.ready[1:]
+		item := d.queues[chatID][0]
+		d.queues[chatID] = d.queues[chatID][1:]
+		d.busy[chatID] = true
+		d.mu.Unlock()
+
+		d.run(item)
+
+		d.mu.Lock()
+		d.busy[chatID] = false
+		delete(d.busy, chatID)
+		if d.inFlight[item.userID]--; d.inFlight[item.userID] <= 0 {
+			delete(d.inFlight, item.userID)
+		}
+		if len(d.queues[chatID]) > 0 {
+			d.ready = append(d.ready, chatID)
+			d.cond.Signal()
+		} else {
+			delete(d.queues, chatID)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// run, tek bir güncellemeyi işler ve oluşabilecek paniği yakalar.
+func (d *updateDispatcher) run(item dispatchedUpdate) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("🔥 PANİK! Kullanıcı: %d, Sohbet: %d, Hata: %v\n%s", item.userID, item.chatID, r, debug.Stack())
+			d.bot.Send(tgbotapi.NewMessage(item.chatID, fmt.Sprintf("❌ İsteğiniz işlenirken beklenmeyen bir hata oluştu: %v", r)))
+		}
+	}()
+	d.handle(item.update)
+}
@@ -6,10 +6,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -57,7 +59,7 @@ func isVideoPlatformURL(urlStr string) bool {
 
 // handleDownloadCommand, `/indir` komutu için ana yönlendiricidir.
 // Gelen URL'yi analiz eder ve işi `handleYtDlpDownload` veya `handleDirectDownload`'a devreder.
-func handleDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleDownloadCommand(bot Messenger, message *tgbotapi.Message) {
 	args := strings.Fields(message.CommandArguments())
 	if len(args) == 0 {
 		reply := "❌ Kullanım: `/indir <URL> [kalite] [format]`"
@@ -77,8 +79,29 @@ func handleDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	}
 }
 
+// ytDlpPrintPath, yt-dlp'nin indirilen dosyanın son yolunu ayrı bir satır
+// olarak yazmasını sağlar. Bu yol, düzenleme kurallarının dosyanın kaynağına
+// göre eşleşebilmesi için kaydedilir (bkz. `organizer_rules.go`).
+const ytDlpPrintPath = "after_move:filepath"
+
+// recordDownloadOrigin, yt-dlp çıktısındaki satır indirilen dosyanın yoluysa
+// dosyayı `/indir` kaynağıyla kaydeder ve true döner.
+func recordDownloadOrigin(line string, userID int64) bool {
+	filePath := strings.TrimSpace(line)
+	if filePath == "" || strings.HasPrefix(filePath, "[") {
+		return false
+	}
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		return false
+	}
+	if err := recordFileOrigin(fileKey(filePath), sourceDownload, userID); err != nil {
+		log.Printf("İndirilen dosyanın kaynağı kaydedilemedi: %v", err)
+	}
+	return true
+}
+
 // handleYtDlpDownload, `yt-dlp` CLI aracını kullanarak video indirir.
-func handleYtDlpDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, args []string) {
+func handleYtDlpDownload(bot Messenger, message *tgbotapi.Message, args []string) {
 	chatID := message.Chat.ID
 	urlStr := args[0]
 	var quality, format string
@@ -116,8 +139,8 @@ func handleYtDlpDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, args [
 	formatStr := strings.Join(preferences, "/")
 
 	statusMsg, _ := bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("▶️ Video hazırlanıyor (Tercihler: Kalite=%s, Format=%s)...", quality, format)))
-	cmdArgs := []string{"--progress", "--newline", "--force-overwrites", "-f", formatStr, "-o", filepath.Join(config.BaseDir, "%(title)s.%(ext)s"), urlStr}
-	cmd := exec.Command("yt-dlp", cmdArgs...)
+	cmdArgs := []string{"--progress", "--newline", "--force-overwrites", "--print", ytDlpPrintPath, "-f", formatStr, "-o", filepath.Join(config.BaseDir, "%(title)s.%(ext)s"), urlStr}
+	cmd := exec.CommandContext(jobsCtx, "yt-dlp", cmdArgs...)
 
 	stdoutPipe, _ := cmd.StdoutPipe()
 	stderrPipe, _ := cmd.StderrPipe()
@@ -141,6 +164,9 @@ func handleYtDlpDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, args [
 		scanner := bufio.NewScanner(stdoutPipe)
 		for scanner.Scan() {
 			line := scanner.Text()
+			if recordDownloadOrigin(line, message.From.ID) {
+				continue
+			}
 			if strings.Contains(line, "[download]") && strings.Contains(line, "%") {
 				fields := strings.Fields(line)
 				for _, field := range fields {
@@ -185,6 +211,11 @@ func handleYtDlpDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, args [
 	err := cmd.Wait()
 	close(doneChan)
 	bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
+	if err != nil && jobsCtx.Err() != nil {
+		cleanupPartialDownloads()
+		bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Bot kapandığı için video indirmesi iptal edildi."))
+		return
+	}
 	if err != nil {
 		errMsg := fmt.Sprintf("❌ Video indirilirken hata oluştu.\n`%v`\n\n**Hata Detayı:**\n`%s`", err, stderrBuf.String())
 		if len(errMsg) > 4096 {
@@ -197,7 +228,7 @@ func handleYtDlpDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, args [
 }
 
 // handleAudioDownloadCommand, `yt-dlp` kullanarak sadece ses dosyası indirir.
-func handleAudioDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleAudioDownloadCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	args := strings.Fields(message.CommandArguments())
 	if len(args) < 1 {
@@ -219,9 +250,9 @@ func handleAudioDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 	// * ÖNEMLİ: `-x` ve `--audio-format` argümanları, `yt-dlp`'ye videoyu
 	// * tamamen yoksayıp sadece en iyi ses akışını indirmesini ve ardından
 	// * belirtilen formata (opus, mp3, flac vb.) dönüştürmesini söyler.
-	cmdArgs := []string{"-x", "--audio-format", audioFormat, "--audio-quality", audioQuality, "-f", "bestaudio/best", "--progress", "--newline", "--force-overwrites", "-o", filepath.Join(config.BaseDir, "%(title)s.%(ext)s"), urlStr}
+	cmdArgs := []string{"-x", "--audio-format", audioFormat, "--audio-quality", audioQuality, "-f", "bestaudio/best", "--progress", "--newline", "--force-overwrites", "--print", ytDlpPrintPath, "-o", filepath.Join(config.BaseDir, "%(title)s.%(ext)s"), urlStr}
 	
-	cmd := exec.Command("yt-dlp", cmdArgs...)
+	cmd := exec.CommandContext(jobsCtx, "yt-dlp", cmdArgs...)
 
 	// ... (İlerleme takibi ve hata yönetimi mantığı handleYtDlpDownload ile aynıdır)
 	stdoutPipe, _ := cmd.StdoutPipe()
@@ -238,6 +269,9 @@ func handleAudioDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 		scanner := bufio.NewScanner(stdoutPipe)
 		for scanner.Scan() {
 			line := scanner.Text()
+			if recordDownloadOrigin(line, message.From.ID) {
+				continue
+			}
 			if strings.Contains(line, "[download]") && strings.Contains(line, "%") {
 				fields := strings.Fields(line)
 				for _, field := range fields {
@@ -278,6 +312,11 @@ func handleAudioDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 	err := cmd.Wait()
 	close(doneChan)
 	bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
+	if err != nil && jobsCtx.Err() != nil {
+		cleanupPartialDownloads()
+		bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Bot kapandığı için ses indirmesi iptal edildi."))
+		return
+	}
 	if err != nil {
 		errMsg := fmt.Sprintf("❌ Ses indirilirken hata oluştu.\n`%v`\n\n**Hata Detayı:**\n`%s`", err, stderrBuf.String())
 		if len(errMsg) > 4096 {
@@ -290,7 +329,7 @@ func handleAudioDownloadCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 }
 
 // handleDirectDownload, standart HTTP GET isteği ile doğrudan dosya indirir.
-func handleDirectDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, urlStr string) {
+func handleDirectDownload(bot Messenger, message *tgbotapi.Message, urlStr string) {
 	chatID := message.Chat.ID
 	statusMsg, err := bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⬇️ İndirme başlatılıyor...\nURL: `%s`", urlStr)))
 	if err != nil {
@@ -301,7 +340,12 @@ func handleDirectDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, urlSt
 		editMsg.ParseMode = "Markdown"
 		bot.Request(editMsg)
 	}
-	resp, err := http.Get(urlStr)
+	req, err := http.NewRequestWithContext(jobsCtx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		editMessage(fmt.Sprintf("❌ Geçersiz URL: `%v`", err))
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		editMessage(fmt.Sprintf("❌ İndirme başarısız: `%v`", err))
 		return
@@ -311,24 +355,21 @@ func handleDirectDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, urlSt
 		editMessage(fmt.Sprintf("❌ Sunucu hatası: `%s`", resp.Status))
 		return
 	}
-	fileName := filepath.Base(resp.Request.URL.Path)
-	if fileName == "" || fileName == "." || len(fileName) > 200 {
+	fileName, err := sanitizeFileName(path.Base(resp.Request.URL.Path))
+	if err != nil || len(fileName) > 200 {
 		fileName = fmt.Sprintf("download_%d", time.Now().Unix())
 	}
-	destPath := filepath.Join(config.BaseDir, fileName)
-	file, err := os.Create(destPath)
+	destPath, err := resolveInBase(fileName)
 	if err != nil {
 		editMessage(fmt.Sprintf("❌ Dosya oluşturulamadı: `%v`", err))
 		return
 	}
-	defer file.Close()
 	totalSize, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
 	progress := &ProgressWriter{Total: totalSize}
 	
-	// * `io.MultiWriter`, gelen veriyi aynı anda birden çok hedefe yazar.
-	// * Burada, indirilen veriyi hem diske (`file`) hem de ilerlemeyi sayan
-	// * `progress` nesnemize yazıyoruz.
-	writer := io.MultiWriter(file, progress)
+	// * `io.TeeReader`, okunan veriyi aynı anda ilerlemeyi sayan `progress`
+	// * nesnemize de yazar; içerik önce geçici dosyaya kaydedilir.
+	reader := io.TeeReader(resp.Body, progress)
 
 	doneChan := make(chan struct{})
 	go func() {
@@ -355,15 +396,30 @@ func handleDirectDownload(bot *tgbotapi.BotAPI, message *tgbotapi.Message, urlSt
 			}
 		}
 	}()
-	_, err = io.Copy(writer, resp.Body)
+	tempPath, err := receiveToTemp(filepath.Dir(destPath), reader, totalSize)
 	close(doneChan)
 	if err != nil {
 		editMessage(fmt.Sprintf("❌ İndirme sırasında hata: `%v`", err))
-		os.Remove(destPath)
 		return
 	}
+	// URL'den gelen ad çoğu zaman uzantısızdır; tür içerikten belirlenir.
+	typed := detectTypeForName(tempPath, destPath)
+	stored, asked, err := storeIncomingFile(bot, chatID, message.From.ID, tempPath, typed.Path, sourceDownload)
+	if err != nil {
+		editMessage(fmt.Sprintf("❌ Dosya kaydedilemedi: `%v`", err))
+		return
+	} else if asked {
+		bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
+		return
+	}
+	destPath = stored.Path
+	typed.Path = stored.Path
+	fileName = filepath.Base(destPath)
+	if err := recordFileOrigin(fileKey(destPath), sourceDownload, message.From.ID); err != nil {
+		log.Printf("İndirilen dosyanın kaynağı kaydedilemedi: %v", err)
+	}
 	bot.Request(tgbotapi.NewDeleteMessage(chatID, statusMsg.MessageID))
 	finalDownloaded := atomic.LoadInt64(&progress.Downloaded)
-	replyText := fmt.Sprintf("✅ *Dosya başarıyla indirildi!*\n\n📄 *Ad:* `%s`\n📏 *Boyut:* %.1f MB\n📁 *Konum:* Gelenler", fileName, float64(finalDownloaded)/1e6)
+	replyText := fmt.Sprintf("✅ *Dosya başarıyla indirildi!*\n\n📄 *Ad:* `%s`\n📏 *Boyut:* %.1f MB\n%s📁 *Konum:* Gelenler\n%s%s", fileName, float64(finalDownloaded)/1e6, detectedTypeText(typed), incomingCollisionText(stored), duplicateWarningText(message.From.ID, destPath))
 	bot.Send(tgbotapi.NewMessage(chatID, replyText))
 }
\ No newline at end of file
@@ -0,0 +1,588 @@
+// duplicate_files.go
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                              KOPYA DOSYALAR
+// #############################################################################
+// Bu dosya, ana klasördeki dosyaların içerik özetlerini (SHA-256) tutar ve
+// aynı içeriğe sahip dosyaların bulunmasını sağlar. Özetler, içerik
+// indeksleyicisinin (bkz. `content_index.go`) tarama ve dosya izleme
+// döngüsünde güncellenir ve `file_hashes.gob` dosyasında saklanır; böylece
+// yalnızca boyutu veya değiştirilme zamanı değişen dosyalar yeniden okunur.
+//
+// Bota gönderilen veya indirilen bir dosyanın aynısı zaten varsa kayıt
+// mesajında uyarı verilir. `/kopyalar` aynı içerikli dosya gruplarını listeler
+// ve düğmelerle her gruptan korunacak kopyanın seçilmesini sağlar. Diğer
+// kopyalar çöp kutusuna taşınır (işlem günlüğünden geri alınabilir) ve
+// açıklamaları ile etiketleri korunan kopyada birleştirilir.
+
+const (
+	fileHashCacheVersion    = 1
+	dedupeSessionTTL        = 15 * time.Minute
+	maxDuplicateGroupsShown = 20 // /kopyalar listesinde gösterilecek en fazla grup
+	maxDedupeGroupButtons   = 10 // Temizleme düğmesi gösterilecek en fazla grup
+	maxDedupeFileButtons    = 10 // Bir grupta düğme gösterilecek en fazla kopya
+)
+
+// hashedFile, özeti hesaplanmış tek bir dosyanın kaydıdır.
+type hashedFile struct {
+	ModTime int64
+	Size    int64
+	Hash    string
+}
+
+// fileHashSnapshot, özet önbelleğinin diske yazılan biçimidir.
+type fileHashSnapshot struct {
+	Version int
+	Files   map[string]*hashedFile
+}
+
+// fileHashCache, göreli yol -> içerik özeti önbelleğidir.
+type fileHashCache struct {
+	mu    sync.RWMutex
+	files map[string]*hashedFile
+	dirty bool // Son kayıttan sonra değişiklik var mı?
+}
+
+var fileHashes = &fileHashCache{files: make(map[string]*hashedFile)}
+
+// hashFile, dosyanın içeriğinin SHA-256 özetini hesaplar.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// #############################################################################
+// #                             ÖZET ÖNBELLEĞİ
+// #############################################################################
+
+// update, dosya değiştiyse özetini yeniden hesaplar ve güncel özeti döndürür.
+func (c *fileHashCache) update(path string, info os.FileInfo) (string, error) {
+	key := fileKey(path)
+	c.mu.RLock()
+	cached, ok := c.files[key]
+	c.mu.RUnlock()
+	if ok && cached.Size == info.Size() && cached.ModTime == info.ModTime().UnixNano() {
+		return cached.Hash, nil
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.files[key] = &hashedFile{ModTime: info.ModTime().UnixNano(), Size: info.Size(), Hash: hash}
+	c.dirty = true
+	c.mu.Unlock()
+	return hash, nil
+}
+
+// hashOf, dosyanın güncel özetini döndürür; önbellekteki kayıt eskiyse
+// dosyayı yeniden okur.
+func (c *fileHashCache) hashOf(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return c.update(path, info)
+}
+
+// removeTree, bir yolu ve (klasörse) altındaki tüm kayıtları siler.
+func (c *fileHashCache) removeTree(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := key + "/"
+	for other := range c.files {
+		if other == key || strings.HasPrefix(other, prefix) {
+			delete(c.files, other)
+			c.dirty = true
+		}
+	}
+}
+
+// prune, tam bir taramada görülmeyen dosyaların kayıtlarını siler.
+func (c *fileHashCache) prune(seen map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.files {
+		if !seen[key] {
+			delete(c.files, key)
+			c.dirty = true
+		}
+	}
+}
+
+// keysWithHash, verilen özete sahip dosyaların göreli yollarını döndürür.
+func (c *fileHashCache) keysWithHash(hash string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var keys []string
+	for key, file := range c.files {
+		if file.Hash == hash {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// load, önceki çalışmada kaydedilen özetleri yükler.
+func (c *fileHashCache) load(path string) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		log.Printf("[Kopyalar] %s okunamadı: %v", path, err)
+		return
+	}
+	var snapshot fileHashSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot); err != nil || snapshot.Version != fileHashCacheVersion {
+		log.Printf("[Kopyalar] %s kullanılamıyor, özetler yeniden hesaplanacak.", path)
+		return
+	}
+	c.mu.Lock()
+	c.files = snapshot.Files
+	if c.files == nil {
+		c.files = make(map[string]*hashedFile)
+	}
+	c.mu.Unlock()
+}
+
+// save, özetler değiştiyse geçici bir dosyaya yazıp asıl dosyanın yerine koyar.
+func (c *fileHashCache) save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&fileHashSnapshot{Version: fileHashCacheVersion, Files: c.files}); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// #############################################################################
+// #                             KOPYA GRUPLARI
+// #############################################################################
+
+// duplicateGroup, aynı içeriğe sahip dosyalardır.
+type duplicateGroup struct {
+	Hash string
+	Size int64
+	Keys []string
+}
+
+// wasted, gruptaki fazla kopyaların kapladığı alandır.
+func (g duplicateGroup) wasted() int64 {
+	return g.Size * int64(len(g.Keys)-1)
+}
+
+// findDuplicateGroups, kullanıcının erişebildiği dosyalar arasında aynı
+// içeriğe sahip olanları gruplar. Gruplar boşa harcanan alana göre sıralanır.
+// Diskte artık bulunmayan veya önbellekteki kaydı eskimiş dosyalar atlanır.
+func findDuplicateGroups(userID int64) []duplicateGroup {
+	fileHashes.mu.RLock()
+	byHash := make(map[string]*duplicateGroup)
+	for key, file := range fileHashes.files {
+		group := byHash[file.Hash]
+		if group == nil {
+			group = &duplicateGroup{Hash: file.Hash, Size: file.Size}
+			byHash[file.Hash] = group
+		}
+		group.Keys = append(group.Keys, key)
+	}
+	fileHashes.mu.RUnlock()
+
+	var groups []duplicateGroup
+	for _, group := range byHash {
+		// * Boş dosyaların hepsi aynı özete sahiptir; kopya sayılmazlar.
+		if len(group.Keys) < 2 || group.Size == 0 {
+			continue
+		}
+		var keys []string
+		for _, key := range group.Keys {
+			path, ok := pathOfKey(key)
+			if !ok || !canAccessPath(userID, path) {
+				continue
+			}
+			if hash, err := fileHashes.hashOf(path); err == nil && hash == group.Hash {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) < 2 {
+			continue
+		}
+		sort.Strings(keys)
+		group.Keys = keys
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].wasted() != groups[j].wasted() {
+			return groups[i].wasted() > groups[j].wasted()
+		}
+		return groups[i].Keys[0] < groups[j].Keys[0]
+	})
+	return groups
+}
+
+// duplicatesOf, bir dosyayla aynı içeriğe sahip ve kullanıcının erişebildiği
+// diğer dosyaların göreli yollarını döndürür. Yeni kaydedilen dosyanın özeti
+// de bu sırada önbelleğe eklenir.
+func duplicatesOf(userID int64, path string) []string {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 {
+		return nil
+	}
+	hash, err := fileHashes.update(path, info)
+	if err != nil {
+		log.Printf("[Kopyalar] %s özeti hesaplanamadı: %v", path, err)
+		return nil
+	}
+	self := fileKey(path)
+	var keys []string
+	for _, key := range fileHashes.keysWithHash(hash) {
+		other, ok := pathOfKey(key)
+		if key == self || !ok || !canAccessPath(userID, other) {
+			continue
+		}
+		if current, err := fileHashes.hashOf(other); err == nil && current == hash {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// duplicateWarningText, kayıt onay mesajlarına eklenecek kopya uyarısını
+// üretir (Markdown). Dosyanın aynısı yoksa boş döner.
+func duplicateWarningText(userID int64, path string) string {
+	keys := duplicatesOf(userID, path)
+	if len(keys) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("♻️ *Bu dosyanın aynısı zaten var:* `%s`\n💡 `/kopyalar` ile kopyaları temizleyebilirsiniz.\n",
+		strings.Join(keys, "`, `"))
+}
+
+// formatFileSize, bayt cinsinden boyutu okunabilir biçimde yazar.
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	default:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+}
+
+// dedupeGroup, gruptan `keep` dışındaki kopyaları çöp kutusuna taşır ve
+// açıklamalarını korunan kopyaya aktarır. İçeriği değişmiş veya artık
+// bulunmayan kopyalar atlanır. Taşınan kopyalar tek bir işlem olarak
+// günlüğe yazılır.
+func dedupeGroup(userID int64, group duplicateGroup, keep string) (removed []TrashEntry, skipped []string, err error) {
+	keepPath, ok := pathOfKey(keep)
+	if !ok || !canAccessPath(userID, keepPath) {
+		return nil, nil, fmt.Errorf("`%s` dosyasına erişilemiyor", keep)
+	}
+	if hash, err := fileHashes.hashOf(keepPath); err != nil || hash != group.Hash {
+		return nil, nil, fmt.Errorf("korunacak dosya `%s` silinmiş veya değişmiş", keep)
+	}
+
+	var merged []FileMetadata
+	var steps []OperationStep
+	for _, key := range group.Keys {
+		if key == keep {
+			continue
+		}
+		path, ok := pathOfKey(key)
+		if !ok || !canAccessPath(userID, path) {
+			skipped = append(skipped, key)
+			continue
+		}
+		if hash, err := fileHashes.hashOf(path); err != nil || hash != group.Hash {
+			skipped = append(skipped, key)
+			continue
+		}
+		meta := getFileMetadata(key)
+		entry, err := moveToTrash(userID, path)
+		if err != nil {
+			log.Printf("[Kopyalar] %s çöp kutusuna taşınamadı: %v", key, err)
+			skipped = append(skipped, key)
+			continue
+		}
+		fileHashes.removeTree(key)
+		merged = append(merged, meta)
+		removed = append(removed, entry)
+		steps = append(steps, OperationStep{From: key, TrashID: entry.ID})
+	}
+	if len(steps) > 0 {
+		recordOperation(userID, operationDedupe, steps...)
+	}
+	if len(merged) > 0 {
+		if err := mergeFileMetadata(keep, merged); err != nil {
+			log.Printf("[Kopyalar] %s açıklamaları birleştirilemedi: %v", keep, err)
+		}
+	}
+	return removed, skipped, nil
+}
+
+// #############################################################################
+// #                          KOPYA TEMİZLEME KOMUTU
+// #############################################################################
+
+// dedupeSession, /kopyalar mesajındaki düğmelerin ait olduğu grup listesidir.
+// Telegram düğme verisi 64 bayt ile sınırlı olduğu için yollar burada tutulur.
+// Ortak düğme değeri deposu (`newCallbackPayload`) değiştirilemeyen metinler
+// sakladığı için kullanılmaz: temizlenen grupların `Keys` alanı oturum
+// boyunca güncellenir ve liste her açılışta bu güncel haliyle yeniden çizilir.
+type dedupeSession struct {
+	userID  int64
+	groups  []duplicateGroup // Temizlenen grupların `Keys` alanı boşaltılır.
+	expires time.Time
+}
+
+var (
+	dedupeSessions = make(map[string]*dedupeSession)
+	dedupeMutex    = &sync.Mutex{}
+)
+
+// renderDuplicateList, kopya gruplarının listesini ve temizleme düğmelerini
+// oluşturur.
+func renderDuplicateList(token string, groups []duplicateGroup) (string, *tgbotapi.InlineKeyboardMarkup) {
+	var active []int
+	var wasted int64
+	for i, group := range groups {
+		if len(group.Keys) > 1 {
+			active = append(active, i)
+			wasted += group.wasted()
+		}
+	}
+	if len(active) == 0 {
+		return "✨ Aynı içeriğe sahip dosya bulunamadı.", nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("♻️ *Kopya Dosyalar* (%d grup, %s boşa harcanıyor)\n\n", len(active), formatFileSize(wasted)))
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for n, i := range active {
+		if n >= maxDuplicateGroupsShown {
+			builder.WriteString(fmt.Sprintf("… ve %d grup daha.\n", len(active)-n))
+			break
+		}
+		group := groups[i]
+		builder.WriteString(fmt.Sprintf("*%d.* %d kopya · %s\n", i+1, len(group.Keys), formatFileSize(group.Size)))
+		for _, key := range group.Keys {
+			builder.WriteString(fmt.Sprintf("   `%s`\n", key))
+		}
+		if n < maxDedupeGroupButtons {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🧹 %d", i+1), fmt.Sprintf("kopya_%s_%d", token, i)))
+			if len(row) == 5 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("❌ Kapat", fmt.Sprintf("kopya_%s_iptal", token)),
+	))
+	builder.WriteString("\n🧹 Bir grubu temizlemek için numarasına basın; korunacak kopyayı seçtikten sonra diğerleri çöp kutusuna taşınır.")
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return builder.String(), &markup
+}
+
+// renderDuplicateGroup, bir gruptaki kopyaları ve korunacak kopyanın
+// seçileceği düğmeleri oluşturur.
+func renderDuplicateGroup(token string, index int, group duplicateGroup) (string, *tgbotapi.InlineKeyboardMarkup) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("♻️ *Grup %d* · %d kopya · %s\n\n", index+1, len(group.Keys), formatFileSize(group.Size)))
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, key := range group.Keys {
+		meta := getFileMetadata(key)
+		builder.WriteString(fmt.Sprintf("*%d.* `%s`", i+1, key))
+		if meta.Description != "" {
+			builder.WriteString(" 📝")
+		}
+		if len(meta.Tags) > 0 {
+			builder.WriteString(" " + formatTags(meta.Tags))
+		}
+		builder.WriteString("\n")
+		if i < maxDedupeFileButtons {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ %d. kopyayı koru", i+1), fmt.Sprintf("kopya_%s_%d_%d", token, index, i)),
+			))
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Gruplar", fmt.Sprintf("kopya_%s_liste", token)),
+	))
+	builder.WriteString("\nKorunacak kopyayı seçin. Diğerlerinin açıklamaları ve etiketleri bu kopyaya aktarılır.")
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return builder.String(), &markup
+}
+
+// handleDuplicatesCommand, /kopyalar komutuyla aynı içerikli dosya
+// gruplarını listeler.
+func handleDuplicatesCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	groups := findDuplicateGroups(message.From.ID)
+	token := randomToken()[:12]
+	text, markup := renderDuplicateList(token, groups)
+	if _, ready := searchIndex.status(); !ready {
+		text = "⏳ Dosyalar hâlâ taranıyor; liste eksik olabilir.\n\n" + text
+	}
+	if markup != nil {
+		now := time.Now()
+		dedupeMutex.Lock()
+		for other, session := range dedupeSessions {
+			if now.After(session.expires) {
+				delete(dedupeSessions, other)
+			}
+		}
+		dedupeSessions[token] = &dedupeSession{userID: message.From.ID, groups: groups, expires: now.Add(dedupeSessionTTL)}
+		dedupeMutex.Unlock()
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	if markup != nil {
+		msg.ReplyMarkup = *markup
+	}
+	if _, err := bot.Send(msg); err != nil {
+		// * Uzun listeler veya yollardaki Markdown karakterleri nedeniyle
+		// * gönderilemezse düz metin olarak parçalanıp gönderilir.
+		for _, chunk := range splitMessageSmart(text) {
+			bot.Send(tgbotapi.NewMessage(chatID, chunk))
+		}
+	}
+}
+
+// handleDuplicatesCallback, /kopyalar mesajındaki düğmeleri işler:
+// `kopya_<token>_<grup>` grubu açar, `kopya_<token>_<grup>_<kopya>` seçilen
+// kopyayı koruyup diğerlerini çöp kutusuna taşır.
+func handleDuplicatesCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	userID := callbackQuery.From.ID
+	parts := strings.Split(callbackQuery.Data, "_")
+	if len(parts) < 3 {
+		return
+	}
+	token := parts[1]
+
+	edit := func(text string, markup *tgbotapi.InlineKeyboardMarkup) {
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		editMsg.ParseMode = "Markdown"
+		editMsg.ReplyMarkup = markup
+		bot.Send(editMsg)
+	}
+
+	dedupeMutex.Lock()
+	session, found := dedupeSessions[token]
+	if found && session.userID != userID {
+		// * Düğmeleri yalnızca komutu gönderen kullanıcı kullanabilir.
+		dedupeMutex.Unlock()
+		return
+	}
+	if found && time.Now().After(session.expires) {
+		delete(dedupeSessions, token)
+		found = false
+	}
+	dedupeMutex.Unlock()
+	if !found {
+		edit("⌛ Bu listenin süresi dolmuş. Lütfen `/kopyalar` komutunu yeniden gönderin.", nil)
+		return
+	}
+	switch parts[2] {
+	case "iptal":
+		dedupeMutex.Lock()
+		delete(dedupeSessions, token)
+		dedupeMutex.Unlock()
+		edit("👍 Kopya listesi kapatıldı.", nil)
+		return
+	case "liste":
+		dedupeMutex.Lock()
+		groups := session.groups
+		dedupeMutex.Unlock()
+		edit(renderDuplicateList(token, groups))
+		return
+	}
+
+	var groupIndex int
+	if _, err := fmt.Sscanf(parts[2], "%d", &groupIndex); err != nil || groupIndex < 0 || groupIndex >= len(session.groups) {
+		return
+	}
+	dedupeMutex.Lock()
+	group := session.groups[groupIndex]
+	dedupeMutex.Unlock()
+	if len(group.Keys) < 2 {
+		edit(renderDuplicateList(token, session.groups))
+		return
+	}
+	if len(parts) < 4 {
+		edit(renderDuplicateGroup(token, groupIndex, group))
+		return
+	}
+
+	var keepIndex int
+	if _, err := fmt.Sscanf(parts[3], "%d", &keepIndex); err != nil || keepIndex < 0 || keepIndex >= len(group.Keys) {
+		return
+	}
+	keep := group.Keys[keepIndex]
+	removed, skipped, err := dedupeGroup(userID, group, keep)
+	if err != nil {
+		edit(fmt.Sprintf("❌ %v", err), nil)
+		return
+	}
+	dedupeMutex.Lock()
+	session.groups[groupIndex].Keys = nil
+	groups := session.groups
+	dedupeMutex.Unlock()
+	log.Printf("[Kopyalar] %s korundu, %d kopya çöp kutusuna taşındı - Temizleyen: %d", keep, len(removed), userID)
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("✅ `%s` korundu; %d kopya çöp kutusuna taşındı (%s).\n",
+		keep, len(removed), formatFileSize(group.Size*int64(len(removed)))))
+	if len(skipped) > 0 {
+		builder.WriteString(fmt.Sprintf("⚠️ Değiştiği veya bulunamadığı için atlanan: `%s`\n", strings.Join(skipped, "`, `")))
+	}
+	builder.WriteString("↩️ Geri almak için: `/geri_al_son`\n\n")
+	text, markup := renderDuplicateList(token, groups)
+	edit(builder.String()+text, markup)
+}
@@ -46,7 +46,7 @@ func (sb *safeBuffer) String() string {
 
 // handleRunCommand, /calistir komutunu işler. Belirtilen betiği,
 // belirtilen bir zaman aşımı süresiyle çalıştırır ve çıktısını raporlar.
-func handleRunCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleRunCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	args := message.CommandArguments()
 	parts := strings.Fields(args)
@@ -154,7 +154,7 @@ func handleRunCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 // handleKillCommand, /kapat komutunu işler. Belirtilen Process ID'ye (PID)
 // sahip olan işlemi sunucu üzerinde sonlandırır.
-func handleKillCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleKillCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	pidStr := message.CommandArguments()
 
@@ -35,7 +35,7 @@ var (
 )
 
 // handleScreenshotCommand, /ss komutunu işleyerek sunucunun anlık ekran görüntüsünü alır.
-func handleScreenshotCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleScreenshotCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 
 	msg := tgbotapi.NewMessage(chatID, "🖥️ Ekran görüntüsü alınıyor, lütfen bekleyin...")
@@ -82,7 +82,7 @@ func handleScreenshotCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 }
 
 // handleStartRecordingCommand, FFmpeg kullanarak bir ekran kaydı işlemi başlatır.
-func handleStartRecordingCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleStartRecordingCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	recordingMutex.Lock()
 	defer recordingMutex.Unlock()
@@ -130,7 +130,7 @@ func handleStartRecordingCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message
 }
 
 // handleStopRecordingCommand, daha önce başlatılmış olan ekran kaydını sonlandırır.
-func handleStopRecordingCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleStopRecordingCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 
 	recordingMutex.Lock()
@@ -0,0 +1,617 @@
+// file_browser.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                               DOSYA GEZGİNİ
+// #############################################################################
+// Bu dosya, `/gezgin` komutuyla ana klasörün satır içi düğmelerle
+// gezilmesini sağlar. Klasörler ve dosyalar sayfalara bölünmüş düğmeler
+// olarak gösterilir; bir dosyaya basıldığında getir, önizle, açıklama ekle,
+// yeniden adlandır, taşı ve sil işlemlerinin bulunduğu bir menü açılır.
+//
+// Telegram düğme verisi 64 bayt ile sınırlı olduğu için yollar düğmelere
+// yazılmaz. Her gezgin mesajı sunucuda bir oturumla eşlenir ve düğmeler
+// `gez_<oturum>_<işlem>[_<sıra>]` biçiminde yalnızca oturum kimliğini ve
+// gösterilen listedeki sırayı taşır. Açıklama, yeni ad veya hedef klasör
+// gerektiren işlemlerde kullanıcıdan yanıt olarak metin istenir.
+
+const (
+	browserPageSize    = 10
+	browserSessionTTL  = 30 * time.Minute
+	browserPromptTTL   = 5 * time.Minute
+	browserButtonRunes = 40   // Düğme metninde gösterilecek en fazla karakter.
+	maxPreviewRunes    = 1500 // Metin önizlemesinde gösterilecek en fazla karakter.
+	maxPhotoPreview    = 10 * 1024 * 1024
+)
+
+// Dosya menüsündeki işlemler ve yetki kontrolünde karşılık geldikleri komutlar.
+const (
+	browserActionGet      = "getir"
+	browserActionPreview  = "onizle"
+	browserActionDescribe = "aciklama"
+	browserActionRename   = "ad"
+	browserActionMove     = "tasi"
+	browserActionDelete   = "sil"
+)
+
+var browserActionCommands = map[string]string{
+	browserActionGet:      "getir",
+	browserActionPreview:  "getir",
+	browserActionDescribe: "aciklama_ekle",
+	browserActionRename:   "yenidenadlandir",
+	browserActionMove:     "tasi",
+	browserActionDelete:   "sil",
+}
+
+// browserEntry, gezgin listesindeki bir klasör veya dosyadır.
+type browserEntry struct {
+	name  string
+	dir   bool
+	size  int64
+	count int // Klasörlerde içerdiği öğe sayısı.
+}
+
+// browserSession, bir gezgin mesajının durumudur. Ortak düğme değeri deposu
+// (`newCallbackPayload`) sabit süreli ve değiştirilemeyen metinler sakladığı
+// için kullanılmaz: klasör, sayfa ve liste her düğmede değişir, süre de her
+// kullanımda uzatılır.
+type browserSession struct {
+	userID  int64
+	dir     string // Ana klasöre göre göreli klasör ("" ana klasör).
+	page    int
+	entries []browserEntry // Son gösterilen klasörün içeriği.
+	file    string         // Menüsü açık olan dosyanın göreli yolu.
+	expires time.Time
+}
+
+// browserPrompt, gezginin kullanıcıdan beklediği metin yanıtıdır.
+type browserPrompt struct {
+	action  string
+	key     string
+	expires time.Time
+}
+
+var (
+	browserSessions = make(map[string]*browserSession)
+	browserPrompts  = make(map[int64]*browserPrompt) // Kullanıcı -> bekleyen yanıt
+	browserMutex    = &sync.Mutex{}
+)
+
+// #############################################################################
+// #                                 LİSTELEME
+// #############################################################################
+
+// readBrowserDir, bir klasörün kullanıcının erişebildiği içeriğini okur.
+// Klasörler önce, ardından dosyalar ada göre sıralanır.
+func readBrowserDir(userID int64, dir string) ([]browserEntry, error) {
+	dirPath := config.BaseDir
+	if dir != "" {
+		var ok bool
+		if dirPath, ok = pathOfKey(dir); !ok {
+			return nil, fmt.Errorf("geçersiz klasör")
+		}
+	}
+	items, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []browserEntry
+	for _, item := range items {
+		path := filepath.Join(dirPath, item.Name())
+		if item.IsDir() {
+			// * Klasörün kategorisi, içindeki bir dosyanın yolundan belirlenir.
+			if !canAccessPath(userID, filepath.Join(path, "_")) {
+				continue
+			}
+			children, _ := os.ReadDir(path)
+			entries = append(entries, browserEntry{name: item.Name(), dir: true, count: len(children)})
+			continue
+		}
+		// * Yazılmakta olan yüklemeler listelenmez.
+		if isIncomingTemp(item.Name()) || !canAccessPath(userID, path) {
+			continue
+		}
+		info, err := item.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, browserEntry{name: item.Name(), size: info.Size()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].dir != entries[j].dir {
+			return entries[i].dir
+		}
+		return strings.ToLower(entries[i].name) < strings.ToLower(entries[j].name)
+	})
+	return entries, nil
+}
+
+// browserPageCount, listenin kaç sayfa olduğunu döndürür (en az 1).
+func browserPageCount(entries []browserEntry) int {
+	return max(1, (len(entries)+browserPageSize-1)/browserPageSize)
+}
+
+// shortenButtonText, düğme metnini okunabilir uzunlukta tutar.
+func shortenButtonText(text string) string {
+	if utf8.RuneCountInString(text) <= browserButtonRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:browserButtonRunes-1]) + "…"
+}
+
+// browserData, bir gezgin düğmesinin verisini oluşturur.
+func browserData(token, op string, args ...string) string {
+	return callbackData("gez", append([]string{token, op}, args...)...)
+}
+
+// renderBrowserList, oturumun klasörünü ve sayfasını düğmeler halinde yazar.
+func renderBrowserList(token string, session *browserSession) (string, tgbotapi.InlineKeyboardMarkup) {
+	pages := browserPageCount(session.entries)
+	session.page = min(max(session.page, 1), pages)
+
+	var dirs, files int
+	for _, entry := range session.entries {
+		if entry.dir {
+			dirs++
+		} else {
+			files++
+		}
+	}
+	location := filepath.Base(config.BaseDir)
+	if session.dir != "" {
+		location += "/" + session.dir
+	}
+	text := fmt.Sprintf("🗂️ *Dosya Gezgini*\n📍 `%s`\n\n📁 %d klasör · 📄 %d dosya · Sayfa %d/%d",
+		location, dirs, files, session.page, pages)
+	if len(session.entries) == 0 {
+		text += "\n\n_Bu klasör boş._"
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	start := (session.page - 1) * browserPageSize
+	end := min(start+browserPageSize, len(session.entries))
+	for i := start; i < end; i++ {
+		entry := session.entries[i]
+		var button tgbotapi.InlineKeyboardButton
+		if entry.dir {
+			button = tgbotapi.NewInlineKeyboardButtonData(
+				shortenButtonText(fmt.Sprintf("📁 %s (%d)", entry.name, entry.count)), browserData(token, "d", strconv.Itoa(i)))
+		} else {
+			button = tgbotapi.NewInlineKeyboardButtonData(
+				shortenButtonText(fmt.Sprintf("📄 %s · %s", entry.name, formatFileSize(entry.size))), browserData(token, "f", strconv.Itoa(i)))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if session.page > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬅️", browserData(token, "p", strconv.Itoa(session.page-1))))
+	}
+	if session.dir != "" {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬆️ Üst klasör", browserData(token, "u")))
+	}
+	if session.page < pages {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("➡️", browserData(token, "p", strconv.Itoa(session.page+1))))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Yenile", browserData(token, "r")),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Kapat", browserData(token, "x")),
+	))
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// renderBrowserFile, bir dosyanın bilgilerini ve kullanıcının yapabileceği
+// işlemlerin düğmelerini yazar.
+func renderBrowserFile(token string, session *browserSession, info os.FileInfo) (string, tgbotapi.InlineKeyboardMarkup) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("📄 `%s`\n\n📏 %s · 🕒 %s\n📁 %s",
+		session.file, formatFileSize(info.Size()), info.ModTime().Format("02.01.2006 15:04"), categoryOfPath(filepath.Join(config.BaseDir, session.file))))
+	meta := getFileMetadata(session.file)
+	if meta.Description != "" {
+		builder.WriteString("\n📝 " + meta.Description)
+	}
+	if len(meta.Tags) > 0 {
+		builder.WriteString("\n🏷️ " + formatTags(meta.Tags))
+	}
+
+	labels := []struct{ action, label string }{
+		{browserActionGet, "📥 Getir"},
+		{browserActionPreview, "👁️ Önizle"},
+		{browserActionDescribe, "📝 Açıklama"},
+		{browserActionRename, "✏️ Yeniden adlandır"},
+		{browserActionMove, "📦 Taşı"},
+		{browserActionDelete, "🗑️ Sil"},
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, item := range labels {
+		if !canRunBrowserAction(session.userID, item.action) {
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(item.label, browserData(token, "a", item.action)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Klasöre dön", browserData(token, "b")),
+	))
+	return builder.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// canRunBrowserAction, kullanıcının bir gezgin işlemine karşılık gelen
+// komutu çalıştırma yetkisi olup olmadığını belirler.
+func canRunBrowserAction(userID int64, action string) bool {
+	spec, ok := lookupCommand(browserActionCommands[action])
+	return ok && canRunCommand(userID, spec)
+}
+
+// #############################################################################
+// #                                 KOMUTLAR
+// #############################################################################
+
+// handleBrowserCommand, /gezgin komutuyla ana klasörde (veya verilen
+// klasörde) bir gezgin mesajı açar.
+func handleBrowserCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	dir := strings.Trim(filepath.ToSlash(strings.TrimSpace(message.CommandArguments())), "/")
+	if dir != "" {
+		path, ok := pathOfKey(dir)
+		if info, err := os.Stat(path); !ok || err != nil || !info.IsDir() {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Klasör bulunamadı: `%s`", dir)))
+			return
+		}
+		dir = fileKey(path)
+		if !canAccessPath(message.From.ID, filepath.Join(path, "_")) {
+			bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+			return
+		}
+	}
+	entries, err := readBrowserDir(message.From.ID, dir)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Klasör okunamadı: %v", err)))
+		return
+	}
+
+	token := randomToken()[:10]
+	session := &browserSession{userID: message.From.ID, dir: dir, page: 1, entries: entries, expires: time.Now().Add(browserSessionTTL)}
+	browserMutex.Lock()
+	pruneBrowserLocked(time.Now())
+	browserSessions[token] = session
+	text, markup := renderBrowserList(token, session)
+	browserMutex.Unlock()
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = markup
+	bot.Send(msg)
+}
+
+// pruneBrowserLocked, süresi dolmuş oturumları ve yanıt beklentilerini siler.
+// `browserMutex` kilitliyken çağrılmalıdır.
+func pruneBrowserLocked(now time.Time) {
+	for token, session := range browserSessions {
+		if now.After(session.expires) {
+			delete(browserSessions, token)
+		}
+	}
+	for userID, prompt := range browserPrompts {
+		if now.After(prompt.expires) {
+			delete(browserPrompts, userID)
+		}
+	}
+}
+
+// handleBrowserCallback, gezgin düğmelerini işler.
+func handleBrowserCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	userID := callbackQuery.From.ID
+	parts := strings.SplitN(callbackQuery.Data, "_", 4)
+	if len(parts) < 3 {
+		return
+	}
+	token, op, arg := parts[1], parts[2], ""
+	if len(parts) == 4 {
+		arg = parts[3]
+	}
+
+	edit := func(text string, markup *tgbotapi.InlineKeyboardMarkup) {
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		editMsg.ParseMode = "Markdown"
+		editMsg.ReplyMarkup = markup
+		bot.Send(editMsg)
+	}
+
+	browserMutex.Lock()
+	session, found := browserSessions[token]
+	if found && session.userID != userID {
+		// * Gezgini yalnızca açan kullanıcı kullanabilir.
+		browserMutex.Unlock()
+		return
+	}
+	if !found || time.Now().After(session.expires) {
+		delete(browserSessions, token)
+		browserMutex.Unlock()
+		edit("⌛ Bu gezginin süresi dolmuş. Lütfen `/gezgin` komutunu yeniden gönderin.", nil)
+		return
+	}
+	if op == "x" {
+		delete(browserSessions, token)
+	}
+	session.expires = time.Now().Add(browserSessionTTL)
+	// * Klasör okuma ve Telegram çağrıları kilit dışında, oturumun bir kopyası
+	// * üzerinde yapılır; böylece yavaş bir disk veya ağ diğer kullanıcıların
+	// * gezginlerini bekletmez. Değişiklikler `save` ile geri yazılır.
+	state := *session
+	browserMutex.Unlock()
+
+	save := func() {
+		browserMutex.Lock()
+		defer browserMutex.Unlock()
+		if current, ok := browserSessions[token]; ok && current == session {
+			state.expires = current.expires
+			*current = state
+		}
+	}
+	showList := func() {
+		entries, err := readBrowserDir(userID, state.dir)
+		if err != nil {
+			// * Klasör silinmiş veya taşınmışsa ana klasöre dönülür.
+			state.dir = ""
+			entries, _ = readBrowserDir(userID, "")
+		}
+		state.entries, state.file = entries, ""
+		text, markup := renderBrowserList(token, &state)
+		save()
+		edit(text, &markup)
+	}
+	entryAt := func(raw string) (browserEntry, bool) {
+		index, err := strconv.Atoi(raw)
+		if err != nil || index < 0 || index >= len(state.entries) {
+			return browserEntry{}, false
+		}
+		return state.entries[index], true
+	}
+	childKey := func(name string) string {
+		if state.dir == "" {
+			return name
+		}
+		return state.dir + "/" + name
+	}
+
+	switch op {
+	case "x":
+		edit("🗂️ Gezgin kapatıldı.", nil)
+	case "r", "b":
+		showList()
+	case "p":
+		state.page, _ = strconv.Atoi(arg)
+		text, markup := renderBrowserList(token, &state)
+		save()
+		edit(text, &markup)
+	case "u":
+		if index := strings.LastIndex(state.dir, "/"); index >= 0 {
+			state.dir = state.dir[:index]
+		} else {
+			state.dir = ""
+		}
+		state.page = 1
+		showList()
+	case "d":
+		if entry, ok := entryAt(arg); ok && entry.dir {
+			state.dir, state.page = childKey(entry.name), 1
+		}
+		showList()
+	case "f":
+		entry, ok := entryAt(arg)
+		if !ok || entry.dir {
+			showList()
+			return
+		}
+		state.file = childKey(entry.name)
+		path, _ := pathOfKey(state.file)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			showList()
+			return
+		}
+		text, markup := renderBrowserFile(token, &state, info)
+		save()
+		edit(text, &markup)
+	case "a":
+		if state.file == "" {
+			showList()
+			return
+		}
+		runBrowserAction(bot, chatID, userID, state.file, arg)
+	}
+}
+
+// runBrowserAction, dosya menüsündeki bir işlemi çalıştırır. Metin
+// gerektiren işlemlerde kullanıcıdan yanıt istenir.
+func runBrowserAction(bot Messenger, chatID, userID int64, key, action string) {
+	if !canRunBrowserAction(userID, action) {
+		bot.Send(tgbotapi.NewMessage(chatID, "🚫 Bu işlem için yetkiniz bulunmuyor."))
+		return
+	}
+	path, ok := pathOfKey(key)
+	if info, err := os.Stat(path); !ok || err != nil || info.IsDir() {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` artık bulunamıyor. Listeyi yenileyin.", key)))
+		return
+	}
+	if !canAccessPath(userID, path) {
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return
+	}
+
+	var question string
+	switch action {
+	case browserActionGet:
+		goBackground(func() { sendStoredFile(bot, chatID, userID, path) })
+		return
+	case browserActionPreview:
+		goBackground(func() { sendFilePreview(bot, chatID, path) })
+		return
+	case browserActionDelete:
+		sendDeleteConfirmation(bot, chatID, userID, path)
+		return
+	case browserActionDescribe:
+		question = fmt.Sprintf("📝 `%s` için açıklamayı yazın:", key)
+	case browserActionRename:
+		question = fmt.Sprintf("✏️ `%s` için yeni adı yazın:", key)
+	case browserActionMove:
+		question = fmt.Sprintf("📦 `%s` hangi klasöre taşınsın? Klasör yolunu yazın (örn. `Dokümanlar/2024`):", key)
+	default:
+		return
+	}
+	browserMutex.Lock()
+	browserPrompts[userID] = &browserPrompt{action: action, key: key, expires: time.Now().Add(browserPromptTTL)}
+	browserMutex.Unlock()
+	msg := tgbotapi.NewMessage(chatID, question+"\n\n_İptal etmek için_ `iptal` _yazın._")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	bot.Send(msg)
+}
+
+// handleBrowserReply, gezginin beklediği bir metin yanıtı varsa işler ve
+// `true` döner. Komut olmayan metin mesajları için `handleFile`'dan önce
+// çağrılır.
+func handleBrowserReply(bot Messenger, message *tgbotapi.Message) bool {
+	if message.Text == "" || message.IsCommand() {
+		return false
+	}
+	userID := message.From.ID
+	chatID := message.Chat.ID
+	browserMutex.Lock()
+	prompt, found := browserPrompts[userID]
+	delete(browserPrompts, userID)
+	browserMutex.Unlock()
+	if !found || time.Now().After(prompt.expires) {
+		return false
+	}
+
+	start := time.Now()
+	input := strings.TrimSpace(message.Text)
+	if strings.EqualFold(input, "iptal") {
+		bot.Send(tgbotapi.NewMessage(chatID, "👍 İşlem iptal edildi."))
+		return true
+	}
+	command := browserActionCommands[prompt.action]
+	args := prompt.key + " " + input
+	if !canRunBrowserAction(userID, prompt.action) {
+		auditMessage(message, auditKindCommand, command, args, auditResultDenied, nil, start)
+		bot.Send(tgbotapi.NewMessage(chatID, "🚫 Bu işlem için yetkiniz bulunmuyor."))
+		return true
+	}
+	path, ok := pathOfKey(prompt.key)
+	if _, err := os.Stat(path); !ok || err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` artık bulunamıyor.", prompt.key)))
+		return true
+	}
+	if !canAccessPath(userID, path) {
+		auditMessage(message, auditKindCommand, command, args, auditResultDenied, nil, start)
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return true
+	}
+
+	var reply string
+	var err error
+	switch prompt.action {
+	case browserActionDescribe:
+		if err = addDescription(prompt.key, input); err != nil {
+			log.Printf("Açıklama eklenemedi (%s): %v", prompt.key, err)
+			err = fmt.Errorf("Açıklama eklenirken bir hata oluştu.")
+		} else {
+			reply = fmt.Sprintf("✅ *Açıklama eklendi!*\n\n📄 *Dosya:* `%s`\n📝 *Açıklama:* %s", prompt.key, input)
+		}
+	case browserActionRename:
+		var newPath string
+		if newPath, err = renameFileTo(userID, path, input); err == nil {
+			reply = fmt.Sprintf("✅ Dosya yeniden adlandırıldı:\n`%s` -> `%s`", prompt.key, fileKey(newPath))
+		}
+	case browserActionMove:
+		var newPath string
+		if newPath, err = moveFileToFolder(userID, path, input); err == nil {
+			reply = fmt.Sprintf("✅ Dosya başarıyla taşındı.\n\n📄 `%s`\n⬇️\n📁 `%s`", prompt.key, fileKey(newPath))
+		}
+	}
+	if err != nil {
+		auditMessage(message, auditKindCommand, command, args, auditResultError, err, start)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
+		return true
+	}
+	auditMessage(message, auditKindCommand, command, args, auditResultOK, nil, start)
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+	return true
+}
+
+// #############################################################################
+// #                                 ÖNİZLEME
+// #############################################################################
+
+// sendFilePreview, dosyayı indirmeden içeriği hakkında fikir verir: resimler
+// fotoğraf olarak, metin ve belgeler ilk satırlarıyla gösterilir. Diğer
+// dosyalar için algılanan tür bildirilir.
+func sendFilePreview(bot Messenger, chatID int64, path string) {
+	key := fileKey(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` okunamadı.", key)))
+		return
+	}
+	typ, known := detectFileType(path)
+	label := "bilinmiyor"
+	if known {
+		label = typ.Label
+	}
+
+	if known && strings.HasPrefix(typ.MIME, "image/") && typ.MIME != "image/svg+xml" && info.Size() <= maxPhotoPreview {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(path))
+		photo.Caption = fmt.Sprintf("👁️ %s · %s", key, formatFileSize(info.Size()))
+		if _, err := bot.Send(photo); err == nil {
+			return
+		}
+	}
+
+	var text string
+	if isIndexableFile(path) && info.Size() <= maxIndexFileSize {
+		text, err = extractText(path)
+		if err != nil {
+			log.Printf("Önizleme metni çıkarılamadı (%s): %v", key, err)
+		}
+	}
+	text = strings.TrimSpace(text)
+	header := fmt.Sprintf("👁️ %s\n🧬 %s · %s", key, label, formatFileSize(info.Size()))
+	if text == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, header+"\n\nBu dosya için metin önizlemesi yok. 📥 Getir ile indirebilirsiniz."))
+		return
+	}
+	if utf8.RuneCountInString(text) > maxPreviewRunes {
+		text = string([]rune(text)[:maxPreviewRunes]) + "\n…"
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, header+"\n\n"+text))
+}
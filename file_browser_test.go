@@ -0,0 +1,80 @@
+// file_browser_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newCallbackQuery, bir kullanıcının verilen düğmeye bastığı sorguyu oluşturur.
+func newCallbackQuery(userID int64, data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:      "cb",
+		From:    &tgbotapi.User{ID: userID, UserName: "test"},
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: userID}},
+		Data:    data,
+	}
+}
+
+// openTestBrowser, /gezgin komutunu çalıştırıp açılan oturumun anahtarını döndürür.
+func openTestBrowser(t *testing.T, bot *recordingMessenger, userID int64) string {
+	t.Helper()
+	handleCommand(bot, newCommandMessage(userID, "/gezgin"))
+	browserMutex.Lock()
+	defer browserMutex.Unlock()
+	for token, session := range browserSessions {
+		if session.userID == userID {
+			t.Cleanup(func() {
+				browserMutex.Lock()
+				delete(browserSessions, token)
+				browserMutex.Unlock()
+			})
+			return token
+		}
+	}
+	t.Fatal("gezgin oturumu açılmadı")
+	return ""
+}
+
+func TestBrowserCallbackNavigates(t *testing.T) {
+	bot := setupTestEnv(t)
+	dir := filepath.Join(config.BaseDir, "Belgeler")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rapor.txt"), []byte("içerik"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	token := openTestBrowser(t, bot, testOperatorID)
+
+	handleCallbackQuery(bot, newCallbackQuery(testOperatorID, browserData(token, "d", "0")))
+	if got := lastText(t, bot); !strings.Contains(got, "Belgeler") {
+		t.Errorf("klasöre girilmedi: %q", got)
+	}
+	browserMutex.Lock()
+	session := browserSessions[token]
+	gotDir, gotEntries := session.dir, len(session.entries)
+	browserMutex.Unlock()
+	if gotDir != "Belgeler" || gotEntries != 1 {
+		t.Errorf("oturum güncellenmedi: klasör %q, %d öğe", gotDir, gotEntries)
+	}
+
+	// Başka bir kullanıcı oturumu kullanamaz.
+	bot.Reset()
+	handleCallbackQuery(bot, newCallbackQuery(testAdminID, browserData(token, "u")))
+	if texts := bot.Texts(); len(texts) != 0 {
+		t.Errorf("yabancı kullanıcıya yanıt verildi: %q", texts)
+	}
+
+	handleCallbackQuery(bot, newCallbackQuery(testOperatorID, browserData(token, "x")))
+	browserMutex.Lock()
+	_, open := browserSessions[token]
+	browserMutex.Unlock()
+	if open {
+		t.Error("kapatılan oturum silinmedi")
+	}
+}
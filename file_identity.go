@@ -0,0 +1,297 @@
+// file_identity.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                             DOSYA KİMLİĞİ
+// #############################################################################
+// Bu dosya, dosyaların ana klasöre (`BaseDir`) göre göreli yollarıyla
+// tanımlanmasını sağlar (örn. `Dokümanlar/rapor.pdf`). Açıklamalar bu yolla
+// saklanır, böylece farklı klasörlerdeki aynı adlı dosyalar birbirine
+// karışmaz. Kullanıcı yalnızca dosya adı yazdığında ve bu ad birden fazla
+// dosyayla eşleştiğinde, hangisinin kastedildiğini soran satır içi düğmeler
+// gönderilir; seçilen yol ile komut yeniden çalıştırılır.
+
+// fileChoiceTTL, belirsiz dosya seçimi düğmelerinin geçerli kaldığı süredir.
+const fileChoiceTTL = 15 * time.Minute
+
+// maxFileChoices, seçim mesajında düğme olarak gösterilecek en fazla eşleşmedir.
+const maxFileChoices = 10
+
+// fileChoice, kullanıcıya sorulan bir dosya seçimini ve komutu yeniden
+// çalıştırmak için gereken bilgileri tutar.
+type fileChoice struct {
+	message    tgbotapi.Message // Seçimi tetikleyen orijinal komut mesajı
+	command    string
+	rest       string   // Dosya adından sonra gelen argümanlar
+	candidates []string // Göreli yollar
+	expires    time.Time
+}
+
+var (
+	pendingFileChoices = make(map[string]*fileChoice)
+	fileChoiceMutex    = &sync.Mutex{}
+)
+
+// fileKey, mutlak bir dosya yolunu ana klasöre göre göreli, `/` ayraçlı
+// kimliğine çevirir. Ana klasör dışındaki yollar için mutlak yol döner.
+func fileKey(absPath string) string {
+	rel, err := filepath.Rel(config.BaseDir, absPath)
+	// * `.._rapor.txt` gibi `..` ile başlayan adlar ana klasörün içindedir.
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(absPath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// pathOfKey, göreli bir dosya kimliğini mutlak yola çevirir. Ana klasörün
+// dışına çıkan yollar, sembolik bağlantılar üzerinden çıkanlar dahil,
+// reddedilir (bkz. `safe_path.go`).
+func pathOfKey(key string) (string, bool) {
+	path, err := resolveInBase(key)
+	if err != nil || path == filepath.Clean(config.BaseDir) {
+		return "", false
+	}
+	return path, true
+}
+
+// isPathQuery, kullanıcının bir dosya adı yerine göreli yol yazıp yazmadığını belirler.
+func isPathQuery(name string) bool {
+	return strings.ContainsAny(name, `/\`)
+}
+
+// findFileMatches, verilen ada sahip tüm dosyaları ana klasör ve tüm alt
+// klasörlerinde (kategori, `/tasi` ile oluşturulan klasörler, `KırpmaKlasörü`)
+// arar ve mutlak yollarını sıralı olarak döndürür. Ad göreli bir yol ise
+// yalnızca o yol kontrol edilir.
+func findFileMatches(name string) []string {
+	if isPathQuery(name) {
+		path, ok := pathOfKey(name)
+		if !ok {
+			return nil
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return []string{path}
+		}
+		return nil
+	}
+
+	var matches []string
+	filepath.Walk(config.BaseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		// * Windows dosya sistemi büyük/küçük harf duyarsız olduğu için karşılaştırma da öyledir.
+		// * Ana klasör dışını gösteren sembolik bağlantılar eşleşmeye dahil edilmez.
+		if info.Mode()&os.ModeSymlink != 0 && !isInsideBase(path) {
+			return nil
+		}
+		if !info.IsDir() && strings.EqualFold(info.Name(), name) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	sort.Strings(matches)
+	return matches
+}
+
+// resolveFileForCommand, bir komutun ilk argümanındaki dosyayı çözümler.
+// Dosya bulunamazsa veya kullanıcının erişimi yoksa ilgili mesajı gönderir.
+// Ad birden fazla erişilebilir dosyayla eşleşiyorsa seçim düğmeleri gönderir.
+// Bu üç durumda `false` döner ve komut işleyicisi sonlanmalıdır.
+func resolveFileForCommand(bot Messenger, message *tgbotapi.Message, name, rest, notFoundText string) (string, bool) {
+	return resolveFileFor(bot, message, message.Command(), name, rest, notFoundText)
+}
+
+// resolveFileFor, `resolveFileForCommand` ile aynıdır ancak seçim yapıldığında
+// çalıştırılacak komut açıkça verilir. Komut dışı akışlar (örn. LLM araçları)
+// tarafından kullanılır.
+func resolveFileFor(bot Messenger, message *tgbotapi.Message, command, name, rest, notFoundText string) (string, bool) {
+	chatID := message.Chat.ID
+	matches := findFileMatches(name)
+	if len(matches) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, notFoundText))
+		return "", false
+	}
+
+	var accessible []string
+	for _, path := range matches {
+		if canAccessPath(message.From.ID, path) {
+			accessible = append(accessible, path)
+		}
+	}
+	switch len(accessible) {
+	case 0:
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return "", false
+	case 1:
+		return accessible[0], true
+	}
+
+	sendFileChoice(bot, message, command, accessible, rest)
+	return "", false
+}
+
+// sendFileChoice, eşleşen dosyaları satır içi düğmelerle listeler.
+func sendFileChoice(bot Messenger, message *tgbotapi.Message, command string, paths []string, rest string) {
+	chatID := message.Chat.ID
+	keys := make([]string, len(paths))
+	for i, path := range paths {
+		keys[i] = fileKey(path)
+	}
+
+	if len(keys) > maxFileChoices {
+		text := fmt.Sprintf("🔀 Bu adla %d dosya eşleşti. Lütfen tam yolu yazın (örn. `/%s %s`):\n\n`%s`",
+			len(keys), command, keys[0], strings.Join(keys, "`\n`"))
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	token := randomToken()[:12]
+	fileChoiceMutex.Lock()
+	pruneFileChoicesLocked(time.Now())
+	pendingFileChoices[token] = &fileChoice{
+		message:    *message,
+		command:    command,
+		rest:       rest,
+		candidates: keys,
+		expires:    time.Now().Add(fileChoiceTTL),
+	}
+	fileChoiceMutex.Unlock()
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, key := range keys {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📄 "+key, fmt.Sprintf("dosya_%s_%d", token, i)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("❌ İptal", fmt.Sprintf("dosya_%s_iptal", token)),
+	))
+	msg := tgbotapi.NewMessage(chatID, "🔀 Bu adla birden fazla dosya bulundu. Hangisini kastettiniz?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	bot.Send(msg)
+}
+
+// handleFileChoiceCallback, seçim düğmesine basıldığında komutu seçilen
+// göreli yolla yeniden çalıştırır. Komut normal akıştan geçtiği için yetki
+// kontrolleri ve denetim kaydı yeniden uygulanır.
+func handleFileChoiceCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 {
+		return
+	}
+	token, choice := parts[1], parts[2]
+
+	fileChoiceMutex.Lock()
+	pending, found := pendingFileChoices[token]
+	if found && (pending.message.From == nil || pending.message.From.ID != callbackQuery.From.ID) {
+		// * Seçimi yalnızca komutu gönderen kullanıcı yapabilir.
+		fileChoiceMutex.Unlock()
+		return
+	}
+	if found && time.Now().After(pending.expires) {
+		found = false
+	}
+	delete(pendingFileChoices, token)
+	fileChoiceMutex.Unlock()
+
+	if !found {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⌛ Bu seçimin süresi dolmuş. Lütfen komutu yeniden gönderin."))
+		return
+	}
+	if choice == "iptal" {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "👍 İşlem iptal edildi."))
+		return
+	}
+	var index int
+	if _, err := fmt.Sscanf(choice, "%d", &index); err != nil || index < 0 || index >= len(pending.candidates) {
+		return
+	}
+	key := pending.candidates[index]
+	bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "📄 Seçilen dosya: "+key))
+
+	// * Orijinal mesaj, seçilen yolu içerecek şekilde yeniden oluşturulur.
+	commandText := "/" + pending.command
+	message := pending.message
+	message.Text = commandText + " " + key + pending.rest
+	message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(utf16.Encode([]rune(commandText)))}}
+	handleCommand(bot, &message)
+}
+
+// pruneFileChoicesLocked, süresi dolmuş seçimleri siler.
+// `fileChoiceMutex` kilitliyken çağrılmalıdır.
+func pruneFileChoicesLocked(now time.Time) {
+	for token, choice := range pendingFileChoices {
+		if now.After(choice.expires) {
+			delete(pendingFileChoices, token)
+		}
+	}
+}
+
+// legacyMetadataKey, eski (yalnızca dosya adıyla tutulan) bir açıklama
+// anahtarını göreli yola çevirir. Ad diskte tek bir dosyayla eşleşmiyorsa
+// anahtar olduğu gibi bırakılır.
+func legacyMetadataKey(key string) string {
+	if isPathQuery(key) {
+		return key
+	}
+	matches := findFileMatches(key)
+	switch len(matches) {
+	case 1:
+		return fileKey(matches[0])
+	case 0:
+		return key
+	default:
+		log.Printf("Uyarı: `%s` açıklaması birden fazla dosyayla eşleşiyor, eski adıyla bırakıldı.", key)
+		return key
+	}
+}
+
+// rekeyLegacyMetadata, bir işlem içindeki tüm eski anahtarları göreli
+// yollara taşır. Hedef anahtarda zaten bir kayıt varsa o korunur.
+func rekeyLegacyMetadata(tx MetadataTx) (int, error) {
+	type rename struct{ from, to string }
+	var renames []rename
+	err := tx.ForEach(func(key string, meta FileMetadata) error {
+		if newKey := legacyMetadataKey(key); newKey != key {
+			renames = append(renames, rename{key, newKey})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range renames {
+		meta, _, err := tx.Get(r.from)
+		if err != nil {
+			return 0, err
+		}
+		if _, exists, err := tx.Get(r.to); err != nil {
+			return 0, err
+		} else if !exists {
+			if err := tx.Put(r.to, meta); err != nil {
+				return 0, err
+			}
+		}
+		if err := tx.Delete(r.from); err != nil {
+			return 0, err
+		}
+	}
+	return len(renames), nil
+}
@@ -7,23 +7,32 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 // #############################################################################
 // #                             DOSYA YÖNETİCİSİ
 // #############################################################################
 // Bu dosya, botun dosya sistemiyle ilgili temel işlemlerini yönetir.
-// Dosyaları kategorilere ayırma, belirli bir dosyayı tüm klasörlerde arama
-// ve program için gerekli klasör yapısını oluşturma gibi görevleri içerir.
+// Dosyaları kategorilere ayırma ve program için gerekli klasör yapısını
+// oluşturma gibi görevleri içerir. Dosya arama ve kimlikleri için bkz.
+// `file_identity.go`.
 
-// kategoriler, dosya uzantılarını ait oldukları kategoriyle eşleştiren
-// bir haritadır (map). Yeni dosya türleri eklemek için bu liste genişletilebilir.
-var kategoriler = map[string][]string{
-	"Resimler":   {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".webp", ".tiff"},
-	"Videolar":   {".mp4", ".mkv", ".mov", ".avi", ".webm", ".flv", ".wmv", ".m4v"},
-	"Dokümanlar": {".pdf", ".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".txt", ".rtf", ".odt", ".ods"},
-	"Sesler":     {".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma", ".m4a", ".opus"},
-	"Arşivler":   {".zip", ".rar", ".7z", ".tar", ".gz", ".bz2"},
+// otherCategoryName, hiçbir kategoriye uymayan dosyaların taşındığı klasördür.
+const otherCategoryName = "Diğer"
+
+// defaultCategories, `config.yaml` içinde `categories` tanımlanmadığında
+// kullanılan, dosya uzantılarını kategorilerle eşleştiren haritayı döndürür.
+// Güncel liste `fileCategories()` ile okunur.
+func defaultCategories() map[string][]string {
+	return map[string][]string{
+		"Resimler":   {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".svg", ".webp", ".tiff"},
+		"Videolar":   {".mp4", ".mkv", ".mov", ".avi", ".webm", ".flv", ".wmv", ".m4v"},
+		"Dokümanlar": {".pdf", ".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".txt", ".rtf", ".odt", ".ods"},
+		"Sesler":     {".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma", ".m4a", ".opus"},
+		"Arşivler":   {".zip", ".rar", ".7z", ".tar", ".gz", ".bz2"},
+	}
 }
 
 // ensureDirectories, program ilk başladığında çalışarak botun ihtiyaç duyduğu
@@ -31,95 +40,173 @@ var kategoriler = map[string][]string{
 // Eğer klasörler mevcut değilse, onları oluşturur.
 func ensureDirectories() {
 	os.MkdirAll(config.BaseDir, os.ModePerm)
-	for category := range kategoriler {
+	for category := range fileCategories() {
 		os.MkdirAll(filepath.Join(config.BaseDir, category), os.ModePerm)
 	}
-	os.MkdirAll(filepath.Join(config.BaseDir, "Diğer"), os.ModePerm)
+	os.MkdirAll(filepath.Join(config.BaseDir, otherCategoryName), os.ModePerm)
 }
 
 // getFileCategory, bir dosya adını alır, uzantısını kontrol eder ve
-// `kategoriler` haritasına göre hangi kategoriye ait olduğunu döndürür.
+// yapılandırmadaki kategorilere göre hangi kategoriye ait olduğunu döndürür.
 // Eşleşme bulunamazsa "Diğer" kategorisini döndürür.
 func getFileCategory(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
-	for category, extensions := range kategoriler {
+	for category, extensions := range fileCategories() {
 		for _, e := range extensions {
 			if e == ext {
 				return category
 			}
 		}
 	}
-	return "Diğer"
+	return otherCategoryName
 }
 
-// findFile, verilen dosya adını tüm olası konumlarda (ana dizin, tüm kategori
-// klasörleri ve medya işleme klasörleri) arar.
-// Dosyayı bulursa, tam yolunu ve `true` değerini döndürür. Bulamazsa, boş string ve `false` döner.
-func findFile(filename string) (string, bool) {
-	// * Aranacak tüm klasörlerin bir listesi dinamik olarak oluşturulur.
-	searchDirs := []string{config.BaseDir}
-	for category := range kategoriler {
-		searchDirs = append(searchDirs, filepath.Join(config.BaseDir, category))
-	}
-	searchDirs = append(searchDirs, filepath.Join(config.BaseDir, "Diğer"))
-	searchDirs = append(searchDirs, getClippingFolderPath()) // Video kırpma klasörü de dahil edilir.
+// organizeMutex, saatlik, dosya izleyicisi ve komutla başlatılan
+// düzenlemelerin aynı anda çalışmasını engeller.
+var organizeMutex = &sync.Mutex{}
 
-	for _, dir := range searchDirs {
-		filePath := filepath.Join(dir, filename)
-		// * `os.Stat`, bir dosya veya dizin hakkında bilgi döndürür.
-		// * Hata `nil` ise (yani hata yoksa), bu dosyanın o konumda var olduğu anlamına gelir.
-		if _, err := os.Stat(filePath); err == nil {
-			return filePath, true
-		}
-	}
-	return "", false
-}
-
-// organizeFiles, ana `Gelenler` klasöründeki tüm dosyaları tarar ve her birini
-// `getFileCategory` fonksiyonunu kullanarak doğru kategori klasörüne taşır.
-func organizeFiles() int {
-	files, err := os.ReadDir(config.BaseDir)
-	if err != nil {
-		log.Printf("Ana klasör okunamadı: %v", err)
-		return 0
-	}
+// organizeFiles, ana `Gelenler` klasöründeki dosyaları düzenleme kurallarına
+// (bkz. `organizer_rules.go`), kurala uymayanları ise `getFileCategory` ile
+// bulunan kategori klasörüne taşır. Hedefte aynı adda dosya varsa gelen
+// dosyalarda olduğu gibi `INCOMING_COLLISION` politikası uygulanır (bkz.
+// `organizeCollisionPolicy`). Taşınan dosyalar tek bir işlem olarak günlüğe
+// yazılır; zamanlayıcı `systemUserID` ile çağırır.
+func organizeFiles(bot Messenger, userID int64) int {
+	organizeMutex.Lock()
+	defer organizeMutex.Unlock()
 
-	organizedCount := 0
-	for _, file := range files {
-		if file.IsDir() {
-			continue // Sadece dosyalarla ilgilen, klasörleri atla.
+	var steps []OperationStep
+	for _, plan := range planOrganize(time.Now()) {
+		sourcePath := plan.Facts.Path
+		if plan.Target == sourcePath {
+			continue
 		}
-
-		sourcePath := filepath.Join(config.BaseDir, file.Name())
-		category := getFileCategory(file.Name())
-		targetDir := filepath.Join(config.BaseDir, category)
-		targetPath := filepath.Join(targetDir, file.Name())
-
-		// * ÖNEMLİ: Bu döngü, hedef klasörde aynı adda bir dosya varsa,
-		// * üzerine yazmak yerine dosya adının sonuna `_1`, `_2` gibi
-		// * bir sayaç ekleyerek isimlendirme çakışmalarını önler.
-		counter := 1
-		originalTargetPath := targetPath
-		for {
-			if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-				// Dosya bu isimle mevcut değil, döngüden çık.
-				break
+		if err := os.MkdirAll(filepath.Dir(plan.Target), os.ModePerm); err != nil {
+			log.Printf("Hedef klasör oluşturulamadı: %s - %v", plan.Target, err)
+			continue
+		}
+		targetPath, version, trashID, owner := plan.Target, "", 0, userID
+		if _, err := os.Lstat(targetPath); err == nil {
+			var policy string
+			policy, owner = organizeCollisionPolicy(userID, plan)
+			switch policy {
+			case collisionAsk:
+				if holdForCollision(bot, owner, plan) {
+					continue
+				}
+				targetPath = uniqueTargetPath(plan.Target)
+			case collisionOverwrite:
+				entry, err := moveToTrash(owner, targetPath)
+				if err != nil {
+					log.Printf("Mevcut dosya çöp kutusuna taşınamadı: %s - %v", fileKey(targetPath), err)
+					targetPath = uniqueTargetPath(plan.Target)
+				} else {
+					trashID = entry.ID
+				}
+			case collisionVersion:
+				if version, err = archiveVersion(targetPath); err != nil {
+					log.Printf("Sürüm saklanamadı: %s - %v", fileKey(targetPath), err)
+					targetPath = uniqueTargetPath(plan.Target)
+				}
+			default:
+				targetPath = uniqueTargetPath(plan.Target)
 			}
-			// Dosya mevcut, yeni bir isim oluştur ve tekrar kontrol et.
-			ext := filepath.Ext(originalTargetPath)
-			base := strings.TrimSuffix(filepath.Base(originalTargetPath), ext)
-			targetPath = filepath.Join(targetDir, fmt.Sprintf("%s_%d%s", base, counter, ext))
-			counter++
 		}
 
 		// * `os.Rename`, Go'da hem dosyaları yeniden adlandırmak hem de
 		// * aynı disk bölümü (volume) içinde taşımak için kullanılır.
-		if err := os.Rename(sourcePath, targetPath); err == nil {
-			organizedCount++
-			log.Printf("Düzenlendi: %s -> %s", file.Name(), category)
+		if err := os.Rename(sourcePath, targetPath); err != nil {
+			log.Printf("Taşıma hatası: %s - %v", plan.Facts.Key, err)
+			restoreReplaced(owner, incomingResult{Replaced: trashID != 0, TrashID: trashID, Version: version}, targetPath)
+			continue
+		}
+		targetKey := fileKey(targetPath)
+		step := OperationStep{From: plan.Facts.Key, To: targetKey}
+		if version != "" {
+			step.Replaced, step.Version = true, versionKeyOf(version)
+		} else if trashID != 0 {
+			step.Replaced, step.TrashID = true, trashID
+		}
+		steps = append(steps, step)
+		if plan.Rule != nil {
+			log.Printf("Düzenlendi: %s -> %s (kural: %s)", plan.Facts.Key, targetKey, plan.Rule.Name)
 		} else {
-			log.Printf("Taşıma hatası: %s - %v", file.Name(), err)
+			log.Printf("Düzenlendi: %s -> %s", plan.Facts.Key, targetKey)
+		}
+		// Varsa, açıklama da dosyayla birlikte yeni konumuna taşınır.
+		if err := moveFileMetadata(plan.Facts.Key, targetKey); err != nil {
+			log.Printf("Açıklama taşınamadı: %s - %v", plan.Facts.Key, err)
 		}
+		if err := moveFileVersions(plan.Facts.Key, targetKey); err != nil {
+			log.Printf("Sürümler taşınamadı: %s - %v", plan.Facts.Key, err)
+		}
+		applyRuleActions(plan.Rule, targetKey, plan.Facts)
 	}
-	return organizedCount
-}
\ No newline at end of file
+	recordOperation(userID, operationOrganize, steps...)
+	return len(steps)
+}
+
+// organizeCollisionPolicy, düzenlenen dosyanın hedefinde aynı adda dosya
+// olduğunda uygulanacak politikayı ve kararın sahibini belirler. Dosyayı bota
+// gönderen veya indiren biliniyorsa politika onun yetkisine göre daraltılır
+// (bkz. `collisionPolicyFor`); bilinmiyorsa düzenlemeyi başlatan kullanıcı
+// esas alınır. Zamanlayıcının düzenlediği yerel dosyalarda yalnızca hiçbir
+// dosyayı silmeyen `version` uygulanır. Soru yalnızca dosyayı gönderene
+// sorulur; yanıtlanmayan sorudaki dosya silineceği için elinde bir kopyası
+// olmalıdır.
+func organizeCollisionPolicy(userID int64, plan organizePlan) (policy string, owner int64) {
+	owner = plan.Facts.Sender
+	if owner == systemUserID {
+		owner = userID
+	}
+	if owner == systemUserID {
+		if config.IncomingCollision == collisionVersion {
+			return collisionVersion, owner
+		}
+		return collisionRename, owner
+	}
+	policy = collisionPolicyFor(owner, plan.Target, config.IncomingCollision)
+	if policy == collisionAsk && plan.Facts.Sender == systemUserID {
+		policy = collisionRename
+	}
+	return policy, owner
+}
+
+// holdForCollision, hedefinde aynı adda dosya bulunan dosyayı ana klasörde
+// gizli bir geçici dosyaya (`.yukleniyor-…`) alır ve ne yapılacağını gönderene
+// sorar; kayıt `handleIncomingCallback` ile tamamlanır. Dosya bekletilemezse
+// false döner.
+func holdForCollision(bot Messenger, owner int64, plan organizePlan) bool {
+	held, err := os.CreateTemp(config.BaseDir, incomingTempPrefix+"*")
+	if err != nil {
+		log.Printf("Dosya bekletilemedi: %s - %v", plan.Facts.Key, err)
+		return false
+	}
+	held.Close()
+	if err := os.Rename(plan.Facts.Path, held.Name()); err != nil {
+		os.Remove(held.Name())
+		log.Printf("Dosya bekletilemedi: %s - %v", plan.Facts.Key, err)
+		return false
+	}
+	if err := moveFileMetadata(plan.Facts.Key, fileKey(held.Name())); err != nil {
+		log.Printf("Açıklama taşınamadı: %s - %v", plan.Facts.Key, err)
+	}
+	log.Printf("Düzenleme bekletiliyor: %s -> %s (gönderene soruldu)", plan.Facts.Key, fileKey(plan.Target))
+	askIncomingCollision(bot, owner, owner, held.Name(), plan.Target, plan.Facts.Source)
+	return true
+}
+
+// uniqueTargetPath, hedefte aynı adda bir dosya varsa adın sonuna `_1`, `_2`
+// gibi bir sayaç ekleyerek boş bir yol bulur; böylece hiçbir dosyanın
+// üzerine yazılmaz.
+func uniqueTargetPath(targetPath string) string {
+	ext := filepath.Ext(targetPath)
+	base := strings.TrimSuffix(targetPath, ext)
+	candidate := targetPath
+	for counter := 1; ; counter++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, counter, ext)
+	}
+}
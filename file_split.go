@@ -0,0 +1,468 @@
+// file_split.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                        PARÇALI DOSYA GÖNDERİMİ
+// #############################################################################
+// Bu dosya, Telegram'ın gönderme limitinden büyük dosyaların numaralı
+// parçalara bölünerek gönderilmesini ve bota gönderilen parçaların yeniden
+// birleştirilmesini sağlar. Bölme düz bayt bölmesidir: `rapor.mp4.part001`,
+// `rapor.mp4.part002`, ... parçaları sırayla gönderilir, ardından her parçanın
+// ve tüm dosyanın SHA-256 özetini içeren `rapor.mp4.parts.json` dosyası
+// gelir. Parçalar diske yazılmadan doğrudan dosyadan okunarak gönderilir.
+//
+// Bota gönderilen parçalar ana klasörün dışındaki `Parcalar` klasöründe
+// (kullanıcı ve dosya adına göre) bekletilir. Manifest ve tüm parçalar
+// geldiğinde özetler doğrulanır, parçalar birleştirilir ve dosya ana klasöre
+// taşınır. Tamamlanmayan parçalar bir hafta sonra silinir.
+
+const (
+	splitManifestSuffix = ".parts.json"
+	splitManifestVer    = 1
+	maxSplitParts       = 999
+	staleSplitPartsAge  = 7 * 24 * time.Hour
+)
+
+// splitPartPattern, `ad.part001` biçimindeki parça adlarını eşler.
+var splitPartPattern = regexp.MustCompile(`^(.+)\.part(\d{3})$`)
+
+// splitPart, manifestteki tek bir parçanın kaydıdır.
+type splitPart struct {
+	Index  int    `json:"index"` // 1'den başlar
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// splitManifest, bölünmüş bir dosyanın parçalarını ve özetlerini tanımlar.
+type splitManifest struct {
+	Version  int         `json:"version"`
+	Name     string      `json:"name"`
+	Size     int64       `json:"size"`
+	SHA256   string      `json:"sha256"`
+	PartSize int64       `json:"part_size"`
+	Parts    []splitPart `json:"parts"`
+}
+
+// splitPartName, parçanın dosya adını döndürür (1'den başlayan sıra ile).
+func splitPartName(name string, index int) string {
+	return fmt.Sprintf("%s.part%03d", name, index)
+}
+
+// parseSplitName, bir dosya adının parça veya manifest olup olmadığını
+// belirler. Parçalar için sıra numarası, manifest için 0 döner.
+func parseSplitName(fileName string) (original string, index int, ok bool) {
+	if strings.HasSuffix(fileName, splitManifestSuffix) && len(fileName) > len(splitManifestSuffix) {
+		return strings.TrimSuffix(fileName, splitManifestSuffix), 0, true
+	}
+	if match := splitPartPattern.FindStringSubmatch(fileName); match != nil {
+		index, _ := strconv.Atoi(match[2])
+		if index > 0 {
+			return match[1], index, true
+		}
+	}
+	return "", 0, false
+}
+
+// splitPartSize, parça boyutunu döndürür. Yapılandırılan değer gönderme
+// limitini aşamaz.
+func splitPartSize() int64 {
+	configMutex.RLock()
+	size := config.SplitPartSize
+	configMutex.RUnlock()
+	if limit := uploadLimit(); size <= 0 || size > limit {
+		return limit
+	}
+	return size
+}
+
+// splitPartCount, dosyanın kaç parçaya bölüneceğini hesaplar.
+func splitPartCount(size, partSize int64) int {
+	return int((size + partSize - 1) / partSize)
+}
+
+// #############################################################################
+// #                              GÖNDERME
+// #############################################################################
+
+// offerSplitSend, gönderme limitinden büyük bir dosya için parçalara bölerek
+// gönderme seçeneğini düğmeyle sunar. Düğmeyi yalnızca isteyen kullanıcı
+// kullanabilir.
+func offerSplitSend(bot Messenger, chatID, userID int64, filePath string, size int64) {
+	partSize := splitPartSize()
+	count := splitPartCount(size, partSize)
+	limitText := fmt.Sprintf("❌ `%s` çok büyük (%s). Telegram ile en fazla %s gönderilebilir.",
+		fileKey(filePath), formatFileSize(size), formatFileSize(uploadLimit()))
+	if count > maxSplitParts {
+		bot.Send(tgbotapi.NewMessage(chatID, limitText))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\n\n✂️ Dosya %d parçaya (en fazla %s) bölünerek gönderilebilir. Parçaları birleştirme bilgisi son mesajda gelir.",
+		limitText, count, formatFileSize(partSize)))
+	id := newCallbackPayload(userID, fileKey(filePath))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✂️ %d parça gönder", count), callbackData("parca", "gonder", id)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ İptal", callbackData("parca", "iptal", id)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleSplitCallback, parçalı gönderme düğmelerini işler.
+func handleSplitCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	userID := callbackQuery.From.ID
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 {
+		return
+	}
+
+	key, err := takeCallbackPayload(parts[2], userID)
+	if errors.Is(err, errPayloadForeign) {
+		return
+	}
+	if parts[1] != "gonder" {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "👍 Gönderim iptal edildi."))
+		return
+	}
+	if err != nil {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⌛ Bu seçeneğin süresi dolmuş. Lütfen `/getir` komutunu yeniden gönderin."))
+		return
+	}
+	filePath, ok := pathOfKey(key)
+	if !ok {
+		return
+	}
+	if !canAccessPath(userID, filePath) {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, categoryDeniedText))
+		return
+	}
+	bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("✂️ `%s` parçalara bölünerek gönderiliyor...", key)))
+	goBackground(func() {
+		if err := sendFileInParts(bot, chatID, filePath); err != nil {
+			log.Printf("Parçalı gönderim başarısız (%s): %v", key, err)
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` gönderilemedi: %v", key, err)))
+		}
+	})
+}
+
+// sendFileInParts, dosyayı parçalara bölerek sırayla gönderir ve ilerlemeyi
+// bir durum mesajında gösterir. Son olarak parçaların özetlerini içeren
+// manifest gönderilir.
+func sendFileInParts(bot Messenger, chatID int64, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(filePath)
+	partSize := splitPartSize()
+	count := splitPartCount(info.Size(), partSize)
+	if count > maxSplitParts {
+		return fmt.Errorf("dosya %d parçadan fazlasına bölünemez", maxSplitParts)
+	}
+	manifest := splitManifest{Version: splitManifestVer, Name: name, Size: info.Size(), PartSize: partSize}
+
+	status, err := bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📤 `%s` gönderiliyor: 0/%d parça", name, count)))
+	if err != nil {
+		return err
+	}
+	whole := sha256.New()
+	for i := 1; i <= count; i++ {
+		if jobsCtx.Err() != nil {
+			return fmt.Errorf("bot kapanıyor, gönderim yarıda kesildi")
+		}
+		offset := int64(i-1) * partSize
+		length := min(partSize, info.Size()-offset)
+		partHash := sha256.New()
+		reader := io.TeeReader(io.NewSectionReader(file, offset, length), io.MultiWriter(partHash, whole))
+
+		part := splitPart{Index: i, Name: splitPartName(name, i), Size: length}
+		doc := tgbotapi.NewDocument(chatID, tgbotapi.FileReader{Name: part.Name, Reader: reader})
+		doc.Caption = fmt.Sprintf("🧩 %s · Parça %d/%d", name, i, count)
+		if _, err := bot.Send(doc); err != nil {
+			return fmt.Errorf("parça %d gönderilemedi: %w", i, err)
+		}
+		part.SHA256 = hex.EncodeToString(partHash.Sum(nil))
+		manifest.Parts = append(manifest.Parts, part)
+
+		edit := tgbotapi.NewEditMessageText(chatID, status.MessageID,
+			fmt.Sprintf("📤 `%s` gönderiliyor: %d/%d parça (%%%d)", name, i, count, i*100/count))
+		edit.ParseMode = "Markdown"
+		bot.Request(edit)
+	}
+	manifest.SHA256 = hex.EncodeToString(whole.Sum(nil))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name + splitManifestSuffix, Bytes: data})
+	doc.Caption = fmt.Sprintf("📋 %s parça listesi (%d parça, %s)\n\n"+
+		"Birleştirmek için:\n"+
+		"Windows: copy /b \"%s.part*\" \"%s\"\n"+
+		"Linux/macOS: cat \"%s\".part* > \"%s\"\n\n"+
+		"Parçaları ve bu dosyayı bota geri gönderirseniz özetler doğrulanıp dosya yeniden oluşturulur.",
+		name, count, formatFileSize(info.Size()), name, name, name, name)
+	if _, err := bot.Send(doc); err != nil {
+		return fmt.Errorf("parça listesi gönderilemedi: %w", err)
+	}
+	bot.Request(tgbotapi.NewDeleteMessage(chatID, status.MessageID))
+	log.Printf("Parçalı gönderim tamamlandı: %s (%d parça)", fileKey(filePath), count)
+	return nil
+}
+
+// #############################################################################
+// #                             BİRLEŞTİRME
+// #############################################################################
+
+// splitPartsMutex, bekleyen parçaların klasörlerine erişimi korur. Aynı
+// dosyanın parçaları eş zamanlı gelebileceği için birleştirme bir kez yapılır.
+var splitPartsMutex = &sync.Mutex{}
+
+// splitStagingDir, bir kullanıcının belirli bir dosya için gönderdiği
+// parçaların bekletildiği klasördür.
+func splitStagingDir(userID int64, original string) string {
+	return filepath.Join(config.PartsDir, strconv.FormatInt(userID, 10), filepath.Base(original))
+}
+
+// receiveSplitPart, bota gönderilen bir parçayı veya manifesti bekleme
+// klasörüne kaydeder. Tüm parçalar tamamlandıysa dosyayı birleştirir ve
+// kullanıcıya sonucu bildirir.
+func receiveSplitPart(bot Messenger, message *tgbotapi.Message, fileName string, body io.Reader) {
+	chatID := message.Chat.ID
+	original, index, _ := parseSplitName(fileName)
+	dir := splitStagingDir(message.From.ID, original)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Parça kaydedilemedi: %v", err)))
+		return
+	}
+	target := filepath.Join(dir, filepath.Base(fileName))
+	if err := writeFileFrom(target, body); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Parça kaydedilemedi: %v", err)))
+		return
+	}
+
+	splitPartsMutex.Lock()
+	defer splitPartsMutex.Unlock()
+	manifest, err := readSplitManifest(dir, original)
+	if os.IsNotExist(err) {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🧩 `%s` parça %d alındı. Birleştirmek için `%s%s` dosyasını da gönderin.",
+			original, index, original, splitManifestSuffix)))
+		return
+	} else if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s%s` okunamadı: %v", original, splitManifestSuffix, err)))
+		os.Remove(filepath.Join(dir, original+splitManifestSuffix))
+		return
+	}
+
+	var missing []string
+	for _, part := range manifest.Parts {
+		if _, err := os.Stat(filepath.Join(dir, part.Name)); err != nil {
+			missing = append(missing, strconv.Itoa(part.Index))
+		}
+	}
+	if len(missing) > 0 {
+		received := len(manifest.Parts) - len(missing)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🧩 `%s`: %d/%d parça alındı. Eksik parçalar: %s",
+			original, received, len(manifest.Parts), strings.Join(missing, ", "))))
+		return
+	}
+
+	status, _ := bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔗 `%s` birleştiriliyor ve doğrulanıyor...", original)))
+	tempPath, target, err := assembleSplitParts(dir, manifest)
+	bot.Request(tgbotapi.NewDeleteMessage(chatID, status.MessageID))
+	if err != nil {
+		log.Printf("Parçalar birleştirilemedi (%s): %v", original, err)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` birleştirilemedi: %v", original, err)))
+		return
+	}
+	os.RemoveAll(dir)
+
+	// Birleştirilen dosya, doğrudan gönderilen dosyalar gibi
+	// `INCOMING_COLLISION` politikasıyla kaydedilir.
+	stored, asked, err := storeIncomingFile(bot, chatID, message.From.ID, tempPath, target, sourceTelegram)
+	if err != nil {
+		log.Printf("Birleştirilen dosya kaydedilemedi (%s): %v", original, err)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` kaydedilemedi: %v", original, err)))
+		return
+	} else if asked {
+		return
+	}
+	savePath := stored.Path
+	if err := recordFileOrigin(fileKey(savePath), sourceTelegram, message.From.ID); err != nil {
+		log.Printf("Dosyanın kaynağı kaydedilemedi: %v", err)
+	}
+	log.Printf("Parçalar birleştirildi: %s (%d parça)", fileKey(savePath), len(manifest.Parts))
+
+	reply := tgbotapi.NewMessage(chatID, fmt.Sprintf(
+		"✅ *Dosya birleştirildi!*\n\n📄 *Ad:* `%s`\n📏 *Boyut:* %s\n🧩 *Parça:* %d (SHA-256 doğrulandı)\n%s📁 *Kategori:* %s\n\n%s",
+		filepath.Base(savePath), formatFileSize(manifest.Size), len(manifest.Parts), incomingCollisionText(stored), getFileCategory(savePath),
+		duplicateWarningText(message.From.ID, savePath)))
+	reply.ParseMode = "Markdown"
+	bot.Send(reply)
+}
+
+// writeFileFrom, akıştaki veriyi geçici bir dosyaya yazıp hedefin yerine koyar.
+func writeFileFrom(target string, body io.Reader) error {
+	tmp := target + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(file, body)
+	if closeErr := file.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		os.Remove(tmp)
+		return copyErr
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// readSplitManifest, bekleme klasöründeki manifesti okur ve doğrular.
+func readSplitManifest(dir, original string) (*splitManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, original+splitManifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var manifest splitManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("geçersiz parça listesi: %w", err)
+	}
+	if manifest.Version != splitManifestVer || manifest.Name != original || len(manifest.Parts) == 0 || len(manifest.Parts) > maxSplitParts {
+		return nil, fmt.Errorf("desteklenmeyen parça listesi")
+	}
+	sort.Slice(manifest.Parts, func(i, j int) bool { return manifest.Parts[i].Index < manifest.Parts[j].Index })
+	var total int64
+	for i, part := range manifest.Parts {
+		if part.Index != i+1 || part.Name != splitPartName(manifest.Name, part.Index) {
+			return nil, fmt.Errorf("parça listesinde %d. parça hatalı", i+1)
+		}
+		total += part.Size
+	}
+	if total != manifest.Size {
+		return nil, fmt.Errorf("parça boyutlarının toplamı dosya boyutuyla uyuşmuyor")
+	}
+	return &manifest, nil
+}
+
+// assembleSplitParts, parçaların özetlerini doğrulayarak birleştirir. Dosya,
+// ana klasördeki hedefinin yanında gizli bir geçici dosyaya (bkz.
+// `incoming_files.go`) yazılır; geçici dosyanın ve hedefin yolları döner.
+// Özeti tutmayan parçalar silinir; kullanıcının yalnızca onları yeniden
+// göndermesi yeterlidir.
+func assembleSplitParts(dir string, manifest *splitManifest) (tempPath, target string, err error) {
+	name, err := sanitizeFileName(manifest.Name)
+	if err != nil {
+		return "", "", err
+	}
+	target, err = resolveInBase(name)
+	if err != nil {
+		return "", "", err
+	}
+	out, err := os.CreateTemp(filepath.Dir(target), incomingTempPrefix+"*")
+	if err != nil {
+		return "", "", fmt.Errorf("geçici dosya oluşturulamadı: %w", err)
+	}
+	tmp := out.Name()
+	whole := sha256.New()
+	var corrupt []string
+	for _, part := range manifest.Parts {
+		partPath := filepath.Join(dir, part.Name)
+		sum, size, err := appendPart(out, whole, partPath)
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return "", "", err
+		}
+		if size != part.Size || sum != part.SHA256 {
+			os.Remove(partPath)
+			corrupt = append(corrupt, strconv.Itoa(part.Index))
+		}
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return "", "", err
+	}
+	if len(corrupt) > 0 {
+		os.Remove(tmp)
+		return "", "", fmt.Errorf("şu parçaların özeti tutmuyor, lütfen yeniden gönderin: %s", strings.Join(corrupt, ", "))
+	}
+	if hex.EncodeToString(whole.Sum(nil)) != manifest.SHA256 {
+		os.Remove(tmp)
+		return "", "", fmt.Errorf("birleştirilen dosyanın özeti parça listesiyle uyuşmuyor")
+	}
+	return tmp, target, nil
+}
+
+// appendPart, bir parçayı çıktıya ekler; parçanın özetini ve boyutunu döndürür.
+func appendPart(out io.Writer, whole hash.Hash, partPath string) (string, int64, error) {
+	in, err := os.Open(partPath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer in.Close()
+	partHash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, whole, partHash), in)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(partHash.Sum(nil)), size, nil
+}
+
+// purgeStaleSplitParts, uzun süredir tamamlanmayan parça klasörlerini siler.
+func purgeStaleSplitParts() {
+	splitPartsMutex.Lock()
+	defer splitPartsMutex.Unlock()
+	users, err := os.ReadDir(config.PartsDir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-staleSplitPartsAge)
+	for _, user := range users {
+		userDir := filepath.Join(config.PartsDir, user.Name())
+		files, _ := os.ReadDir(userDir)
+		for _, entry := range files {
+			info, err := entry.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			log.Printf("Tamamlanmayan parçalar silindi: %s", filepath.Join(userDir, entry.Name()))
+			os.RemoveAll(filepath.Join(userDir, entry.Name()))
+		}
+	}
+}
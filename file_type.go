@@ -0,0 +1,343 @@
+// file_type.go
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// #############################################################################
+// #                          İÇERİKTEN TÜR ALGILAMA
+// #############################################################################
+// Bu dosya, dosyaların türünü adlarına değil içeriklerine (ilk baytlardaki
+// imzalara) bakarak belirler. Telegram'dan gelen veya `/indir` ile indirilen
+// uzantısız dosyalara doğru uzantı verilir; böylece dosyalar `Diğer` yerine
+// doğru kategoriye taşınır. Uzantısı içeriğiyle uyuşmayan dosyalar kayıt
+// onayında bildirilir. Düzenleme kurallarındaki `mime` koşulu ve uzantısız
+// dosyaların kategorisi de buradan belirlenir.
+
+// fileTypeHeadSize, türü belirlemek için okunan bayt sayısıdır.
+const fileTypeHeadSize = 512
+
+// fileType, içerikten algılanan bir dosya türüdür. `Exts` bu tür için kabul
+// edilen uzantılardır; ilki uzantısız dosyalara verilir.
+type fileType struct {
+	Label string
+	MIME  string
+	Exts  []string
+	Text  bool // düz metin; uzantısı yalnızca ikili bir türe aitse uyuşmaz sayılır
+}
+
+// Ext, türün varsayılan uzantısını döndürür.
+func (t fileType) Ext() string {
+	if len(t.Exts) == 0 {
+		return ""
+	}
+	return t.Exts[0]
+}
+
+// fileSignature, belirli bir konumdaki sabit bayt imzasıdır. Kısa (zayıf)
+// imzalar metin dosyalarının başında da bulunabileceği için yalnızca içerik
+// metin değilse denenir.
+type fileSignature struct {
+	offset int
+	magic  string
+	typ    fileType
+	weak   bool
+}
+
+// fileSignatures, sabit imzalı türlerdir. Daha özel imzalar önce gelmelidir.
+var fileSignatures = []fileSignature{
+	{0, "%PDF-", fileType{"PDF belgesi", "application/pdf", []string{".pdf"}, false}, false},
+	{0, "\x89PNG\r\n\x1a\n", fileType{"PNG resmi", "image/png", []string{".png"}, false}, false},
+	{0, "\xff\xd8\xff", fileType{"JPEG resmi", "image/jpeg", []string{".jpg", ".jpeg", ".jpe", ".jfif"}, false}, false},
+	{0, "GIF87a", fileType{"GIF resmi", "image/gif", []string{".gif"}, false}, false},
+	{0, "GIF89a", fileType{"GIF resmi", "image/gif", []string{".gif"}, false}, false},
+	{0, "II*\x00", fileType{"TIFF resmi", "image/tiff", []string{".tiff", ".tif"}, false}, false},
+	{0, "MM\x00*", fileType{"TIFF resmi", "image/tiff", []string{".tiff", ".tif"}, false}, false},
+	{0, "BM", fileType{"BMP resmi", "image/bmp", []string{".bmp"}, false}, true},
+	{0, "\x00\x00\x01\x00", fileType{"Simge", "image/x-icon", []string{".ico"}, false}, true},
+	{0, "ID3", fileType{"MP3 sesi", "audio/mpeg", []string{".mp3"}, false}, false},
+	{0, "fLaC", fileType{"FLAC sesi", "audio/flac", []string{".flac"}, false}, false},
+	{0, "Rar!\x1a\x07", fileType{"RAR arşivi", "application/vnd.rar", []string{".rar"}, false}, false},
+	{0, "7z\xbc\xaf\x27\x1c", fileType{"7-Zip arşivi", "application/x-7z-compressed", []string{".7z"}, false}, false},
+	{0, "\x1f\x8b", fileType{"GZIP arşivi", "application/gzip", []string{".gz", ".tgz"}, false}, false},
+	{0, "BZh", fileType{"BZIP2 arşivi", "application/x-bzip2", []string{".bz2"}, false}, true},
+	{0, "\xfd7zXZ\x00", fileType{"XZ arşivi", "application/x-xz", []string{".xz", ".txz"}, false}, false},
+	{257, "ustar", fileType{"TAR arşivi", "application/x-tar", []string{".tar"}, false}, false},
+	{0, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", fileType{"Office 97-2003 belgesi", "application/x-ole-storage", []string{".doc", ".xls", ".ppt", ".msg"}, false}, false},
+	{0, "{\\rtf", fileType{"RTF belgesi", "application/rtf", []string{".rtf"}, false}, false},
+	{0, "MZ", fileType{"Windows programı", "application/vnd.microsoft.portable-executable", []string{".exe", ".dll", ".sys", ".msi"}, false}, true},
+	{0, "SQLite format 3\x00", fileType{"SQLite veritabanı", "application/vnd.sqlite3", []string{".sqlite", ".db", ".sqlite3"}, false}, false},
+}
+
+// Konteyner biçimlerinin alt türleri.
+var (
+	typeWebP      = fileType{"WebP resmi", "image/webp", []string{".webp"}, false}
+	typeWAV       = fileType{"WAV sesi", "audio/wav", []string{".wav"}, false}
+	typeAVI       = fileType{"AVI videosu", "video/x-msvideo", []string{".avi"}, false}
+	typeMP4       = fileType{"MP4 videosu", "video/mp4", []string{".mp4", ".m4v", ".m4a", ".3gp", ".mov"}, false}
+	typeM4A       = fileType{"M4A sesi", "audio/mp4", []string{".m4a", ".mp4"}, false}
+	typeMOV       = fileType{"QuickTime videosu", "video/quicktime", []string{".mov", ".mp4"}, false}
+	typeHEIC      = fileType{"HEIC resmi", "image/heic", []string{".heic", ".heif"}, false}
+	typeMKV       = fileType{"Matroska videosu", "video/x-matroska", []string{".mkv", ".mka", ".webm"}, false}
+	typeWebM      = fileType{"WebM videosu", "video/webm", []string{".webm", ".mkv"}, false}
+	typeOgg       = fileType{"Ogg sesi", "audio/ogg", []string{".ogg", ".oga", ".ogv", ".opus"}, false}
+	typeOpus      = fileType{"Opus sesi", "audio/opus", []string{".opus", ".ogg"}, false}
+	typeMP3Frame  = fileType{"MP3 sesi", "audio/mpeg", []string{".mp3"}, false}
+	typeZIP       = fileType{"ZIP arşivi", "application/zip", []string{".zip", ".cbz"}, false}
+	typeDOCX      = fileType{"Word belgesi", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", []string{".docx", ".docm"}, false}
+	typeXLSX      = fileType{"Excel tablosu", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", []string{".xlsx", ".xlsm"}, false}
+	typePPTX      = fileType{"PowerPoint sunusu", "application/vnd.openxmlformats-officedocument.presentationml.presentation", []string{".pptx", ".pptm"}, false}
+	typeEPUB      = fileType{"EPUB kitabı", "application/epub+zip", []string{".epub"}, false}
+	typeAPK       = fileType{"Android paketi", "application/vnd.android.package-archive", []string{".apk"}, false}
+	typeJAR       = fileType{"Java arşivi", "application/java-archive", []string{".jar"}, false}
+	typeHTML      = fileType{"HTML sayfası", "text/html", []string{".html", ".htm"}, true}
+	typeSVG       = fileType{"SVG resmi", "image/svg+xml", []string{".svg"}, true}
+	typePlainText = fileType{"Metin", "text/plain", []string{".txt"}, true}
+)
+
+// odfTypes, OpenDocument dosyalarının `mimetype` girdisine göre türleridir.
+var odfTypes = map[string]fileType{
+	"application/vnd.oasis.opendocument.text":         {"OpenDocument metni", "application/vnd.oasis.opendocument.text", []string{".odt"}, false},
+	"application/vnd.oasis.opendocument.spreadsheet":  {"OpenDocument tablosu", "application/vnd.oasis.opendocument.spreadsheet", []string{".ods"}, false},
+	"application/vnd.oasis.opendocument.presentation": {"OpenDocument sunusu", "application/vnd.oasis.opendocument.presentation", []string{".odp"}, false},
+}
+
+// detectFileType, dosyanın içeriğinden türünü belirler. Tanınmayan ikili
+// dosyalar için false döner.
+func detectFileType(path string) (fileType, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fileType{}, false
+	}
+	head := make([]byte, fileTypeHeadSize)
+	n, _ := io.ReadFull(file, head)
+	file.Close()
+	head = head[:n]
+	if n == 0 {
+		return fileType{}, false
+	}
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return detectZipType(path), true
+	}
+	if t, ok := detectContainerType(head); ok {
+		return t, true
+	}
+	if t, ok := matchSignatures(head, false); ok {
+		return t, true
+	}
+	if t, ok := detectTextType(head); ok {
+		return t, true
+	}
+	if t, ok := matchSignatures(head, true); ok {
+		return t, true
+	}
+	// MP3 dosyaları ID3 etiketi olmadan doğrudan bir çerçeveyle başlayabilir.
+	if len(head) > 2 && head[0] == 0xff && (head[1]&0xe6) == 0xe2 {
+		return typeMP3Frame, true
+	}
+	return fileType{}, false
+}
+
+// matchSignatures, `weak` değerine göre güçlü veya zayıf imzaları dener.
+func matchSignatures(head []byte, weak bool) (fileType, bool) {
+	for _, sig := range fileSignatures {
+		if sig.weak != weak {
+			continue
+		}
+		end := sig.offset + len(sig.magic)
+		if end <= len(head) && string(head[sig.offset:end]) == sig.magic {
+			return sig.typ, true
+		}
+	}
+	return fileType{}, false
+}
+
+// detectContainerType, RIFF, ISO BMFF (`ftyp`), Matroska ve Ogg gibi
+// konteyner biçimlerini ve içerdikleri türü ayırt eder.
+func detectContainerType(head []byte) (fileType, bool) {
+	switch {
+	case len(head) >= 12 && string(head[:4]) == "RIFF":
+		switch string(head[8:12]) {
+		case "WEBP":
+			return typeWebP, true
+		case "WAVE":
+			return typeWAV, true
+		case "AVI ":
+			return typeAVI, true
+		}
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		switch brand := string(head[8:12]); {
+		case brand == "M4A " || brand == "M4B ":
+			return typeM4A, true
+		case brand == "qt  ":
+			return typeMOV, true
+		case brand == "heic" || brand == "heix" || brand == "mif1" || brand == "msf1":
+			return typeHEIC, true
+		default:
+			return typeMP4, true
+		}
+	case bytes.HasPrefix(head, []byte("\x1a\x45\xdf\xa3")):
+		if bytes.Contains(head, []byte("webm")) {
+			return typeWebM, true
+		}
+		return typeMKV, true
+	case bytes.HasPrefix(head, []byte("OggS")):
+		if bytes.Contains(head, []byte("OpusHead")) {
+			return typeOpus, true
+		}
+		return typeOgg, true
+	}
+	return fileType{}, false
+}
+
+// detectZipType, ZIP arşivinin içindeki girdilere bakarak Office, OpenDocument,
+// EPUB ve benzeri ZIP tabanlı biçimleri ayırt eder.
+func detectZipType(path string) fileType {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return typeZIP
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		switch {
+		case f.Name == "mimetype":
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+			data, _ := io.ReadAll(io.LimitReader(rc, 128))
+			rc.Close()
+			mimeType := strings.TrimSpace(string(data))
+			if t, ok := odfTypes[mimeType]; ok {
+				return t
+			}
+			if mimeType == typeEPUB.MIME {
+				return typeEPUB
+			}
+		case strings.HasPrefix(f.Name, "word/"):
+			return typeDOCX
+		case strings.HasPrefix(f.Name, "xl/"):
+			return typeXLSX
+		case strings.HasPrefix(f.Name, "ppt/"):
+			return typePPTX
+		case f.Name == "AndroidManifest.xml":
+			return typeAPK
+		case f.Name == "META-INF/MANIFEST.MF":
+			return typeJAR
+		}
+	}
+	return typeZIP
+}
+
+// detectTextType, NUL baytı içermeyen geçerli UTF-8 içeriği metin kabul eder.
+// HTML ve SVG ayrıca tanınır.
+func detectTextType(head []byte) (fileType, bool) {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return fileType{}, false
+	}
+	sample := bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	// * Okunan bölüm çok baytlı bir karakterin ortasında bitebilir.
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	if !utf8.Valid(sample) {
+		return fileType{}, false
+	}
+	lower := strings.ToLower(strings.TrimSpace(string(sample)))
+	switch {
+	case strings.HasPrefix(lower, "<!doctype html") || strings.HasPrefix(lower, "<html"):
+		return typeHTML, true
+	case strings.HasPrefix(lower, "<svg") || (strings.HasPrefix(lower, "<?xml") && strings.Contains(lower, "<svg")):
+		return typeSVG, true
+	}
+	return typePlainText, true
+}
+
+// isBinaryTypeExt, uzantının ikili türlerden birine ait olup olmadığını belirler.
+func isBinaryTypeExt(ext string) bool {
+	for _, sig := range fileSignatures {
+		if !sig.typ.Text && containsString(sig.typ.Exts, ext) {
+			return true
+		}
+	}
+	for _, t := range []fileType{typeWebP, typeWAV, typeAVI, typeMP4, typeM4A, typeMOV, typeHEIC, typeMKV, typeWebM,
+		typeOgg, typeOpus, typeZIP, typeDOCX, typeXLSX, typePPTX, typeEPUB, typeAPK, typeJAR} {
+		if containsString(t.Exts, ext) {
+			return true
+		}
+	}
+	for _, t := range odfTypes {
+		if containsString(t.Exts, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionMismatch, dosya adının uzantısı algılanan türle uyuşmuyorsa true
+// döner. Uzantısız dosyalar uyuşmazlık sayılmaz; onlara uzantı eklenir.
+// Metin dosyaları yalnızca ikili bir türün uzantısını taşıyorsa uyuşmaz.
+func extensionMismatch(t fileType, name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" || containsString(t.Exts, ext) {
+		return false
+	}
+	if t.Text {
+		return isBinaryTypeExt(ext)
+	}
+	return true
+}
+
+// typedFileResult, `detectTypeForName` sonucudur.
+type typedFileResult struct {
+	Path     string   // dosyanın son yolu (uzantı eklendiyse yeni yol)
+	Type     fileType // algılanan tür
+	Known    bool     // tür algılanabildiyse true
+	Renamed  bool     // uzantısız dosyaya uzantı eklendiyse true
+	Mismatch bool     // uzantı içerikle uyuşmuyorsa true
+}
+
+// detectTypeForName, `contentPath` içeriğinin türünü belirler ve dosyanın
+// `targetPath` adıyla kaydedilmesi durumunda alacağı yolu hesaplar:
+// uzantısız bir ada algılanan türün uzantısı eklenir; uzantısı uyuşmayan
+// dosyalar yalnızca işaretlenir. Dosyaya dokunmaz; geçici dosyaya yazılan
+// yüklemeler için kullanılır.
+func detectTypeForName(contentPath, targetPath string) typedFileResult {
+	result := typedFileResult{Path: targetPath}
+	result.Type, result.Known = detectFileType(contentPath)
+	if !result.Known {
+		return result
+	}
+	if filepath.Ext(targetPath) == "" && result.Type.Ext() != "" {
+		result.Path, result.Renamed = targetPath+result.Type.Ext(), true
+		return result
+	}
+	result.Mismatch = extensionMismatch(result.Type, targetPath)
+	return result
+}
+
+// detectedTypeText, kayıt onay mesajlarına eklenecek tür satırlarını üretir
+// (Markdown). Tür bilinmiyorsa boş döner.
+func detectedTypeText(result typedFileResult) string {
+	if !result.Known {
+		return ""
+	}
+	text := fmt.Sprintf("🧬 *Tür:* %s (`%s`)\n", result.Type.Label, result.Type.MIME)
+	if result.Renamed {
+		text += fmt.Sprintf("🔤 Uzantı içerikten belirlendi: `%s`\n", filepath.Ext(result.Path))
+	}
+	if result.Mismatch {
+		text += fmt.Sprintf("⚠️ Uzantı içerikle uyuşmuyor; dosya `%s` uzantılı olmalı.\n", result.Type.Ext())
+	}
+	return text
+}
@@ -0,0 +1,418 @@
+// file_versions.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                              DOSYA SÜRÜMLERİ
+// #############################################################################
+// Bu dosya, üzerine yazılan dosyaların eski hallerini saklar. Sürümler ana
+// klasörün dışındaki `VERSIONS_DIR` klasöründe (varsayılan `Surumler`),
+// dosyanın göreli yolunu taşıyan bir klasörde tutulur; böylece aramalarda ve
+// listelerde görünmezler:
+//
+//	Surumler/Dokümanlar/rapor.pdf/20241018-153000.pdf
+//
+// Sürüm klasörü dosyaya bağlıdır: dosya taşındığında veya yeniden
+// adlandırıldığında sürümleri de onunla birlikte taşınır. Dosya başına
+// `VERSIONS_KEEP` sürümden fazlası ve `VERSIONS_RETENTION_DAYS` günden eski
+// sürümler silinir.
+
+// versionTimeLayout, sürüm dosyalarının adındaki zaman damgasının biçimidir.
+const versionTimeLayout = "20060102-150405"
+
+// versionsShown, /surumler listesinde gösterilecek en fazla sürüm sayısıdır.
+const versionsShown = 20
+
+// versionButtons, /surumler listesinde düğmesi gösterilecek sürüm sayısıdır.
+const versionButtons = 10
+
+// versionEntry, bir dosyanın saklanan tek sürümüdür.
+type versionEntry struct {
+	Path  string
+	Saved time.Time // dosyanın sürüm olarak saklandığı an
+	Size  int64
+}
+
+// versionDirOf, bir dosyanın sürümlerinin saklandığı klasörü döndürür.
+func versionDirOf(key string) string {
+	return filepath.Join(config.VersionsDir, filepath.FromSlash(key))
+}
+
+// versionKeyOf, bir sürüm dosyasının sürüm klasörüne göre `/` ayraçlı
+// yolunu döndürür. İşlem günlüğünde bu yol saklanır.
+func versionKeyOf(path string) string {
+	rel, err := filepath.Rel(config.VersionsDir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// splitVersionName, sürüm adını zaman damgasına ve sayaca ayırır. Aynı
+// saniyede saklanan sürümlerin adına `_1`, `_2` gibi bir sayaç eklenir.
+func splitVersionName(name string) (string, int) {
+	stamp, counter, _ := strings.Cut(strings.TrimSuffix(name, filepath.Ext(name)), "_")
+	n, _ := strconv.Atoi(counter)
+	return stamp, n
+}
+
+// versionSavedAt, sürümün saklandığı anı adındaki zaman damgasından okur.
+// Ad çözülemezse dosyanın değiştirilme zamanı kullanılır.
+func versionSavedAt(name string, info os.FileInfo) time.Time {
+	stamp, _ := splitVersionName(name)
+	if saved, err := time.ParseInLocation(versionTimeLayout, stamp, time.Local); err == nil {
+		return saved
+	}
+	return info.ModTime()
+}
+
+// nextVersionPath, yeni bir sürüm için yol üretir. Aynı saniyede saklanmış
+// sürümler varsa en büyük sayacın bir fazlası seçilir; silinen bir sürümün
+// boşalan adı yeniden kullanılsaydı yeni sürüm en eskisi gibi sıralanırdı.
+func nextVersionPath(dir, stamp, ext string) string {
+	items, _ := os.ReadDir(dir)
+	next := -1
+	for _, item := range items {
+		if itemStamp, counter := splitVersionName(item.Name()); itemStamp == stamp && counter >= next {
+			next = counter + 1
+		}
+	}
+	if next <= 0 {
+		return uniqueTargetPath(filepath.Join(dir, stamp+ext))
+	}
+	return uniqueTargetPath(filepath.Join(dir, fmt.Sprintf("%s_%d%s", stamp, next, ext)))
+}
+
+// listVersions, bir dosyanın sürümlerini en yenisi başta olacak şekilde
+// döndürür.
+func listVersions(key string) []versionEntry {
+	dir := versionDirOf(key)
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var versions []versionEntry
+	for _, item := range items {
+		info, err := item.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		versions = append(versions, versionEntry{
+			Path:  filepath.Join(dir, item.Name()),
+			Saved: versionSavedAt(item.Name(), info),
+			Size:  info.Size(),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].Saved.Equal(versions[j].Saved) {
+			return versions[i].Saved.After(versions[j].Saved)
+		}
+		_, ci := splitVersionName(filepath.Base(versions[i].Path))
+		_, cj := splitVersionName(filepath.Base(versions[j].Path))
+		return ci > cj
+	})
+	return versions
+}
+
+// archiveVersion, ana klasördeki bir dosyayı sürüm olarak saklar ve
+// sürümün yolunu döndürür. Dosya yerinden kaldırılır; çağıran, yerine yeni
+// içeriği koymalıdır. Saklama sınırını aşan eski sürümler silinir.
+func archiveVersion(path string) (string, error) {
+	key := fileKey(path)
+	dir := versionDirOf(key)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	target := nextVersionPath(dir, time.Now().Format(versionTimeLayout), filepath.Ext(path))
+	if err := moveFile(path, target); err != nil {
+		return "", fmt.Errorf("sürüm saklanamadı: %w", err)
+	}
+	log.Printf("Sürüm saklandı: %s -> %s", key, target)
+	keep, maxAge := versionRetention()
+	pruneVersions(key, keep, maxAge)
+	return target, nil
+}
+
+// restoreVersion, sürüm klasörüne göre yolu verilen sürümü ana klasördeki
+// yerine geri koyar. Hedefte bir dosya varsa üzerine yazılmaz.
+func restoreVersion(versionKey, target string) error {
+	rel := filepath.FromSlash(versionKey)
+	if !filepath.IsLocal(rel) {
+		return errOutsideBaseDir
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("`%s` yolunda zaten bir dosya var", fileKey(target))
+	}
+	source := filepath.Join(config.VersionsDir, rel)
+	if err := moveFile(source, target); err != nil {
+		return err
+	}
+	removeEmptyVersionDirs(filepath.Dir(source))
+	return nil
+}
+
+// moveFileVersions, bir dosyanın sürümlerini yeni anahtarına taşır. Yeni
+// anahtarın zaten sürümleri varsa ikisi birleştirilir. Sürüm yoksa hiçbir
+// şey yapmaz.
+func moveFileVersions(oldKey, newKey string) error {
+	if oldKey == newKey {
+		return nil
+	}
+	versions := listVersions(oldKey)
+	if len(versions) == 0 {
+		return nil
+	}
+	newDir := versionDirOf(newKey)
+	if err := os.MkdirAll(newDir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, version := range versions {
+		target := uniqueTargetPath(filepath.Join(newDir, filepath.Base(version.Path)))
+		if err := moveFile(version.Path, target); err != nil {
+			return err
+		}
+	}
+	removeEmptyVersionDirs(versionDirOf(oldKey))
+	keep, maxAge := versionRetention()
+	pruneVersions(newKey, keep, maxAge)
+	return nil
+}
+
+// pruneVersions, bir dosyanın `keep` sayısını aşan ve `maxAge` süresinden
+// eski sürümlerini kalıcı olarak siler. Sıfır değerler sınır yok demektir.
+// Silinen sürüm sayısını döndürür.
+func pruneVersions(key string, keep int, maxAge time.Duration) int {
+	removed := 0
+	for i, version := range listVersions(key) {
+		if (keep <= 0 || i < keep) && (maxAge <= 0 || time.Since(version.Saved) <= maxAge) {
+			continue
+		}
+		if err := os.Remove(version.Path); err != nil {
+			log.Printf("Eski sürüm silinemedi: %s - %v", version.Path, err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		log.Printf("%s için %d eski sürüm silindi.", key, removed)
+		removeEmptyVersionDirs(versionDirOf(key))
+	}
+	return removed
+}
+
+// removeEmptyVersionDirs, boş kalan sürüm klasörlerini sürüm kök klasörüne
+// kadar yukarı doğru siler.
+func removeEmptyVersionDirs(dir string) {
+	root := filepath.Clean(config.VersionsDir)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		// * `os.Remove` dolu klasörleri silmez.
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// purgeExpiredVersions, tüm dosyalar için saklama sınırını uygular. Sınır
+// yapılandırmada düşürüldüğünde eski sürümler de böylece temizlenir. Saatlik
+// zamanlayıcı çağırır.
+func purgeExpiredVersions() {
+	keep, maxAge := versionRetention()
+	if keep <= 0 && maxAge <= 0 {
+		return
+	}
+	keys := make(map[string]bool)
+	filepath.Walk(config.VersionsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		keys[versionKeyOf(filepath.Dir(path))] = true
+		return nil
+	})
+	for key := range keys {
+		pruneVersions(key, keep, maxAge)
+	}
+}
+
+// #############################################################################
+// #                             SÜRÜM KOMUTLARI
+// #############################################################################
+
+// versionDownloadName, sürüm gönderilirken kullanılacak dosya adını üretir:
+// `rapor.pdf` dosyasının bir sürümü `rapor_20241018-153000.pdf` olarak gelir.
+func versionDownloadName(key string, version versionEntry) string {
+	name := filepath.Base(filepath.FromSlash(key))
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), version.Saved.Format(versionTimeLayout), ext)
+}
+
+// sendVersionFile, bir sürümü asıl dosyanın adı ve sürümün tarihiyle
+// birlikte gönderir.
+func sendVersionFile(bot Messenger, chatID int64, key string, number int, version versionEntry) {
+	if version.Size > uploadLimit() {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` dosyasının %d numaralı sürümü çok büyük (%s). Telegram ile en fazla %s gönderilebilir.",
+			key, number, formatFileSize(version.Size), formatFileSize(uploadLimit()))))
+		return
+	}
+	file, err := os.Open(version.Path)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Sürüm okunamadı: `%s`", key)))
+		return
+	}
+	defer file.Close()
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileReader{Name: versionDownloadName(key, version), Reader: file})
+	doc.Caption = fmt.Sprintf("🗂️ *%s* — %d numaralı sürüm\n🕒 Saklandığı tarih: %s",
+		filepath.Base(filepath.FromSlash(key)), number, version.Saved.Format("02.01.2006 15:04"))
+	doc.ParseMode = "Markdown"
+	if _, err := bot.Send(doc); err != nil {
+		log.Printf("Sürüm gönderilemedi: %v", err)
+	}
+}
+
+// handleVersionsCommand, /surumler komutuyla bir dosyanın saklanan
+// sürümlerini tarih ve boyutlarıyla listeler. Düğmelere basılarak sürümler
+// getirilebilir.
+func handleVersionsCommand(bot Messenger, message *tgbotapi.Message) {
+	args := strings.TrimSpace(message.CommandArguments())
+	chatID := message.Chat.ID
+	if args == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/surumler <dosya_adı>`"))
+		return
+	}
+	filePath, ok := resolveFileForCommand(bot, message, args, "", fmt.Sprintf("❌ `%s` dosyası bulunamadı!", args))
+	if !ok {
+		return
+	}
+	key := fileKey(filePath)
+	versions := listVersions(key)
+	if len(versions) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ `%s` için saklanan bir sürüm yok.", key)))
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🗂️ *Sürümler:* `%s`\n", key))
+	if info, err := os.Stat(filePath); err == nil {
+		builder.WriteString(fmt.Sprintf("📄 Güncel hali: %s — %s\n", info.ModTime().Format("02.01.2006 15:04"), formatFileSize(info.Size())))
+	}
+	builder.WriteString("\n")
+	names := make([]string, 0, len(versions))
+	for i, version := range versions {
+		names = append(names, filepath.Base(version.Path))
+		if i < versionsShown {
+			builder.WriteString(fmt.Sprintf("*%d.* %s — %s\n", i+1, version.Saved.Format("02.01.2006 15:04"), formatFileSize(version.Size)))
+		}
+	}
+	if len(versions) > versionsShown {
+		builder.WriteString(fmt.Sprintf("... ve %d sürüm daha\n", len(versions)-versionsShown))
+	}
+	if keep, _ := versionRetention(); keep > 0 {
+		builder.WriteString(fmt.Sprintf("\nDosya başına en fazla %d sürüm saklanır.", keep))
+	}
+	builder.WriteString(fmt.Sprintf("\n💡 `/surum_getir %s 2` ile bir sürümü getirebilirsiniz.", key))
+
+	msg := tgbotapi.NewMessage(chatID, builder.String())
+	msg.ParseMode = "Markdown"
+	// Sürüm adları sunucuda saklanır; liste açıkken yeni bir sürüm eklense
+	// bile düğmeler aynı sürümü getirir.
+	id := newCallbackPayload(message.From.ID, key+"|"+strings.Join(names, "|"))
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i := 0; i < len(versions) && i < versionButtons; i++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("📥 %d", i+1), callbackData("surum", id, strconv.Itoa(i+1))))
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	bot.Send(msg)
+}
+
+// handleVersionGetCommand, /surum_getir komutuyla bir dosyanın verilen
+// numaralı sürümünü gönderir. Numara verilmezse en yeni sürüm gönderilir.
+func handleVersionGetCommand(bot Messenger, message *tgbotapi.Message) {
+	args := strings.Fields(message.CommandArguments())
+	chatID := message.Chat.ID
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/surum_getir <dosya_adı> [no]`\nSürüm numaraları `/surumler` ile görülebilir."))
+		return
+	}
+	number, rest := 1, ""
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Sürüm numarası pozitif bir sayı olmalıdır."))
+			return
+		}
+		number, rest = n, " "+args[1]
+	}
+	filePath, ok := resolveFileForCommand(bot, message, args[0], rest, fmt.Sprintf("❌ `%s` dosyası bulunamadı!", args[0]))
+	if !ok {
+		return
+	}
+	key := fileKey(filePath)
+	versions := listVersions(key)
+	if len(versions) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ `%s` için saklanan bir sürüm yok.", key)))
+		return
+	}
+	if number > len(versions) {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` için %d numaralı sürüm yok (toplam %d sürüm).", key, number, len(versions))))
+		return
+	}
+	sendVersionFile(bot, chatID, key, number, versions[number-1])
+}
+
+// handleVersionCallback, /surumler listesindeki düğmelere basıldığında ilgili
+// sürümü gönderir. Düğmeler liste süresi dolana kadar tekrar kullanılabilir.
+func handleVersionCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	userID := callbackQuery.From.ID
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 {
+		return
+	}
+	value, err := peekCallbackPayload(parts[1], userID)
+	if errors.Is(err, errPayloadForeign) {
+		return
+	} else if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "⌛ Bu listenin süresi dolmuş. Lütfen `/surumler` komutunu yeniden çalıştırın."))
+		return
+	}
+	fields := strings.Split(value, "|")
+	number, err := strconv.Atoi(parts[2])
+	if err != nil || number < 1 || number >= len(fields) {
+		return
+	}
+	key, name := fields[0], fields[number]
+
+	// * Liste açıldıktan sonra kullanıcının kategori yetkisi değişmiş olabilir.
+	if path, ok := pathOfKey(key); !ok || !canAccessPath(userID, path) {
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return
+	}
+	for _, version := range listVersions(key) {
+		if filepath.Base(version.Path) == name {
+			sendVersionFile(bot, chatID, key, number, version)
+			return
+		}
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Bu sürüm artık bulunamıyor; dosya taşınmış veya sürüm silinmiş olabilir. `/surumler %s` ile listeyi yenileyin.", key)))
+}
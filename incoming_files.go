@@ -0,0 +1,310 @@
+// incoming_files.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                          GELEN DOSYALARIN KAYDI
+// #############################################################################
+// Bu dosya, bota gönderilen ve `/indir` ile indirilen dosyaların ana klasöre
+// güvenle yazılmasını sağlar. İçerik önce hedef klasörde gizli bir geçici
+// dosyaya (`.yukleniyor-…`) yazılır ve beklenen boyutla karşılaştırılır;
+// yarım kalan bir yükleme hiçbir zaman asıl adıyla görünmez. Hedefte aynı
+// adda bir dosya varsa `INCOMING_COLLISION` politikası uygulanır:
+//
+//	rename    – yeni dosya `_1`, `_2` ekiyle kaydedilir (varsayılan)
+//	overwrite – mevcut dosya çöp kutusuna taşınır, yenisi yerine geçer (`/sil` yetkisi gerekir)
+//	ask       – ne yapılacağı kullanıcıya düğmelerle sorulur
+//	version   – mevcut dosya sürüm olarak saklanır, yenisi yerine geçer
+
+const (
+	collisionRename    = "rename"
+	collisionOverwrite = "overwrite"
+	collisionAsk       = "ask"
+	collisionVersion   = "version"
+)
+
+// incomingTempPrefix, yazılmakta olan geçici dosyaların adının önekidir.
+const incomingTempPrefix = ".yukleniyor-"
+
+// staleIncomingAge, yanıtlanmamış sorulardan kalan geçici dosyaların
+// silinmeden önce bekletildiği süredir.
+const staleIncomingAge = 24 * time.Hour
+
+// incomingResult, gelen bir dosyanın kaydedilme sonucudur.
+type incomingResult struct {
+	Path     string // dosyanın kaydedildiği yol
+	Renamed  bool   // aynı adda dosya olduğu için yeni adla kaydedildiyse true
+	Replaced bool   // mevcut dosyanın yerine kaydedildiyse true
+	TrashID  int    // yerine kaydedilen dosyanın çöp kutusundaki kimliği
+	Version  string // mevcut dosya sürüm olarak saklandıysa sürümün yolu
+}
+
+// isIncomingTemp, adın yazılmakta olan bir geçici dosyaya ait olup
+// olmadığını belirler. Bu dosyalar listelenmez ve indekslenmez.
+func isIncomingTemp(name string) bool {
+	return strings.HasPrefix(name, incomingTempPrefix)
+}
+
+// validCollisionPolicy, yapılandırmadaki politika adının geçerli olup
+// olmadığını belirler.
+func validCollisionPolicy(policy string) bool {
+	switch policy {
+	case collisionRename, collisionOverwrite, collisionAsk, collisionVersion:
+		return true
+	}
+	return false
+}
+
+// receiveToTemp, içeriği `dir` klasöründe gizli bir geçici dosyaya yazar.
+// `expected` sıfırdan büyükse yazılan boyut onunla karşılaştırılır. Hata
+// durumunda geçici dosya silinir.
+func receiveToTemp(dir string, body io.Reader, expected int64) (string, error) {
+	tmp, err := os.CreateTemp(dir, incomingTempPrefix+"*")
+	if err != nil {
+		return "", fmt.Errorf("geçici dosya oluşturulamadı: %w", err)
+	}
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && expected > 0 && written != expected {
+		err = fmt.Errorf("yükleme yarıda kesildi: %s / %s alındı", formatFileSize(written), formatFileSize(expected))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// commitIncoming, geçici dosyayı verilen politikaya göre hedefe taşır.
+// Hedefte dosya yoksa politika önemsizdir. Mevcut dosyanın yerine geçilirse
+// eski dosya çöp kutusuna (`overwrite`) veya sürümlere (`version`) taşınır
+// ve değişiklik işlem günlüğüne yazılır; böylece `/geri_al_son` ile geri
+// alınabilir. Başarısız olursa geçici dosya silinir ve eski dosya yerine
+// konur.
+func commitIncoming(userID int64, tempPath, target, policy string) (incomingResult, error) {
+	result := incomingResult{Path: target}
+	if _, err := os.Lstat(target); err == nil {
+		switch policy {
+		case collisionOverwrite:
+			entry, err := moveToTrash(userID, target)
+			if err != nil {
+				os.Remove(tempPath)
+				return result, fmt.Errorf("mevcut dosya çöp kutusuna taşınamadı: %w", err)
+			}
+			result.Replaced, result.TrashID = true, entry.ID
+		case collisionVersion:
+			version, err := archiveVersion(target)
+			if err != nil {
+				os.Remove(tempPath)
+				return result, err
+			}
+			result.Version = version
+		default:
+			result.Path, result.Renamed = uniqueTargetPath(target), true
+		}
+	}
+	if err := os.Rename(tempPath, result.Path); err != nil {
+		os.Remove(tempPath)
+		restoreReplaced(userID, result, target)
+		return result, fmt.Errorf("dosya kaydedilemedi: %w", err)
+	}
+
+	step := OperationStep{From: fileKey(result.Path), Replaced: true}
+	switch {
+	case result.Replaced:
+		step.TrashID = result.TrashID
+	case result.Version != "":
+		step.Version = versionKeyOf(result.Version)
+	default:
+		return result, nil
+	}
+	recordOperation(userID, operationReplace, step)
+	return result, nil
+}
+
+// restoreReplaced, kaydedilemeyen gelen dosya için kenara alınan eski dosyayı
+// yerine geri koyar.
+func restoreReplaced(userID int64, result incomingResult, target string) {
+	var err error
+	switch {
+	case result.Replaced:
+		_, err = restoreFromTrash(userID, result.TrashID)
+	case result.Version != "":
+		err = restoreVersion(versionKeyOf(result.Version), target)
+	}
+	if err != nil {
+		log.Printf("Eski dosya yerine geri konamadı (%s): %v", fileKey(target), err)
+	}
+}
+
+// collisionPolicyFor, kullanıcı için uygulanacak politikayı belirler. Mevcut
+// dosyanın kategorisine erişimi olmayan veya dosya silme yetkisi olmayan
+// kullanıcılar başkasının dosyasının yerine geçemez; dosyaları yeni adla
+// kaydedilir.
+func collisionPolicyFor(userID int64, target, policy string) string {
+	if !canAccessPath(userID, target) {
+		return collisionRename
+	}
+	if policy == collisionOverwrite {
+		if spec, ok := lookupCommand("sil"); !ok || !canRunCommand(userID, spec) {
+			return collisionRename
+		}
+	}
+	return policy
+}
+
+// storeIncomingFile, gelen dosyayı politikaya göre kaydeder. Hedef doluysa ve
+// politika `ask` ise kullanıcıya sorulur ve `asked` true döner; bu durumda
+// kayıt, düğmeye basıldığında `handleIncomingCallback` ile tamamlanır.
+func storeIncomingFile(bot Messenger, chatID, userID int64, tempPath, target, source string) (result incomingResult, asked bool, err error) {
+	policy := collisionPolicyFor(userID, target, config.IncomingCollision)
+	if _, statErr := os.Lstat(target); statErr == nil && policy == collisionAsk {
+		askIncomingCollision(bot, chatID, userID, tempPath, target, source)
+		return incomingResult{}, true, nil
+	}
+	result, err = commitIncoming(userID, tempPath, target, policy)
+	return result, false, err
+}
+
+// incomingCollisionText, kayıt mesajına eklenecek çakışma satırını üretir
+// (Markdown). Çakışma olmadıysa boş döner.
+func incomingCollisionText(result incomingResult) string {
+	switch {
+	case result.Replaced:
+		return fmt.Sprintf("♻️ Aynı adlı dosyanın yerine kaydedildi; eskisi çöp kutusunda (`/geri_al %d`).\n", result.TrashID)
+	case result.Version != "":
+		return "🗂️ Önceki hali sürüm olarak saklandı.\n"
+	case result.Renamed:
+		return "🆕 Aynı adda bir dosya olduğu için yeni adla kaydedildi.\n"
+	}
+	return ""
+}
+
+// #############################################################################
+// #                            KULLANICIYA SORMA
+// #############################################################################
+
+// askIncomingCollision, aynı adda dosya olduğunda ne yapılacağını düğmelerle
+// sorar. Geçici dosyanın ve hedefin yolları sunucuda saklanır; düğmeleri
+// yalnızca dosyayı gönderen kullanıcı kullanabilir.
+func askIncomingCollision(bot Messenger, chatID, userID int64, tempPath, target, source string) {
+	key := fileKey(target)
+	id := newCallbackPayload(userID, strings.Join([]string{source, fileKey(tempPath), key}, "|"))
+
+	details := ""
+	if info, err := os.Stat(target); err == nil {
+		details = fmt.Sprintf(" (%s, %s)", formatFileSize(info.Size()), info.ModTime().Format("02.01.2006 15:04"))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🆕 Yeni adla kaydet", callbackData("gelen", collisionRename, id))),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🗂️ Eskisini sürüm olarak sakla", callbackData("gelen", collisionVersion, id))),
+	}
+	if collisionPolicyFor(userID, target, collisionOverwrite) == collisionOverwrite {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("♻️ Üzerine yaz", callbackData("gelen", collisionOverwrite, id))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Kaydetme", callbackData("gelen", "iptal", id))))
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ `%s` zaten var%s. Yeni dosya ne yapılsın?", key, details))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	bot.Send(msg)
+}
+
+// handleIncomingCallback, aynı adlı dosya sorusunun düğmelerini işler.
+func handleIncomingCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	userID := callbackQuery.From.ID
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 {
+		return
+	}
+	action := parts[1]
+
+	value, err := takeCallbackPayload(parts[2], userID)
+	if errors.Is(err, errPayloadForeign) {
+		return
+	}
+	edit := func(text string) {
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+		editMsg.ParseMode = "Markdown"
+		bot.Send(editMsg)
+	}
+	if err != nil {
+		edit("⌛ Bu sorunun süresi dolmuş; dosya kaydedilmedi. Lütfen dosyayı yeniden gönderin.")
+		return
+	}
+	fields := strings.SplitN(value, "|", 3)
+	if len(fields) != 3 {
+		return
+	}
+	source := fields[0]
+	tempPath, okTemp := pathOfKey(fields[1])
+	target, okTarget := pathOfKey(fields[2])
+	if !okTemp || !okTarget {
+		return
+	}
+	if _, err := os.Stat(tempPath); err != nil {
+		edit("❌ Gönderilen dosya artık bulunamıyor. Lütfen dosyayı yeniden gönderin.")
+		return
+	}
+	if action == "iptal" || !validCollisionPolicy(action) {
+		os.Remove(tempPath)
+		removeFileMetadata(fields[1])
+		edit(fmt.Sprintf("👍 `%s` kaydedilmedi.", fields[2]))
+		return
+	}
+
+	// * Soru gönderildikten sonra yetki değişmiş olabilir.
+	result, err := commitIncoming(userID, tempPath, target, collisionPolicyFor(userID, target, action))
+	if err != nil {
+		log.Printf("Gelen dosya kaydedilemedi (%s): %v", fields[2], err)
+		edit(fmt.Sprintf("❌ `%s` kaydedilemedi: %v", fields[2], err))
+		return
+	}
+	// * Düzenleme sırasında bekletilen dosyanın açıklaması ve etiketleri
+	// * yeni konumuna taşınır; orada bir açıklama varsa o korunur.
+	if meta := getFileMetadata(fileKey(result.Path)); meta.Description == "" && len(meta.Tags) == 0 {
+		err = moveFileMetadata(fields[1], fileKey(result.Path))
+	} else {
+		err = removeFileMetadata(fields[1])
+	}
+	if err != nil {
+		log.Printf("Açıklama taşınamadı: %s - %v", fields[1], err)
+	}
+	if err := recordFileOrigin(fileKey(result.Path), source, userID); err != nil {
+		log.Printf("Dosyanın kaynağı kaydedilemedi: %v", err)
+	}
+	log.Printf("Dosya kaydedildi: %s", fileKey(result.Path))
+	edit(fmt.Sprintf("✅ *Dosya kaydedildi:* `%s`\n%s%s", fileKey(result.Path), incomingCollisionText(result), duplicateWarningText(userID, result.Path)))
+}
+
+// purgeStaleIncoming, yanıtlanmamış sorulardan veya yarıda kalan
+// yüklemelerden kalan geçici dosyaları siler. Saatlik zamanlayıcı çağırır.
+func purgeStaleIncoming() {
+	filepath.Walk(config.BaseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !isIncomingTemp(info.Name()) {
+			return nil
+		}
+		if time.Since(info.ModTime()) > staleIncomingAge {
+			if err := os.Remove(path); err == nil {
+				log.Printf("Yarım kalan yükleme silindi: %s", fileKey(path))
+			}
+		}
+		return nil
+	})
+}
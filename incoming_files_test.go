@@ -0,0 +1,234 @@
+// incoming_files_test.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// writeTestFile, ana klasöre göre verilen yola bir dosya yazar.
+func writeTestFile(t *testing.T, key, content string) string {
+	t.Helper()
+	path := filepath.Join(config.BaseDir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPlanOrganizeSkipsIncomingTemp(t *testing.T) {
+	setupTestEnv(t)
+	writeTestFile(t, "rapor.pdf", "pdf")
+	writeTestFile(t, incomingTempPrefix+"123", "yarım")
+	writeTestFile(t, "Arsiv/"+incomingTempPrefix+"456", "yarım")
+	var rule OrganizeRuleConfig
+	rule.Name = "arşiv"
+	rule.Match.Folder = "Arsiv"
+	rule.Action.Move = "Eski"
+	rules, problems := compileOrganizeRules([]OrganizeRuleConfig{rule})
+	if len(problems) > 0 {
+		t.Fatal(problems)
+	}
+	config.OrganizeRules = rules
+
+	for _, plan := range planOrganize(time.Now()) {
+		if isIncomingTemp(plan.Facts.Name) {
+			t.Errorf("yazılmakta olan dosya düzenlenecek: %s", plan.Facts.Key)
+		}
+	}
+}
+
+// readTestFile, ana klasöre göre verilen dosyanın içeriğini okur.
+func readTestFile(t *testing.T, key string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(config.BaseDir, filepath.FromSlash(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCommitIncomingReplacementCanBeUndone(t *testing.T) {
+	for _, policy := range []string{collisionOverwrite, collisionVersion} {
+		t.Run(policy, func(t *testing.T) {
+			bot := setupTestEnv(t)
+			target := writeTestFile(t, "rapor.txt", "eski")
+			tempPath, err := receiveToTemp(config.BaseDir, strings.NewReader("yeni"), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := commitIncoming(testOperatorID, tempPath, target, policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Path != target || readTestFile(t, "rapor.txt") != "yeni" {
+				t.Fatalf("yeni dosya yerine konmadı: %+v", result)
+			}
+
+			handleCommand(bot, newCommandMessage(testOperatorID, "/islemler"))
+			if got := lastText(t, bot); !strings.Contains(got, "rapor.txt` yerine yenisi kaydedildi") {
+				t.Errorf("/islemler değişikliği göstermiyor: %q", got)
+			}
+
+			handleCommand(bot, newCommandMessage(testOperatorID, "/geri_al_son"))
+			if got := readTestFile(t, "rapor.txt"); got != "eski" {
+				t.Fatalf("geri alma sonrası içerik = %q, %q bekleniyordu (yanıt: %q)", got, "eski", lastText(t, bot))
+			}
+			// Geri alınan yeni dosya da kaybolmaz.
+			switch policy {
+			case collisionOverwrite:
+				entries, _ := listTrash(testOperatorID)
+				if len(entries) != 1 || entries[0].Original != "rapor.txt" {
+					t.Errorf("yeni dosya çöp kutusuna taşınmadı: %+v", entries)
+				}
+			case collisionVersion:
+				if versions := listVersions("rapor.txt"); len(versions) != 1 {
+					t.Errorf("yeni dosya sürüm olarak saklanmadı: %d sürüm", len(versions))
+				}
+			}
+		})
+	}
+}
+
+func TestCommitIncomingRestoresOnFailure(t *testing.T) {
+	for _, policy := range []string{collisionOverwrite, collisionVersion} {
+		t.Run(policy, func(t *testing.T) {
+			setupTestEnv(t)
+			target := writeTestFile(t, "rapor.txt", "eski")
+			missing := filepath.Join(config.BaseDir, incomingTempPrefix+"yok")
+			if _, err := commitIncoming(testOperatorID, missing, target, policy); err == nil {
+				t.Fatal("olmayan geçici dosya kaydedildi")
+			}
+			if got := readTestFile(t, "rapor.txt"); got != "eski" {
+				t.Errorf("eski dosya yerine konmadı: %q", got)
+			}
+			if ops, _ := recentOperations(testOperatorID, 0, 10); len(ops) != 0 {
+				t.Errorf("başarısız kayıt günlüğe yazıldı: %+v", ops)
+			}
+		})
+	}
+}
+
+func TestSplitAssemblyUsesCollisionPolicy(t *testing.T) {
+	bot := setupTestEnv(t)
+	config.IncomingCollision = collisionVersion
+	writeTestFile(t, "arsiv.bin", "eski")
+
+	chunks := []string{"birinci-", "ikinci"}
+	manifest := splitManifest{Version: splitManifestVer, Name: "arsiv.bin", PartSize: int64(len(chunks[0]))}
+	whole := sha256.New()
+	for i, chunk := range chunks {
+		sum := sha256.Sum256([]byte(chunk))
+		whole.Write([]byte(chunk))
+		manifest.Size += int64(len(chunk))
+		manifest.Parts = append(manifest.Parts, splitPart{Index: i + 1, Name: splitPartName("arsiv.bin", i+1), Size: int64(len(chunk)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	manifest.SHA256 = hex.EncodeToString(whole.Sum(nil))
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := newCommandMessage(testOperatorID, "")
+	for i, chunk := range chunks {
+		receiveSplitPart(bot, message, manifest.Parts[i].Name, strings.NewReader(chunk))
+	}
+	receiveSplitPart(bot, message, "arsiv.bin"+splitManifestSuffix, strings.NewReader(string(data)))
+
+	if got := readTestFile(t, "arsiv.bin"); got != "birinci-ikinci" {
+		t.Fatalf("birleştirilen içerik = %q (yanıt: %q)", got, lastText(t, bot))
+	}
+	if versions := listVersions("arsiv.bin"); len(versions) != 1 {
+		t.Errorf("eski dosya sürüm olarak saklanmadı: %d sürüm", len(versions))
+	}
+	if ops, _ := recentOperations(testOperatorID, 0, 10); len(ops) != 1 || ops[0].Kind != operationReplace {
+		t.Errorf("değiştirme işlem günlüğüne yazılmadı: %+v", ops)
+	}
+	if _, err := os.Stat(splitStagingDir(testOperatorID, "arsiv.bin")); !os.IsNotExist(err) {
+		t.Errorf("bekleme klasörü silinmedi: %v", err)
+	}
+}
+
+func TestOrganizeAppliesCollisionPolicy(t *testing.T) {
+	for _, policy := range []string{collisionRename, collisionOverwrite, collisionVersion, collisionAsk} {
+		t.Run(policy, func(t *testing.T) {
+			bot := setupTestEnv(t)
+			config.IncomingCollision = policy
+			server := newFakeBotAPIServer()
+			defer server.Close()
+
+			// Aynı ad iki kez gönderilir; araya düzenleme girdiği için ikinci
+			// dosya ana klasörde değil, kategori klasöründe çakışır.
+			handleFile(bot, newDocumentMessage(t, bot, server, testOperatorID, "f1", "rapor.txt", []byte("eski"), 4))
+			organizeFiles(bot, systemUserID)
+			handleFile(bot, newDocumentMessage(t, bot, server, testOperatorID, "f2", "rapor.txt", []byte("yeni"), 4))
+			bot.Reset()
+			organizeFiles(bot, systemUserID)
+			category := getFileCategory("rapor.txt")
+			organized := category + "/rapor.txt"
+
+			switch policy {
+			case collisionRename:
+				if got := readTestFile(t, category+"/rapor_1.txt"); got != "yeni" {
+					t.Errorf("yeni dosya = %q", got)
+				}
+				return
+			case collisionAsk:
+				if _, err := os.Stat(filepath.Join(config.BaseDir, "rapor.txt")); !os.IsNotExist(err) {
+					t.Fatalf("dosya soru yanıtlanmadan bekletilmedi: %v", err)
+				}
+				msg := bot.Sent[len(bot.Sent)-1].(tgbotapi.MessageConfig)
+				if msg.ChatID != testOperatorID || !strings.Contains(msg.Text, organized) {
+					t.Fatalf("soru = %+v", msg)
+				}
+				markup := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+				var overwrite string
+				for _, row := range markup.InlineKeyboard {
+					if data := *row[0].CallbackData; strings.HasPrefix(data, "gelen_"+collisionOverwrite+"_") {
+						overwrite = data
+					}
+				}
+				if overwrite == "" {
+					t.Fatal("üzerine yaz düğmesi yok")
+				}
+				handleCallbackQuery(bot, newCallbackQuery(testOperatorID, overwrite))
+			}
+
+			if got := readTestFile(t, organized); got != "yeni" {
+				t.Fatalf("yeni dosya yerine konmadı: %q (yanıt: %q)", got, lastText(t, bot))
+			}
+			if _, err := os.Stat(filepath.Join(config.BaseDir, category, "rapor_1.txt")); !os.IsNotExist(err) {
+				t.Errorf("rapor_1.txt oluştu: %v", err)
+			}
+			if policy == collisionVersion {
+				if versions := listVersions(organized); len(versions) != 1 {
+					t.Errorf("eski dosya sürüm olarak saklanmadı: %d sürüm", len(versions))
+				}
+			} else if entries, _ := listTrash(testOperatorID); len(entries) != 1 || entries[0].Original != organized {
+				t.Errorf("eski dosya çöp kutusuna taşınmadı: %+v", entries)
+			}
+
+			handleCommand(bot, newCommandMessage(testOperatorID, "/geri_al_son"))
+			if got := readTestFile(t, organized); got != "eski" {
+				t.Errorf("geri alma sonrası içerik = %q (yanıt: %q)", got, lastText(t, bot))
+			}
+			// Düzenleme geri alınınca yeni dosya ana klasöre döner.
+			if policy != collisionAsk {
+				if got := readTestFile(t, "rapor.txt"); got != "yeni" {
+					t.Errorf("ana klasördeki dosya = %q", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// invite_store.go
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// #############################################################################
+// #                          DAVET KODLARI VE GEÇİCİ ERİŞİM
+// #############################################################################
+// Bu dosya, yöneticinin `.env` dosyasını düzenlemeden yeni kullanıcılara
+// erişim vermesini sağlayan davet kodlarını yönetir. Davetler `users.json`
+// içinde kullanıcılar ve rollerle birlikte saklanır ve aynı `accessMutex`
+// kilidiyle korunur. Bir davet:
+//   - Belirli bir rolle sınırlıdır,
+//   - Tek kullanımlık veya belirli sayıda kullanımlık olabilir,
+//   - Kendi geçerlilik süresine sahip olabilir,
+//   - Kullanan kişiye kalıcı ya da süreli (geçici) erişim verebilir.
+// Süresi dolan davetler ve geçici erişimler `runScheduler` tarafından
+// saatlik olarak temizlenir.
+
+// Invite, tek bir davet kodunu temsil eder.
+type Invite struct {
+	Code      string  `json:"code"`
+	Role      string  `json:"role"`
+	MaxUses   int     `json:"max_uses"`             // 0: sınırsız
+	Uses      int     `json:"uses"`                 // şimdiye kadar kaç kez kullanıldığı
+	Expires   string  `json:"expires,omitempty"`    // kodun geçerlilik bitişi (RFC3339)
+	AccessFor string  `json:"access_for,omitempty"` // kullanana verilecek erişim süresi (örn. "72h")
+	CreatedBy int64   `json:"created_by"`           // daveti oluşturan yönetici
+	Created   string  `json:"created"`              // oluşturulma zamanı (RFC3339)
+	UsedBy    []int64 `json:"used_by,omitempty"`    // daveti kullanan kullanıcılar
+}
+
+// inviteCodeAlphabet, davet kodlarında kullanılan karakterlerdir. Karışıklığa
+// yol açan 0/O ve 1/I karakterleri bilerek dışarıda bırakılmıştır.
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// inviteCodeLength, üretilen davet kodlarının uzunluğudur.
+const inviteCodeLength = 10
+
+// generateInviteCode, kriptografik olarak rastgele bir davet kodu üretir.
+func generateInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("rastgele kod üretilemedi: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// parseDurationArg, "90m", "12h" gibi Go sürelerinin yanında gün için "7g"
+// (veya "7d") biçimini de kabul eder. "0" ve "kalici" süresiz anlamına gelir
+// ve sıfır döndürür.
+func parseDurationArg(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "0" || s == "kalici" || s == "kalıcı" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "g") || strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("geçersiz gün sayısı: %s", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("geçersiz süre: %s (örn. 30m, 12h, 7g)", s)
+	}
+	return d, nil
+}
+
+// isExpired, RFC3339 biçimindeki bir bitiş zamanının geçip geçmediğini
+// kontrol eder. Boş değer süresiz kabul edilir.
+func isExpired(expires string, now time.Time) bool {
+	if expires == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return false
+	}
+	return !now.Before(t)
+}
+
+// createInvite, yeni bir davet kodu oluşturur ve kaydeder. `validFor` kodun,
+// `accessFor` ise kodu kullanan kişiye verilecek erişimin süresidir; ikisi
+// de sıfırsa süresizdir.
+func createInvite(createdBy int64, roleName string, validFor, accessFor time.Duration, maxUses int) (Invite, error) {
+	code, err := generateInviteCode()
+	if err != nil {
+		return Invite{}, err
+	}
+
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if _, ok := accessData.Roles[roleName]; !ok {
+		return Invite{}, fmt.Errorf("bilinmeyen rol: %s", roleName)
+	}
+	now := time.Now()
+	invite := &Invite{
+		Code:      code,
+		Role:      roleName,
+		MaxUses:   maxUses,
+		CreatedBy: createdBy,
+		Created:   now.Format(time.RFC3339),
+	}
+	if validFor > 0 {
+		invite.Expires = now.Add(validFor).Format(time.RFC3339)
+	}
+	if accessFor > 0 {
+		invite.AccessFor = accessFor.String()
+	}
+	accessData.Invites[code] = invite
+	if err := saveUsers(); err != nil {
+		delete(accessData.Invites, code)
+		return Invite{}, err
+	}
+	return *invite, nil
+}
+
+// redeemInvite, bir davet kodunu kullanarak kullanıcıyı kaydeder ve
+// oluşturulan hesabın bir kopyasını döndürür. Kod geçersizse, süresi
+// dolmuşsa veya kullanım hakkı bitmişse hata döner.
+func redeemInvite(code string, userID int64, name string) (UserAccount, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+
+	now := time.Now()
+	invite, ok := accessData.Invites[code]
+	if !ok || isExpired(invite.Expires, now) || (invite.MaxUses > 0 && invite.Uses >= invite.MaxUses) {
+		return UserAccount{}, fmt.Errorf("davet kodu geçersiz veya süresi dolmuş")
+	}
+	if _, ok := accessData.Roles[invite.Role]; !ok {
+		return UserAccount{}, fmt.Errorf("davetin rolü artık tanımlı değil: %s", invite.Role)
+	}
+	if existing, ok := accessData.Users[userID]; ok && !isExpired(existing.Expires, now) {
+		return UserAccount{}, fmt.Errorf("zaten kayıtlı bir kullanıcısınız")
+	}
+
+	account := &UserAccount{ID: userID, Name: name, Role: invite.Role, Added: now.Format(time.RFC3339), Invite: code}
+	if invite.AccessFor != "" {
+		if d, err := time.ParseDuration(invite.AccessFor); err == nil {
+			account.Expires = now.Add(d).Format(time.RFC3339)
+		}
+	}
+	accessData.Users[userID] = account
+	invite.Uses++
+	invite.UsedBy = append(invite.UsedBy, userID)
+	if invite.MaxUses > 0 && invite.Uses >= invite.MaxUses {
+		// * Kullanım hakkı biten davet hemen silinir; kimin kullandığı
+		// * bilgisi kullanıcının `Invite` alanında kalır.
+		delete(accessData.Invites, code)
+	}
+	if err := saveUsers(); err != nil {
+		return UserAccount{}, err
+	}
+	return *account, nil
+}
+
+// revokeInvite, henüz kullanılmamış (veya kullanım hakkı kalan) bir daveti iptal eder.
+// Davetle önceden kaydolmuş kullanıcıların erişimi bundan etkilenmez.
+func revokeInvite(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if _, ok := accessData.Invites[code]; !ok {
+		return fmt.Errorf("davet bulunamadı: %s", code)
+	}
+	delete(accessData.Invites, code)
+	return saveUsers()
+}
+
+// listInvites, aktif davetleri oluşturulma sırasıyla (kopya olarak) döndürür.
+func listInvites() []Invite {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	var invites []Invite
+	for _, invite := range accessData.Invites {
+		invites = append(invites, *invite)
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].Created < invites[j].Created })
+	return invites
+}
+
+// cleanupExpiredAccess, süresi dolan davetleri ve geçici erişimleri siler.
+// Silinen kullanıcı hesaplarının kopyalarını ve silinen davet sayısını döndürür.
+func cleanupExpiredAccess(now time.Time) ([]UserAccount, int, error) {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if accessData == nil {
+		return nil, 0, nil
+	}
+
+	var removedUsers []UserAccount
+	for id, account := range accessData.Users {
+		if id != config.AdminChatID && isExpired(account.Expires, now) {
+			removedUsers = append(removedUsers, *account)
+			delete(accessData.Users, id)
+		}
+	}
+	removedInvites := 0
+	for code, invite := range accessData.Invites {
+		if isExpired(invite.Expires, now) {
+			delete(accessData.Invites, code)
+			removedInvites++
+		}
+	}
+
+	if len(removedUsers) == 0 && removedInvites == 0 {
+		return nil, 0, nil
+	}
+	sort.Slice(removedUsers, func(i, j int) bool { return removedUsers[i].ID < removedUsers[j].ID })
+	return removedUsers, removedInvites, saveUsers()
+}
@@ -0,0 +1,238 @@
+// lifecycle.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                         YAŞAM DÖNGÜSÜ VE KAPANIŞ
+// #############################################################################
+// Bu dosya, botun arka plan işlerinin başlatılmasını ve düzgün bir şekilde
+// kapatılmasını yönetir. Kapanış iki aşamalıdır:
+//   1. `lifecycleCtx` iptal edilir: Yeni güncelleme alınmaz, zamanlayıcı ve
+//      izleyiciler durur, devam eden işlerin bitmesi beklenir.
+//   2. `SHUTDOWN_TIMEOUT` süresi dolarsa `jobsCtx` iptal edilir: Bu context
+//      ile başlatılan yt-dlp, ffmpeg, speedtest gibi alt süreçler
+//      sonlandırılır ve yarım kalan indirme dosyaları temizlenir.
+// Son olarak ekran kaydı düzgünce durdurulur, gönderilemeyen bildirimler
+// diske yazılır ve metadata deposu kapatılır.
+
+var (
+	// lifecycleCtx, kapanış başladığında iptal edilir. Uzun ömürlü döngüler
+	// (zamanlayıcı, izleyiciler, güncelleme alımı) bunu dinler.
+	lifecycleCtx, stopLifecycle = context.WithCancel(context.Background())
+
+	// jobsCtx, kapanış süresi dolduğunda iptal edilir. Devam eden işlerin
+	// başlattığı alt süreçler ve ağ istekleri bu context'e bağlanır.
+	jobsCtx, cancelJobs = context.WithCancel(context.Background())
+
+	// backgroundJobs, `goBackground` ile başlatılan goroutine'leri sayar.
+	backgroundJobs sync.WaitGroup
+
+	shutdownOnce sync.Once
+	finalizeOnce sync.Once
+)
+
+// pendingNotificationsPath, kapanışta gönderilemeyen bildirimlerin yazıldığı dosyadır.
+const pendingNotificationsPath = "pending_notifications.json"
+
+// shutdownGracePeriod, işler iptal edildikten sonra sürecin zorla
+// sonlandırılmasına kadar beklenen ek süredir.
+const shutdownGracePeriod = 5 * time.Second
+
+// goBackground, bir işi kapanışta beklenecek şekilde ayrı bir goroutine'de başlatır.
+func goBackground(fn func()) {
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		fn()
+	}()
+}
+
+// isShuttingDown, kapanışın başlayıp başlamadığını bildirir.
+func isShuttingDown() bool {
+	return lifecycleCtx.Err() != nil
+}
+
+// beginShutdown, kapanışın ilk aşamasını başlatır: yöneticiyi bilgilendirir,
+// güncelleme kaynağını kapatır ve süre dolduğunda işleri iptal edecek
+// zamanlayıcıyı kurar. Birden fazla kez çağrılması güvenlidir.
+func beginShutdown(bot Messenger, reason string, stopUpdates func()) {
+	shutdownOnce.Do(func() {
+		log.Printf("Kapanış başlatıldı (%s). Devam eden işler için en fazla %s beklenecek.", reason, shutdownTimeout())
+		if config.AdminChatID != 0 {
+			bot.Send(tgbotapi.NewMessage(config.AdminChatID, fmt.Sprintf("🔴 Bot kapanıyor (%s). Devam eden işler tamamlanıyor...", reason)))
+		}
+
+		stopLifecycle()
+		stopUpdates()
+
+		go func() {
+			time.Sleep(shutdownTimeout())
+			log.Println("Kapanış süresi doldu, devam eden işler iptal ediliyor...")
+			cancelJobs()
+			time.Sleep(shutdownGracePeriod)
+			log.Println("İşler zamanında sonlanmadı, bot zorla kapatılıyor.")
+			finalizeShutdown(bot)
+			os.Exit(1)
+		}()
+	})
+}
+
+// finishShutdown, güncelleme dağıtıcısı boşaldıktan sonra `main` tarafından
+// çağrılır. Kalan arka plan işlerini bekler ve kapanışı tamamlar.
+func finishShutdown(bot Messenger) {
+	done := make(chan struct{})
+	go func() {
+		backgroundJobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-jobsCtx.Done():
+		// Süre zaten doldu; iptal edilen işlerin kapanması için kısa bir süre tanınır.
+		select {
+		case <-done:
+		case <-time.After(shutdownGracePeriod):
+			log.Println("Bazı arka plan işleri sonlanmadı, beklemeden devam ediliyor.")
+		}
+	}
+	cancelJobs()
+	finalizeShutdown(bot)
+}
+
+// finalizeShutdown, kalıcı durumu diske yazar. Yalnızca bir kez çalışır.
+func finalizeShutdown(bot Messenger) {
+	finalizeOnce.Do(func() {
+		stopRecordingOnShutdown()
+
+		if err := savePendingNotifications(); err != nil {
+			log.Printf("Bekleyen bildirimler kaydedilemedi: %v", err)
+		}
+
+		if err := closeMetadata(); err != nil {
+			log.Printf("Metadata deposu kapatılamadı: %v", err)
+		}
+
+		accessMutex.Lock()
+		if accessData != nil {
+			if err := saveUsers(); err != nil {
+				log.Printf("Kullanıcılar kaydedilemedi: %v", err)
+			}
+		}
+		accessMutex.Unlock()
+
+		log.Println("Kapanış tamamlandı.")
+	})
+}
+
+// stopRecordingOnShutdown, devam eden bir ekran kaydı varsa FFmpeg'e 'q'
+// göndererek videonun düzgün kapanmasını sağlar.
+func stopRecordingOnShutdown() {
+	recordingMutex.Lock()
+	defer recordingMutex.Unlock()
+	if recordingCmd == nil || recordingCmd.Process == nil {
+		return
+	}
+	log.Printf("Kapanış: ekran kaydı durduruluyor. Dosya: %s", recordingFileName)
+	if _, err := recordingStdin.Write([]byte("q\n")); err != nil {
+		recordingCmd.Process.Kill()
+	}
+	waitDone := make(chan struct{})
+	go func() {
+		recordingCmd.Wait()
+		close(waitDone)
+	}()
+	select {
+	case <-waitDone:
+	case <-time.After(10 * time.Second):
+		log.Println("FFmpeg zamanında kapanmadı, işlem sonlandırılıyor.")
+		recordingCmd.Process.Kill()
+		<-waitDone
+	}
+	recordingStdin.Close()
+	recordingCmd = nil
+	recordingStdin = nil
+	recordingFileName = ""
+}
+
+// pendingNotification, diske yazılabilen bir bildirim mesajıdır.
+type pendingNotification struct {
+	ChatID    int64  `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// savePendingNotifications, `notificationQueue` içindeki metin mesajlarını
+// bir sonraki başlangıçta gönderilmek üzere diske yazar.
+func savePendingNotifications() error {
+	queueMutex.Lock()
+	defer queueMutex.Unlock()
+	var pending []pendingNotification
+	for _, c := range notificationQueue {
+		if msg, ok := c.(tgbotapi.MessageConfig); ok {
+			pending = append(pending, pendingNotification{ChatID: msg.ChatID, Text: msg.Text, ParseMode: msg.ParseMode})
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Printf("%d bekleyen bildirim diske yazıldı.", len(pending))
+	return os.WriteFile(pendingNotificationsPath, data, 0600)
+}
+
+// loadPendingNotifications, önceki çalışmadan kalan bildirimleri kuyruğa
+// geri yükler. Kuyruk, internet bağlantısı kontrolünde gönderilir.
+func loadPendingNotifications() {
+	data, err := os.ReadFile(pendingNotificationsPath)
+	if err != nil {
+		return
+	}
+	var pending []pendingNotification
+	if err := json.Unmarshal(data, &pending); err != nil {
+		log.Printf("Bekleyen bildirimler okunamadı: %v", err)
+		return
+	}
+	queueMutex.Lock()
+	for _, p := range pending {
+		msg := tgbotapi.NewMessage(p.ChatID, p.Text)
+		msg.ParseMode = p.ParseMode
+		notificationQueue = append(notificationQueue, msg)
+	}
+	queueMutex.Unlock()
+	os.Remove(pendingNotificationsPath)
+	log.Printf("Önceki çalışmadan %d bekleyen bildirim yüklendi.", len(pending))
+}
+
+// cleanupPartialDownloads, iptal edilen yt-dlp indirmelerinin ana klasörde
+// bıraktığı yarım dosyaları (`.part`, `.ytdl`) siler.
+func cleanupPartialDownloads() {
+	entries, err := os.ReadDir(config.BaseDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".part") || strings.HasSuffix(name, ".ytdl") || strings.Contains(name, ".part-Frag")) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(config.BaseDir, name)); err == nil {
+			log.Printf("Yarım kalan indirme silindi: %s", name)
+		}
+	}
+}
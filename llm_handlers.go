@@ -42,12 +42,6 @@ var (
 	systemPrompt     string
 )
 
-var modelFallbackList = []string{
-	"gemini-2.5-flash",
-	"gemini-2.0-flash",
-	"gemini-2.5-flash-lite",
-}
-
 var botTools = []*genai.Tool{
 	{
 		FunctionDeclarations: []*genai.FunctionDeclaration{
@@ -79,7 +73,7 @@ var botTools = []*genai.Tool{
 					Properties: map[string]*genai.Schema{
 						"category": {
 							Type:        genai.TypeString,
-							Description: "İçeriği listelenecek kategori adı. Geçerli değerler: 'Resimler', 'Dokümanlar', 'Videolar', 'Sesler', 'Arşivler', 'Diğer'.",
+							Description: "İçeriği listelenecek kategori adı. Varsayılan değerler: 'Resimler', 'Dokümanlar', 'Videolar', 'Sesler', 'Arşivler', 'Diğer' (config.yaml ile değiştirilebilir).",
 						},
 					},
 					Required: []string{"category"},
@@ -87,7 +81,7 @@ var botTools = []*genai.Tool{
 			},
 			{
 				Name:        "search_files",
-				Description: "Kullanıcı 'içinde ... geçen dosyaları bul' veya '... dosyasını ara' dediğinde kullanılır. Verilen anahtar kelimeyi tüm dosya adlarında arar ve bulunan dosyaların yollarını içeren bir liste döndürür.",
+				Description: "Kullanıcı 'adında ... geçen dosyaları bul' veya '... dosyasını ara' dediğinde kullanılır. Verilen anahtar kelimeyi tüm dosya adlarında arar (dosya içeriklerinde arama için 'search_file_contents' kullanılır) ve bulunan dosyaların yollarını içeren bir liste döndürür.",
 				Parameters: &genai.Schema{
 					Type: genai.TypeObject,
 					Properties: map[string]*genai.Schema{
@@ -99,6 +93,20 @@ var botTools = []*genai.Tool{
 					Required: []string{"keyword"},
 				},
 			},
+			{
+				Name:        "search_file_contents",
+				Description: "Kullanıcı bir belgenin içeriğinde geçen bir ifadeyi aradığında kullanılır (Örn: 'kira sözleşmesi geçen belgeleri bul', 'içinde fatura numarası olan pdf hangisi?'). PDF, Word (docx), ODT, metin ve kaynak kod dosyalarının içeriğinde arar ve en alakalı dosyaların yollarını kısa alıntılarla birlikte döndürür. Dosya adında arama için 'search_files' kullanılmalıdır.",
+				Parameters: &genai.Schema{
+					Type: genai.TypeObject,
+					Properties: map[string]*genai.Schema{
+						"query": {
+							Type:        genai.TypeString,
+							Description: "Belge içeriklerinde aranacak kelimeler. Tüm kelimeleri içeren belgeler döner. Sonuna '*' eklenen kelime önek olarak aranır (örn. 'sözleşme*').",
+						},
+					},
+					Required: []string{"query"},
+				},
+			},
 			{
 				Name:        "send_file",
 				Description: "Kullanıcı bir dosyanın kendisine gönderilmesini istediğinde kullanılır (Örn: 'rapor.pdf dosyasını gönder'). Bu araç, belirtilen dosyayı bulur ve doğrudan kullanıcıya gönderir. Bu bir 'ateşle ve unut' (fire-and-forget) komutudur; asıl dosya gönderim işlemi arka planda gerçekleşir. Bu fonksiyonun döndürdüğü metin, sadece işlemin başarıyla tetiklendiğini belirten bir onaydır.",
@@ -107,7 +115,7 @@ var botTools = []*genai.Tool{
 					Properties: map[string]*genai.Schema{
 						"filename": {
 							Type:        genai.TypeString,
-							Description: "Kullanıcıya gönderilecek olan dosyanın tam adı ve uzantısı. Yapay zeka, kullanıcının isteğinden dosya adını doğru bir şekilde çıkarmalıdır.",
+							Description: "Kullanıcıya gönderilecek olan dosyanın tam adı ve uzantısı veya ana klasöre göre yolu (örn. 'Dokümanlar/rapor.pdf'). Yapay zeka, kullanıcının isteğinden dosya adını doğru bir şekilde çıkarmalıdır.",
 						},
 					},
 					Required: []string{"filename"},
@@ -115,13 +123,13 @@ var botTools = []*genai.Tool{
 			},
 			{
 				Name:        "delete_file",
-				Description: "DİKKAT: Sunucudan bir dosyayı KALICI olarak siler. Bu işlem geri alınamaz. Kullanıcı '... dosyasını sil' veya '... dosyasını kaldır' gibi çok net bir komut verdiğinde kullanılır. Başarılı veya hatalı olduğuna dair bir sonuç metni döndürür.",
+				Description: "DİKKAT: Sunucudan bir dosyayı siler. Dosya çöp kutusuna taşınır ve saklama süresi dolana kadar `/geri_al` ile geri alınabilir. Kullanıcı '... dosyasını sil' veya '... dosyasını kaldır' gibi çok net bir komut verdiğinde kullanılır. Başarılı veya hatalı olduğuna dair bir sonuç metni döndürür.",
 				Parameters: &genai.Schema{
 					Type: genai.TypeObject,
 					Properties: map[string]*genai.Schema{
 						"filename": {
 							Type:        genai.TypeString,
-							Description: "Kalıcı olarak silinecek dosyanın uzantısı dahil tam adı.",
+							Description: "Kalıcı olarak silinecek dosyanın uzantısı dahil tam adı veya ana klasöre göre yolu (örn. 'Dokümanlar/rapor.pdf'). Aynı adla birden fazla dosya varsa yol kullanılmalıdır.",
 						},
 					},
 					Required: []string{"filename"},
@@ -171,7 +179,7 @@ var botTools = []*genai.Tool{
 // #                            LLM Komut İşleyicileri
 // #############################################################################
 
-func handleLlmOnCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleLlmOnCommand(bot Messenger, message *tgbotapi.Message) {
 	if config.GeminiAPIKey == "" {
 		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "❌ LLM özelliği yönetici tarafından yapılandırılmamış. (API Anahtarı eksik)"))
 		return
@@ -185,13 +193,13 @@ func handleLlmOnCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		return
 	}
 
-	modelName := modelFallbackList[0]
+	modelName := llmModels()[0]
 	log.Printf("Yeni sohbet oturumu '%s' modeli ile başlatılıyor.", modelName)
 	model := client.GenerativeModel(modelName)
 	model.Tools = botTools
-	if systemPrompt != "" {
+	if prompt := currentSystemPrompt(); prompt != "" {
 		model.SystemInstruction = &genai.Content{
-			Parts: []genai.Part{genai.Text(systemPrompt)},
+			Parts: []genai.Part{genai.Text(prompt)},
 		}
 	}
 	chatSession := model.StartChat()
@@ -214,147 +222,147 @@ func handleLlmOnCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	}
 }
 
-func handleLlmQuery(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleLlmQuery(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	userID := message.From.ID
 	userQuery := message.Text
 
-	// Her sorguyu kendi goroutine'i içinde işle, böylece loglar karışmaz.
-	go func() {
-		log.Printf("\n\n--- YENI AKILLI SORGU BAŞLADI ---\nKullanıcı: %d, Sorgu: %s", userID, userQuery)
-
-		statusMsg, _ := bot.Send(tgbotapi.NewMessage(chatID, "🧠 Düşünüyorum..."))
-		messageID := statusMsg.MessageID
+	// Sorgu, dağıtıcının worker goroutine'inde çalışır (bkz. `dispatcher.go`);
+	// aynı sohbetteki sorgular sırayla işlenir.
+	log.Printf("\n\n--- YENI AKILLI SORGU BAŞLADI ---\nKullanıcı: %d, Sorgu: %s", userID, userQuery)
 
-		llmMutex.Lock()
-		currentUserSession := userChatSessions[userID]
-		llmMutex.Unlock()
+	statusMsg, _ := bot.Send(tgbotapi.NewMessage(chatID, "🧠 Düşünüyorum..."))
+	messageID := statusMsg.MessageID
 
-		if currentUserSession == nil || currentUserSession.Session == nil {
-			bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, "❌ Aktif bir sohbet oturumu bulunamadı. Lütfen `/llm` komutuyla yeniden başlatın."))
-			return
-		}
+	llmMutex.Lock()
+	currentUserSession := userChatSessions[userID]
+	llmMutex.Unlock()
 
-		ctx := context.Background()
-		
-		var finalResponse *genai.GenerateContentResponse
-		var finalErr error
+	if currentUserSession == nil || currentUserSession.Session == nil {
+		bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, "❌ Aktif bir sohbet oturumu bulunamadı. Lütfen `/llm` komutuyla yeniden başlatın."))
+		return
+	}
 
-		// Kullanıcının bu spesifik sorgusu için ilk prompt.
-		// Bu değişken, döngüler boyunca değiştirilmeyecek.
-		initialPrompt := []genai.Part{genai.Text(userQuery)}
+	// Kapanış süresi dolarsa devam eden Gemini istekleri iptal edilir.
+	ctx := jobsCtx
+	
+	var finalResponse *genai.GenerateContentResponse
+	var finalErr error
 
-		for _, modelName := range modelFallbackList {
-			log.Printf("[DEBUG] Model denemesi başlıyor: %s", modelName)
-			bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("🧠 Düşünüyorum... (Model: %s)", modelName)))
+	// Kullanıcının bu spesifik sorgusu için ilk prompt.
+	// Bu değişken, döngüler boyunca değiştirilmeyecek.
+	initialPrompt := []genai.Part{genai.Text(userQuery)}
 
-			// Gerekirse sohbet oturumunu ve modelini değiştir.
-			if currentUserSession.ModelName != modelName {
-				log.Printf("[INFO] Model değiştiriliyor: %s -> %s", currentUserSession.ModelName, modelName)
-				
-				client, err := genai.NewClient(ctx, option.WithAPIKey(config.GeminiAPIKey))
-				if err != nil { finalErr = err; break }
-				
-				newModel := client.GenerativeModel(modelName)
-				newModel.Tools = botTools
-				if systemPrompt != "" {
-					newModel.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(systemPrompt)}}
-				}
+	for _, modelName := range llmModels() {
+		log.Printf("[DEBUG] Model denemesi başlıyor: %s", modelName)
+		bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("🧠 Düşünüyorum... (Model: %s)", modelName)))
 
-				newSession := newModel.StartChat()
-				newSession.History = currentUserSession.Session.History
-				
-				currentUserSession = &UserLlmSession{
-					Session:   newSession,
-					ModelName: modelName,
-				}
-			}
+		// Gerekirse sohbet oturumunu ve modelini değiştir.
+		if currentUserSession.ModelName != modelName {
+			log.Printf("[INFO] Model değiştiriliyor: %s -> %s", currentUserSession.ModelName, modelName)
 			
-			var resp *genai.GenerateContentResponse
-			var attemptErr error
+			client, err := genai.NewClient(ctx, option.WithAPIKey(config.GeminiAPIKey))
+			if err != nil { finalErr = err; break }
 			
-			// Bu model için Function Calling döngüsünü başlat.
-			// Her zaman en baştaki, orijinal prompt ile başla.
-			promptPartsForThisAttempt := initialPrompt
-			const maxTurns = 5
-			for i := 0; i < maxTurns; i++ {
-				log.Printf("[DEBUG] -> Model '%s' ile API çağrısı yapılıyor (Tur %d)", modelName, i+1)
-				resp, attemptErr = currentUserSession.Session.SendMessage(ctx, promptPartsForThisAttempt...)
-				if attemptErr != nil {
-					break 
-				}
+			newModel := client.GenerativeModel(modelName)
+			newModel.Tools = botTools
+			if prompt := currentSystemPrompt(); prompt != "" {
+				newModel.SystemInstruction = &genai.Content{Parts: []genai.Part{genai.Text(prompt)}}
+			}
+
+			newSession := newModel.StartChat()
+			newSession.History = currentUserSession.Session.History
+			
+			currentUserSession = &UserLlmSession{
+				Session:   newSession,
+				ModelName: modelName,
+			}
+		}
+		
+		var resp *genai.GenerateContentResponse
+		var attemptErr error
+		
+		// Bu model için Function Calling döngüsünü başlat.
+		// Her zaman en baştaki, orijinal prompt ile başla.
+		promptPartsForThisAttempt := initialPrompt
+		const maxTurns = 5
+		for i := 0; i < maxTurns; i++ {
+			log.Printf("[DEBUG] -> Model '%s' ile API çağrısı yapılıyor (Tur %d)", modelName, i+1)
+			resp, attemptErr = currentUserSession.Session.SendMessage(ctx, promptPartsForThisAttempt...)
+			if attemptErr != nil {
+				break 
+			}
 
-				if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
-					attemptErr = fmt.Errorf("modelden boş veya geçersiz içerik alındı")
+			if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+				attemptErr = fmt.Errorf("modelden boş veya geçersiz içerik alındı")
+				break
+			}
+			
+			candidate := resp.Candidates[0]
+			hasFunctionCall := false
+			for _, part := range candidate.Content.Parts {
+				if _, ok := part.(genai.FunctionCall); ok {
+					hasFunctionCall = true
 					break
 				}
-				
-				candidate := resp.Candidates[0]
-				hasFunctionCall := false
-				for _, part := range candidate.Content.Parts {
-					if _, ok := part.(genai.FunctionCall); ok {
-						hasFunctionCall = true
-						break
-					}
-				}
+			}
 
-				if hasFunctionCall {
-					log.Printf("[DEBUG] <- Model '%s' bir FunctionCall isteğiyle yanıt verdi.", modelName)
-					var nextPromptParts []genai.Part
-					for _, part := range candidate.Content.Parts {
-						if call, ok := part.(genai.FunctionCall); ok {
-							responsePart := executeTool(bot, message, &call)
-							nextPromptParts = append(nextPromptParts, responsePart)
-						}
+			if hasFunctionCall {
+				log.Printf("[DEBUG] <- Model '%s' bir FunctionCall isteğiyle yanıt verdi.", modelName)
+				var nextPromptParts []genai.Part
+				for _, part := range candidate.Content.Parts {
+					if call, ok := part.(genai.FunctionCall); ok {
+						responsePart := executeTool(bot, message, &call)
+						nextPromptParts = append(nextPromptParts, responsePart)
 					}
-					promptPartsForThisAttempt = nextPromptParts
-					continue
-				} else {
-					log.Printf("[DEBUG] <- Model '%s' nihai bir metin cevabıyla yanıt verdi.", modelName)
-					break 
 				}
+				promptPartsForThisAttempt = nextPromptParts
+				continue
+			} else {
+				log.Printf("[DEBUG] <- Model '%s' nihai bir metin cevabıyla yanıt verdi.", modelName)
+				break 
 			}
+		}
 
-			if attemptErr != nil {
-				errorText := attemptErr.Error()
-				if strings.Contains(errorText, "429") || strings.Contains(strings.ToLower(errorText), "quota") {
-					log.Printf("[UYARI] KOTA HATASI ALGILANDI: Model '%s' başarısız oldu. Bir sonraki modele geçiliyor.", modelName)
-					finalErr = attemptErr
-					continue
-				}
-				
-				log.Printf("[HATA] KRİTİK HATA: Model '%s' başarısız oldu. Hata: %v", modelName, attemptErr)
+		if attemptErr != nil {
+			errorText := attemptErr.Error()
+			if strings.Contains(errorText, "429") || strings.Contains(strings.ToLower(errorText), "quota") {
+				log.Printf("[UYARI] KOTA HATASI ALGILANDI: Model '%s' başarısız oldu. Bir sonraki modele geçiliyor.", modelName)
 				finalErr = attemptErr
-				break
+				continue
 			}
-
-			finalResponse = resp
-			finalErr = nil
-			log.Printf("[BAŞARI] Başarılı yanıt '%s' modelinden alındı.", modelName)
+			
+			log.Printf("[HATA] KRİTİK HATA: Model '%s' başarısız oldu. Hata: %v", modelName, attemptErr)
+			finalErr = attemptErr
 			break
 		}
 
-		if finalErr != nil {
-			log.Printf("[HATA] Tüm modeller denendi ve hepsi başarısız oldu. Son hata: %v", finalErr)
-			bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, "❌ Servis şu anda çok yoğun veya bir hata oluştu. Lütfen daha sonra tekrar deneyin."))
-			return
-		}
+		finalResponse = resp
+		finalErr = nil
+		log.Printf("[BAŞARI] Başarılı yanıt '%s' modelinden alındı.", modelName)
+		break
+	}
 
-		llmMutex.Lock()
-		userChatSessions[userID] = currentUserSession
-		llmMutex.Unlock()
+	if finalErr != nil {
+		log.Printf("[HATA] Tüm modeller denendi ve hepsi başarısız oldu. Son hata: %v", finalErr)
+		bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, "❌ Servis şu anda çok yoğun veya bir hata oluştu. Lütfen daha sonra tekrar deneyin."))
+		return
+	}
 
-		candidate := finalResponse.Candidates[0]
-		if txt, ok := candidate.Content.Parts[0].(genai.Text); ok {
-			sendFinalLlmResponse(bot, chatID, messageID, string(txt))
-		} else {
-			log.Printf("[HATA] Son yanıt metin değil, beklenmedik bir durum. Part: %T", candidate.Content.Parts[0])
-			bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, "✅ İşlem tamamlandı (ancak bir özet metni üretilemedi)."))
-		}
-	}() // Goroutine'i burada kapat.
+	llmMutex.Lock()
+	userChatSessions[userID] = currentUserSession
+	llmMutex.Unlock()
+
+	candidate := finalResponse.Candidates[0]
+	if txt, ok := candidate.Content.Parts[0].(genai.Text); ok {
+		sendFinalLlmResponse(bot, chatID, messageID, string(txt))
+	} else {
+		log.Printf("[HATA] Son yanıt metin değil, beklenmedik bir durum. Part: %T", candidate.Content.Parts[0])
+		bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, "✅ İşlem tamamlandı (ancak bir özet metni üretilemedi)."))
+	}
 }
 
-func handleLlmOffCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleLlmOffCommand(bot Messenger, message *tgbotapi.Message) {
 	userID := message.From.ID
 	llmMutex.Lock()
 	delete(llmActiveUsers, userID)
@@ -371,11 +379,21 @@ func handleLlmOffCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 // #                         Yardımcı Fonksiyonlar
 // #############################################################################
 
-func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.FunctionCall) genai.Part {
+func executeTool(bot Messenger, message *tgbotapi.Message, call *genai.FunctionCall) genai.Part {
 	log.Printf("[DEBUG] executeTool çağrıldı. İstenen fonksiyon: %s, Parametreler: %v", call.Name, call.Args)
 	
 	var toolResult string
 	var toolErr error
+	userID := message.From.ID
+	start := time.Now()
+	toolArgs, _ := json.Marshal(call.Args)
+
+	// * Araçlar da komutlar gibi rol tabanlı yetkiye tabidir (bkz. `auth.go`).
+	if !canUseTool(userID, call.Name) {
+		log.Printf("⚠️ YETKİSİZ ARAÇ DENEMESİ! Kullanıcı: %d, Araç: %s", userID, call.Name)
+		auditMessage(message, auditKindTool, call.Name, string(toolArgs), auditResultDenied, nil, start)
+		return buildToolResponse(call.Name, "", fmt.Errorf("kullanıcının '%s' aracını kullanma yetkisi yok", call.Name))
+	}
 
 	switch call.Name {
 	case "get_system_status":
@@ -389,33 +407,43 @@ func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.Fu
 		go handleScreenshotCommand(bot, message)
 		toolResult = "Ekran görüntüsü alma komutu başarıyla tetiklendi ve arka planda çalışıyor. Sonuç doğrudan kullanıcıya gönderilecek."
 	case "list_files":
-		toolResult, toolErr = getFileListText()
+		toolResult, toolErr = getFileListText(userID)
 	case "list_files_in_category":
 		if category, ok := call.Args["category"].(string); ok {
-			toolResult, toolErr = listFilesInCategoryInternal(category)
+			toolResult, toolErr = listFilesInCategoryInternal(userID, category)
 		} else {
 			toolErr = fmt.Errorf("kategori parametresi eksik")
 		}
 	case "search_files":
 		if keyword, ok := call.Args["keyword"].(string); ok {
-			toolResult = searchFilesText(keyword)
+			toolResult = searchFilesText(userID, keyword)
 		} else {
 			toolErr = fmt.Errorf("keyword parametresi eksik")
 		}
+	case "search_file_contents":
+		if query, ok := call.Args["query"].(string); ok {
+			toolResult, toolErr = searchContentText(userID, query)
+		} else {
+			toolErr = fmt.Errorf("query parametresi eksik")
+		}
 	case "send_file":
 		if filename, ok := call.Args["filename"].(string); ok {
-			go func() {
+			goBackground(func() {
 				bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("🔍 `%s` dosyası aranıyor...", filename)))
 				
-				filePath, found := findFile(filename)
-				if !found {
-					bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Sentinel, `%s` dosyasını bulamadı.", filename)))
+				// Ad birden fazla dosyayla eşleşirse seçim `/getir` komutuyla tamamlanır.
+				filePath, ok := resolveFileFor(bot, message, "getir", filename, "", fmt.Sprintf("❌ Sentinel, `%s` dosyasını bulamadı.", filename))
+				if !ok {
 					return
 				}
 
 				fileInfo, err := os.Stat(filePath)
-				if err != nil || fileInfo.Size() > 50*1024*1024 {
-					bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` dosyası gönderilemiyor (çok büyük veya okunamıyor).", filename)))
+				if err != nil {
+					bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` dosyası okunamıyor.", filename)))
+					return
+				}
+				if fileInfo.Size() > uploadLimit() {
+					offerSplitSend(bot, message.Chat.ID, message.From.ID, filePath, fileInfo.Size())
 					return
 				}
 
@@ -425,19 +453,19 @@ func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.Fu
 					log.Printf("[HATA] LLM aracılığıyla dosya gönderilemedi: %v", err)
 					bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` dosyası gönderilirken bir hata oluştu.", filename)))
 				}
-			}()
+			})
 			toolResult = fmt.Sprintf("`%s` adlı dosyayı gönderme işlemi başlatıldı. Dosya birazdan gelecek.", filename)
 		} else {
 			toolErr = fmt.Errorf("filename parametresi eksik")
 		}
 	case "delete_file":
 		if filename, ok := call.Args["filename"].(string); ok {
-			toolResult, toolErr = deleteFileInternal(filename)
+			toolResult, toolErr = deleteFileInternal(userID, filename)
 		} else {
 			toolErr = fmt.Errorf("filename parametresi eksik")
 		}
 	case "organize_files":
-		toolResult = organizeFilesInternal()
+		toolResult = organizeFilesInternal(bot, userID)
 	case "download_video_or_audio":
 		if url, ok := call.Args["url"].(string); ok {
 			audioOnly := false
@@ -445,7 +473,7 @@ func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.Fu
 				audioOnly = ao
 			}
 
-			go func() {
+			goBackground(func() {
 				fakeMessage := *message
 				if audioOnly {
 					fakeMessage.Text = "/indir_ses " + url
@@ -454,7 +482,7 @@ func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.Fu
 					fakeMessage.Text = "/indir " + url
 					handleDownloadCommand(bot, &fakeMessage)
 				}
-			}()
+			})
 			toolResult = fmt.Sprintf("`%s` adresinden indirme işlemi arka planda başlatıldı.", url)
 		} else {
 			toolErr = fmt.Errorf("url parametresi eksik")
@@ -470,6 +498,18 @@ func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.Fu
 		toolErr = fmt.Errorf("'%s' adında bir araç bulunamadı", call.Name)
 	}
 
+	auditResult := auditResultOK
+	if toolErr != nil {
+		auditResult = auditResultError
+	}
+	auditMessage(message, auditKindTool, call.Name, string(toolArgs), auditResult, toolErr, start)
+
+	return buildToolResponse(call.Name, toolResult, toolErr)
+}
+
+// buildToolResponse, bir aracın sonucunu Gemini'ye geri gönderilecek
+// `FunctionResponse` biçimine çevirir.
+func buildToolResponse(toolName, toolResult string, toolErr error) genai.Part {
 	var finalResult map[string]any
 	if toolErr != nil {
 		log.Printf("[HATA] Araç çalıştırılırken hata oluştu: %v", toolErr)
@@ -482,11 +522,11 @@ func executeTool(bot *tgbotapi.BotAPI, message *tgbotapi.Message, call *genai.Fu
 	log.Printf("[DEBUG] executeTool sonucu Gemini'ye gönderilmek üzere hazırlanıyor: %s", string(responseJSON))
 	
 	return &genai.FunctionResponse{
-		Name:     call.Name,
+		Name:     toolName,
 		Response: map[string]any{"response": string(responseJSON)},
 	}
 }
-func sendFinalLlmResponse(bot *tgbotapi.BotAPI, chatID int64, messageID int, responseText string) {
+func sendFinalLlmResponse(bot Messenger, chatID int64, messageID int, responseText string) {
 	log.Printf("[DEBUG] Orijinal LLM yanıtı: %s", responseText)
 	if responseText == "" {
 		responseText = "Anlaşılır bir yanıt üretemedim."
@@ -536,15 +576,23 @@ func isUserInLlmMode(userID int64) bool {
 	return llmActiveUsers[userID]
 }
 func loadSystemPrompt() error {
-	data, err := os.ReadFile("system_prompt.txt")
+	path := systemPromptFile()
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Printf("⚠️ Sistem prompt dosyası (system_prompt.txt) okunamadı. Hata: %v", err)
+		log.Printf("⚠️ Sistem prompt dosyası (%s) okunamadı. Hata: %v", path, err)
 		return err
 	}
+	llmMutex.Lock()
 	systemPrompt = string(data)
+	llmMutex.Unlock()
 	log.Println("Sistem prompt'u başarıyla yüklendi.")
 	return nil
 }
+func currentSystemPrompt() string {
+	llmMutex.Lock()
+	defer llmMutex.Unlock()
+	return systemPrompt
+}
 func splitMessageSmart(text string) []string {
 	var chunks []string
 	parts := strings.Split(text, "```")
@@ -592,30 +640,51 @@ func runSpeedTestInternal() (string, error) {
 		quality, downloadMbps, uploadMbps, ping,
 	), nil
 }
-func deleteFileInternal(filename string) (string, error) {
-	filePath, found := findFile(filename)
-	if !found {
+func deleteFileInternal(userID int64, filename string) (string, error) {
+	matches := findFileMatches(filename)
+	if len(matches) == 0 {
 		return "", fmt.Errorf("silinecek dosya bulunamadı: `%s`", filename)
 	}
-	if err := os.Remove(filePath); err != nil {
-		return "", fmt.Errorf("`%s` dosyası silinirken bir hata oluştu: %v", filename, err)
+	if len(matches) > 1 {
+		var keys []string
+		for _, path := range matches {
+			if canAccessPath(userID, path) {
+				keys = append(keys, fileKey(path))
+			}
+		}
+		return "", fmt.Errorf("`%s` adıyla birden fazla dosya var, hangisinin silineceğini kullanıcıya sorup tam yolu kullan: `%s`", filename, strings.Join(keys, "`, `"))
+	}
+	filePath := matches[0]
+	if !canAccessPath(userID, filePath) {
+		return "", fmt.Errorf("kullanıcının `%s` dosyasının kategorisine erişim yetkisi yok", filename)
 	}
-	removeDescription(filename)
-	return fmt.Sprintf("`%s` dosyası başarıyla silindi.", filename), nil
+	key := fileKey(filePath)
+	entry, err := moveToTrash(userID, filePath)
+	if err != nil {
+		return "", fmt.Errorf("`%s` dosyası silinirken bir hata oluştu: %v", key, err)
+	}
+	recordOperation(userID, operationDelete, OperationStep{From: key, TrashID: entry.ID})
+	return fmt.Sprintf("`%s` dosyası çöp kutusuna taşındı. Kullanıcı `/geri_al %d` komutuyla geri alabilir.", key, entry.ID), nil
 }
-func organizeFilesInternal() string {
-	count := organizeFiles()
+func organizeFilesInternal(bot Messenger, userID int64) string {
+	count := organizeFiles(bot, userID)
 	if count == 0 {
 		return "Taşınacak yeni dosya bulunamadığı için herhangi bir işlem yapılmadı."
 	}
 	return fmt.Sprintf("%d adet dosya başarıyla kategorilere ayrıldı.", count)
 }
-func listFilesInCategoryInternal(category string) (string, error) {
-	categoryDir := filepath.Join(config.BaseDir, category)
-	files, err := os.ReadDir(categoryDir)
+func listFilesInCategoryInternal(userID int64, category string) (string, error) {
+	if !canAccessCategory(userID, category) {
+		return "", fmt.Errorf("kullanıcının `%s` kategorisine erişim yetkisi yok", category)
+	}
+	categoryDir, err := resolveInBase(category)
+	var files []os.DirEntry
+	if err == nil {
+		files, err = os.ReadDir(categoryDir)
+	}
 	if err != nil {
 		var cats []string
-		for k := range kategoriler {
+		for k := range fileCategories() {
 			cats = append(cats, k)
 		}
 		sort.Strings(cats)
@@ -632,19 +701,22 @@ func listFilesInCategoryInternal(category string) (string, error) {
 	}
 	return fmt.Sprintf("`%s` kategorisinde %d dosya bulundu:\n- %s", category, len(fileNames), strings.Join(fileNames, "\n- ")), nil
 }
-func getFileListText() (string, error) {
+func getFileListText(userID int64) (string, error) {
+	if !canAccessCategory(userID, rootCategoryName) {
+		return "", fmt.Errorf("kullanıcının ana klasöre erişim yetkisi yok")
+	}
 	files, err := os.ReadDir(config.BaseDir)
 	if err != nil { return "", fmt.Errorf("ana klasördeki dosyalar okunurken bir hata oluştu") }
 	var fileNames []string
-	for _, file := range files { if !file.IsDir() { fileNames = append(fileNames, file.Name()) } }
+	for _, file := range files { if !file.IsDir() && !isIncomingTemp(file.Name()) { fileNames = append(fileNames, file.Name()) } }
 	if len(fileNames) == 0 { return "Ana klasör boş.", nil }
 	return fmt.Sprintf("Ana klasörde %d dosya bulundu:\n- %s", len(fileNames), strings.Join(fileNames, "\n- ")), nil
 }
-func searchFilesText(keyword string) string {
+func searchFilesText(userID int64, keyword string) string {
 	var foundFiles []string
 	filepath.Walk(config.BaseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil { return nil }
-		if !info.IsDir() && strings.Contains(strings.ToLower(info.Name()), strings.ToLower(keyword)) {
+		if !info.IsDir() && !isIncomingTemp(info.Name()) && strings.Contains(strings.ToLower(info.Name()), strings.ToLower(keyword)) && canAccessPath(userID, path) {
 			relPath, _ := filepath.Rel(config.BaseDir, path)
 			foundFiles = append(foundFiles, relPath)
 		}
@@ -656,10 +728,10 @@ func searchFilesText(keyword string) string {
 func startApplicationInternal(shortcutName string) (string, error) {
 	appName := strings.ToLower(shortcutName)
 
-	appPath, found := config.Uygulamalar[appName]
+	appPath, found := applications()[appName]
 	if !found {
 		var availableApps []string
-		for name := range config.Uygulamalar {
+		for name := range applications() {
 			availableApps = append(availableApps, name)
 		}
 		sort.Strings(availableApps)
@@ -5,8 +5,8 @@ import (
 	"io"
 	"log"
 	"os"
-
-	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
@@ -20,7 +20,7 @@ func main() {
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
 	// ASCII Art banner
-	fmt.Println(`
+	fmt.Print(`
 ██████ ███████ ███    ██ ████████ ██ ███    ██ ███████ ██     
 ██      ██      ████   ██    ██    ██ ████   ██ ██      ██     
 ███████ █████   ██ ██  ██    ██    ██ ██ ██  ██ █████   ██     
@@ -35,16 +35,21 @@ func main() {
 
 	log.Println("Uygulama başlatılıyor...")
 
-	// .env dosyasından yapılandırmayı yükle.
+	// .env ve config.yaml dosyalarından yapılandırmayı yükle.
 	if err := loadConfig(); err != nil {
 		log.Fatalf("Yapılandırma yüklenemedi: %v", err)
 	}
 
-	// metadata.json dosyasından dosya açıklamalarını yükle.
+	// Dosya açıklamalarının deposunu aç (gerekirse metadata.json içeri aktarılır).
 	if err := loadMetadata(); err != nil {
 		log.Fatalf("Metadata yüklenemedi: %v", err)
 	}
 
+	// users.json dosyasından kullanıcıları ve rolleri yükle.
+	if err := loadUsers(); err != nil {
+		log.Fatalf("Kullanıcılar yüklenemedi: %v", err)
+	}
+
 	// system_prompt.txt dosyasından Gemini AI için sistem talimatını yükle.
 	if err := loadSystemPrompt(); err != nil {
 		// Fonksiyon zaten kendi içinde bir uyarı logu basıyor.
@@ -55,24 +60,55 @@ func main() {
 	ensureDirectories()
 
 	// Telegram Bot API ile bağlantı kur.
-	bot, err := tgbotapi.NewBotAPI(config.BotToken)
+	api, err := newBotAPI()
 	if err != nil {
 		log.Panic(err)
 	}
-	bot.Debug = false
-	log.Printf("%s botu olarak yetkilendirildi.", bot.Self.UserName)
+	api.Debug = false
+	log.Printf("%s botu olarak yetkilendirildi.", api.Self.UserName)
+	if config.BotAPIURL != "" {
+		log.Printf("Bot API sunucusu: %s (yerel kip: %t, dosya limiti: %s)", config.BotAPIURL, usingLocalBotAPI(), formatFileSize(uploadLimit()))
+	}
+
+	// İşleyiciler somut istemci yerine `Messenger` arayüzü üzerinden çalışır.
+	bot := newTelegramMessenger(api)
 
-	// Arka planda çalışacak görevleri ayrı goroutine'lerde başlat.
-	go runScheduler(bot) // Saatlik görevler ve dosya izleyiciyi başlatır.
-	go startWorkers(bot) // Port ve internet izleyici worker'larını başlatır.
+	// Komut kaydındaki komutları Telegram'ın "/" menüsüne yükle.
+	registerBotMenu(bot)
+
+	// Önceki çalışmadan kalan gönderilmemiş bildirimleri kuyruğa geri al.
+	loadPendingNotifications()
+
+	// Arka planda çalışacak görevleri ayrı goroutine'lerde başlat. Hepsi
+	// kapanışta `lifecycleCtx` ile durdurulur (bkz. `lifecycle.go`).
+	goBackground(func() { runScheduler(lifecycleCtx, bot) })    // Saatlik görevler ve dosya izleyiciyi başlatır.
+	startWorkers(lifecycleCtx, bot)                             // Port ve internet izleyici worker'larını başlatır.
+	goBackground(func() { watchConfigFile(lifecycleCtx, bot) }) // config.yaml değişince yeniden yükler.
+	goBackground(func() { runContentIndexer(lifecycleCtx) })    // Belge içeriklerini indeksler (bkz. `content_index.go`).
+
+	// Telegram'dan güncellemeleri (mesajlar, vb.) almaya başla. Yapılandırmaya
+	// göre uzun yoklama veya webhook kullanılır (bkz. `update_source.go`).
+	updates, stopUpdates, err := startUpdateSource(api)
+	if err != nil {
+		log.Fatalf("Güncelleme kaynağı başlatılamadı: %v", err)
+	}
 
-	// Telegram'dan güncellemeleri (mesajlar, vb.) almaya başla.
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := bot.GetUpdatesChan(u)
+	// Ctrl+C veya sonlandırma sinyalinde kapanışı başlat: güncelleme kaynağı
+	// kapanır (webhook modunda Telegram'daki kayıt da silinir), devam eden
+	// işler `SHUTDOWN_TIMEOUT` süresince beklenir.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("%v sinyali alındı, bot kapatılıyor...", sig)
+		beginShutdown(bot, sig.String(), stopUpdates)
+	}()
 
 	log.Println("Bot güncellemeleri dinlemeye başladı...")
 
-	// Gelen güncellemeleri sonsuz bir döngüde işle.
-	handleUpdates(bot, updates)
+	// Gelen güncellemeleri kapanış başlayana kadar işle. Fonksiyon, kuyruktaki
+	// işler bittiğinde döner.
+	handleUpdates(lifecycleCtx, bot, updates)
+	finishShutdown(bot)
+	log.Println("Bot durduruldu.")
 }
@@ -0,0 +1,145 @@
+// messenger.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                             MESAJLAŞMA KATMANI
+// #############################################################################
+// Bu dosya, işleyicilerin (handler) Telegram ile konuşurken kullandığı küçük
+// arayüzü tanımlar. İşleyiciler somut `*tgbotapi.BotAPI` yerine `Messenger`
+// aldığı için, canlı bir Telegram bağlantısı olmadan da (örneğin
+// `messenger_fake.go` içindeki sahte istemcilerle) çalıştırılabilirler.
+//
+// `BOT_API_URL` ayarlanırsa istemci api.telegram.org yerine kendi
+// sunucunuzda çalışan Telegram Bot API sunucusuna bağlanır. Sunucu `--local`
+// kipinde çalışıyorsa (`BOT_API_LOCAL=true`) dosyalar HTTP ile indirilmez,
+// sunucunun kendi diskindeki yolundan doğrudan okunur ve dosya boyutu
+// limitleri 2 GB'a çıkar.
+
+// Bot API dosya boyutu limitleri.
+const (
+	telegramUploadLimit   = 50 * 1024 * 1024   // Genel sunucuyla gönderilebilecek en büyük dosya.
+	telegramDownloadLimit = 20 * 1024 * 1024   // Genel sunucudan indirilebilecek en büyük dosya.
+	localBotAPIFileLimit  = 2000 * 1024 * 1024 // Yerel sunucuda gönderme ve indirme limiti.
+)
+
+// localFilePrefix, yerel kipte `GetFileDirectURL` tarafından döndürülen
+// disk yollarını HTTP adreslerinden ayırır (bkz. `openTelegramFile`).
+const localFilePrefix = "file://"
+
+// Messenger, botun Telegram'a mesaj göndermek, API isteği yapmak ve
+// dosya indirme adresini öğrenmek için ihtiyaç duyduğu tüm işlemleri içerir.
+type Messenger interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetFileDirectURL(fileID string) (string, error)
+}
+
+// telegramMessenger, gerçek Bot API istemcisini `Messenger` arayüzüne uyarlar.
+// `fileEndpoint`, dosya indirme adreslerinin hangi sunucudan üretileceğini
+// belirler; kütüphane bunu sabit olarak api.telegram.org'a bağladığı için
+// farklı bir sunucuyla konuşurken bu alan değiştirilir.
+type telegramMessenger struct {
+	*tgbotapi.BotAPI
+	fileEndpoint string
+	local        bool // Yerel kipteki Bot API sunucusu: dosya yolları disk yoludur.
+}
+
+// newBotAPI, yapılandırmaya göre genel Telegram sunucusuna veya
+// `BOT_API_URL` ile belirtilen sunucuya bağlanan istemciyi oluşturur.
+func newBotAPI() (*tgbotapi.BotAPI, error) {
+	if config.BotAPIURL == "" {
+		return tgbotapi.NewBotAPI(config.BotToken)
+	}
+	return tgbotapi.NewBotAPIWithAPIEndpoint(config.BotToken, config.BotAPIURL+"/bot%s/%s")
+}
+
+// newTelegramMessenger, istemciyi yapılandırılan dosya sunucusunu kullanan
+// bir `telegramMessenger` ile sarar.
+func newTelegramMessenger(api *tgbotapi.BotAPI) *telegramMessenger {
+	if config.BotAPIURL == "" {
+		return &telegramMessenger{BotAPI: api, fileEndpoint: tgbotapi.FileEndpoint}
+	}
+	return &telegramMessenger{BotAPI: api, fileEndpoint: config.BotAPIURL + "/file/bot%s/%s", local: config.BotAPILocal}
+}
+
+// GetFileDirectURL, dosya kimliğinden indirme adresini `fileEndpoint`
+// şablonuna göre üretir. Yerel kipte sunucu dosyanın mutlak yolunu döndürür;
+// bu durumda `localFilePrefix` ile başlayan disk yolu döner.
+func (m *telegramMessenger) GetFileDirectURL(fileID string) (string, error) {
+	file, err := m.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return "", err
+	}
+	if m.local && (strings.HasPrefix(file.FilePath, "/") || filepath.IsAbs(file.FilePath)) {
+		return localFilePrefix + localBotAPIPath(file.FilePath), nil
+	}
+	return fmt.Sprintf(m.fileEndpoint, m.Token, file.FilePath), nil
+}
+
+// localBotAPIPath, yerel sunucunun bildirdiği dosya yolunu botun gördüğü
+// yola çevirir. Sunucu başka bir makinede veya kapsayıcıda çalışıyorsa
+// `BOT_API_SERVER_DIR` öneki `BOT_API_LOCAL_DIR` ile değiştirilir.
+func localBotAPIPath(serverPath string) string {
+	if config.BotAPIServerDir != "" && config.BotAPILocalDir != "" {
+		prefix := strings.TrimRight(config.BotAPIServerDir, "/\\")
+		if rest, ok := strings.CutPrefix(serverPath, prefix); ok && (rest == "" || rest[0] == '/' || rest[0] == '\\') {
+			return filepath.Join(config.BotAPILocalDir, filepath.FromSlash(rest))
+		}
+	}
+	return filepath.FromSlash(serverPath)
+}
+
+// openTelegramFile, Telegram'daki bir dosyayı okumak için açar. Yerel kipte
+// dosya doğrudan diskten, aksi halde HTTP ile okunur.
+func openTelegramFile(bot Messenger, fileID string) (io.ReadCloser, error) {
+	fileURL, err := bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if path, ok := strings.CutPrefix(fileURL, localFilePrefix); ok {
+		return os.Open(path)
+	}
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("sunucu hatası: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// usingLocalBotAPI, botun yerel kipteki bir Bot API sunucusuna bağlı olup
+// olmadığını bildirir.
+func usingLocalBotAPI() bool {
+	return config.BotAPIURL != "" && config.BotAPILocal
+}
+
+// uploadLimit, Telegram'a tek parça olarak gönderilebilecek en büyük dosya
+// boyutudur.
+func uploadLimit() int64 {
+	if usingLocalBotAPI() {
+		return localBotAPIFileLimit
+	}
+	return telegramUploadLimit
+}
+
+// downloadLimit, botun Telegram'dan indirebileceği en büyük dosya boyutudur.
+func downloadLimit() int64 {
+	if usingLocalBotAPI() {
+		return localBotAPIFileLimit
+	}
+	return telegramDownloadLimit
+}
@@ -0,0 +1,236 @@
+// messenger_fake_test.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                         SAHTE TELEGRAM İSTEMCİLERİ
+// #############################################################################
+// Bu dosya, işleyicileri canlı Telegram olmadan çalıştırmak için iki yardımcı
+// sunar:
+//   - `recordingMessenger`: Hiç ağ kullanmadan gönderilen her şeyi kaydeden,
+//     tablo tabanlı testler için en hafif sahte istemci.
+//   - `fakeBotAPIServer`: Bot API'yi taklit eden yerel bir HTTP sunucusu.
+//     Gerçek `tgbotapi.BotAPI` istemcisini (ve dosya indirmelerini) uçtan uca
+//     denemek gerektiğinde kullanılır.
+
+// recordingMessenger, `Messenger` arayüzünü uygulayan ve yapılan tüm
+// çağrıları bellekte tutan sahte bir istemcidir.
+type recordingMessenger struct {
+	mu            sync.Mutex
+	Sent          []tgbotapi.Chattable
+	Requests      []tgbotapi.Chattable
+	FileURLs      map[string]string // dosya kimliği -> indirme adresi
+	SendErr       error             // ayarlanırsa her Send/Request bu hatayı döndürür
+	nextMessageID int
+}
+
+// newRecordingMessenger, boş bir `recordingMessenger` oluşturur.
+func newRecordingMessenger() *recordingMessenger {
+	return &recordingMessenger{FileURLs: make(map[string]string)}
+}
+
+// Send, gönderilen nesneyi kaydeder ve artan bir mesaj kimliği döndürür.
+func (m *recordingMessenger) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Sent = append(m.Sent, c)
+	if m.SendErr != nil {
+		return tgbotapi.Message{}, m.SendErr
+	}
+	m.nextMessageID++
+	return tgbotapi.Message{MessageID: m.nextMessageID, Date: int(time.Now().Unix())}, nil
+}
+
+// Request, isteği kaydeder ve başarılı bir API cevabı döndürür.
+func (m *recordingMessenger) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Requests = append(m.Requests, c)
+	if m.SendErr != nil {
+		return nil, m.SendErr
+	}
+	return &tgbotapi.APIResponse{Ok: true, Result: json.RawMessage("true")}, nil
+}
+
+// GetFileDirectURL, `FileURLs` haritasına önceden eklenmiş adresi döndürür.
+func (m *recordingMessenger) GetFileDirectURL(fileID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if u, ok := m.FileURLs[fileID]; ok {
+		return u, nil
+	}
+	return "", fmt.Errorf("bilinmeyen dosya kimliği: %s", fileID)
+}
+
+// Texts, gönderilen mesajların ve düzenlemelerin metinlerini (dosyalar için
+// açıklamalarını) gönderim sırasıyla döndürür.
+func (m *recordingMessenger) Texts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var texts []string
+	for _, c := range append(append([]tgbotapi.Chattable{}, m.Sent...), m.Requests...) {
+		switch v := c.(type) {
+		case tgbotapi.MessageConfig:
+			texts = append(texts, v.Text)
+		case tgbotapi.EditMessageTextConfig:
+			texts = append(texts, v.Text)
+		case tgbotapi.DocumentConfig:
+			texts = append(texts, v.Caption)
+		case tgbotapi.PhotoConfig:
+			texts = append(texts, v.Caption)
+		case tgbotapi.VideoConfig:
+			texts = append(texts, v.Caption)
+		}
+	}
+	return texts
+}
+
+// Reset, kaydedilmiş tüm çağrıları temizler.
+func (m *recordingMessenger) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Sent = nil
+	m.Requests = nil
+	m.nextMessageID = 0
+}
+
+// fakeBotAPICall, sahte sunucuya yapılan tek bir Bot API çağrısını temsil eder.
+type fakeBotAPICall struct {
+	Method string
+	Params url.Values
+}
+
+// fakeBotAPIServer, Bot API'nin ihtiyaç duyduğumuz küçük bir alt kümesini
+// taklit eden yerel HTTP sunucusudur. `/bot<token>/<metot>` isteklerine
+// sahte cevaplar verir, `/file/bot<token>/<yol>` altından da `AddFile` ile
+// eklenen dosyaları sunar.
+type fakeBotAPIServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	calls     []fakeBotAPICall
+	files     map[string]string // dosya kimliği -> dosya yolu
+	contents  map[string][]byte // dosya yolu -> içerik
+	messageID int
+}
+
+// newFakeBotAPIServer, sahte Bot API sunucusunu başlatır. İş bitince
+// `Close` çağrılmalıdır.
+func newFakeBotAPIServer() *fakeBotAPIServer {
+	s := &fakeBotAPIServer{
+		files:    make(map[string]string),
+		contents: make(map[string][]byte),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Messenger, bu sunucuya bağlanan gerçek bir Bot API istemcisi döndürür.
+func (s *fakeBotAPIServer) Messenger() (*telegramMessenger, error) {
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("123456:TEST", s.URL+"/bot%s/%s")
+	if err != nil {
+		return nil, err
+	}
+	return &telegramMessenger{BotAPI: api, fileEndpoint: s.URL + "/file/bot%s/%s"}, nil
+}
+
+// AddFile, `getFile` ile sorgulanabilecek ve indirilebilecek bir dosya ekler.
+func (s *fakeBotAPIServer) AddFile(fileID, filePath string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileID] = filePath
+	s.contents[filePath] = data
+}
+
+// Calls, belirtilen metoda yapılan çağrıları döndürür. Metot boşsa tüm
+// çağrılar döner.
+func (s *fakeBotAPIServer) Calls(method string) []fakeBotAPICall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []fakeBotAPICall
+	for _, call := range s.calls {
+		if method == "" || call.Method == method {
+			result = append(result, call)
+		}
+	}
+	return result
+}
+
+func (s *fakeBotAPIServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+
+	if strings.HasPrefix(path, "file/") {
+		parts := strings.SplitN(path, "/", 3)
+		s.mu.Lock()
+		data, ok := s.contents[parts[len(parts)-1]]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+		return
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	method := parts[1]
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		r.ParseMultipartForm(32 << 20)
+	} else {
+		r.ParseForm()
+	}
+	params := r.Form
+
+	s.mu.Lock()
+	s.calls = append(s.calls, fakeBotAPICall{Method: method, Params: params})
+	var result any
+	var failure string
+	switch method {
+	case "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, FirstName: "Sentinel", UserName: "sentinel_test_bot"}
+	case "getFile":
+		if filePath, ok := s.files[params.Get("file_id")]; ok {
+			result = tgbotapi.File{FileID: params.Get("file_id"), FilePath: filePath}
+		} else {
+			failure = "Bad Request: invalid file_id"
+		}
+	case "sendMessage", "sendDocument", "sendPhoto", "sendVideo", "sendAnimation", "sendAudio", "editMessageText":
+		s.messageID++
+		chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+		result = tgbotapi.Message{
+			MessageID: s.messageID,
+			Date:      int(time.Now().Unix()),
+			Chat:      &tgbotapi.Chat{ID: chatID},
+			Text:      params.Get("text"),
+		}
+	default:
+		result = true
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if failure != "" {
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 400, "description": failure})
+		return
+	}
+	raw, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": json.RawMessage(raw)})
+}
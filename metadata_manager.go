@@ -2,12 +2,10 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
+	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -15,114 +13,353 @@ import (
 // #                             METADATA YÖNETİCİSİ
 // #############################################################################
 // Bu dosya, dosyalara eklenen açıklamaların (metadata) yönetilmesinden
-// sorumludur. Tüm açıklamalar bellekte bir haritada (map) tutulur ve
-// program her kapandığında veya bir değişiklik olduğunda `metadata.json`
-// adlı dosyaya kalıcı olarak kaydedilir.
+// sorumludur. Açıklamalar `metadataStore` üzerinden okunur ve yazılır;
+// verinin nasıl saklandığı `metadata_store.go` içinde tanımlanır.
 
 // FileMetadata, her bir dosya için saklanacak olan verileri tanımlayan yapıdır.
 // `json:"..."` etiketleri, bu yapının JSON formatına nasıl çevrileceğini belirtir.
 type FileMetadata struct {
-	Description string `json:"description"`
-	Updated     string `json:"updated"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Updated     string   `json:"updated"`
+
+	// Dosyanın nereden geldiği; düzenleme kuralları tarafından kullanılır
+	// (bkz. `organizer_rules.go`).
+	Source string `json:"source,omitempty"` // "telegram" veya "indir"
+	Sender int64  `json:"sender,omitempty"` // dosyayı gönderen/indiren kullanıcı
 }
 
-// * Bu global değişkenler, tüm metadata işlemlerinin merkezidir.
-var (
-	// fileMetadata, dosya adlarını (string) FileMetadata yapılarına eşleyen
-	// ve tüm açıklamaları bellekte tutan ana haritadır.
-	fileMetadata map[string]FileMetadata
+// empty, kayıtta saklanmaya değer bir bilgi kalmadıysa true döner.
+func (m FileMetadata) empty() bool {
+	return m.Description == "" && len(m.Tags) == 0 && m.Source == ""
+}
 
-	// metadataMutex, `fileMetadata` haritasına aynı anda birden fazla yerden
-	// (örneğin iki farklı komutla) yazılmasını veya okunmasını engelleyen
-	// bir kilittir. Bu, "race condition" hatalarını önler.
-	metadataMutex = &sync.Mutex{}
-)
+// metadataStore, tüm metadata işlemlerinin merkezidir. Program başlangıcında
+// `loadMetadata` ile açılır, kapanışta `closeMetadata` ile kapatılır.
+// Arka uçlar eş zamanlı erişime karşı kendi kilitlerini kullanır.
+var metadataStore MetadataStore
 
-// loadMetadata, program başlangıcında çalışarak `metadata.json` dosyasını
-// okur ve içeriğini `fileMetadata` haritasına yükler.
+// loadMetadata, program başlangıcında yapılandırılan metadata deposunu açar.
 func loadMetadata() error {
-	metadataMutex.Lock()
-	defer metadataMutex.Unlock()
-
-	fileMetadata = make(map[string]FileMetadata)
-	// * Program ilk kez çalışıyorsa veya dosya silinmişse, `metadata.json`
-	// * dosyası mevcut olmayabilir. Bu durumda, boş bir dosya oluşturulur.
-	if _, err := os.Stat(config.MetadataFilePath); os.IsNotExist(err) {
-		log.Println("metadata.json bulunamadı, boş olarak oluşturuluyor.")
-		return saveMetadata() // Boş dosyayı kaydet ve çık.
-	}
-
-	data, err := os.ReadFile(config.MetadataFilePath)
+	store, err := openMetadataStore()
 	if err != nil {
-		return fmt.Errorf("metadata dosyası okunamadı: %w", err)
+		return err
 	}
-	if len(data) == 0 {
-		return nil // Boş dosya bir hata değildir.
-	}
-
-	log.Println("Metadata başarıyla yüklendi.")
-	// * Okunan JSON verisini `fileMetadata` haritasına "unmarshal" et (çözümle).
-	return json.Unmarshal(data, &fileMetadata)
+	metadataStore = store
+	log.Printf("Metadata başarıyla yüklendi (%s, %d açıklama).", config.MetadataBackend, countDescriptions())
+	return nil
 }
 
-// saveMetadata, bellekteki `fileMetadata` haritasının güncel halini
-// `metadata.json` dosyasına, okunabilir bir formatta yazar.
-func saveMetadata() error {
-	// * `json.MarshalIndent`, JSON verisini girintili (insan tarafından okunabilir)
-	// * bir şekilde formatlar.
-	data, err := json.MarshalIndent(fileMetadata, "", "  ")
-	if err != nil {
-		return fmt.Errorf("metadata JSON'a çevrilemedi: %w", err)
+// closeMetadata, metadata deposunu kapatır. Bolt arka ucunda bu, dosya
+// kilidini bırakır.
+func closeMetadata() error {
+	if metadataStore == nil {
+		return nil
 	}
-	// * `os.WriteFile`, veriyi belirtilen dosyaya yazar. `0644` dosya izinleridir.
-	return os.WriteFile(config.MetadataFilePath, data, 0644)
+	return metadataStore.Close()
 }
 
 // addDescription, bir dosyaya yeni bir açıklama ekler veya mevcut olanı günceller.
+// Dosyanın etiketleri korunur.
 func addDescription(filename, description string) error {
-	metadataMutex.Lock()
-	defer metadataMutex.Unlock()
-
-	fileMetadata[filename] = FileMetadata{
-		Description: description,
-		Updated:     time.Now().Format(time.RFC3339),
-	}
-	return saveMetadata()
+	return metadataStore.Update(func(tx MetadataTx) error {
+		meta, _, err := tx.Get(filename)
+		if err != nil {
+			return err
+		}
+		meta.Description = description
+		meta.Updated = time.Now().Format(time.RFC3339)
+		return tx.Put(filename, meta)
+	})
 }
 
 // getDescription, bir dosyanın açıklamasını döndürür.
 func getDescription(filename string) (string, bool) {
-	metadataMutex.Lock()
-	defer metadataMutex.Unlock()
-	meta, found := fileMetadata[filename]
+	var meta FileMetadata
+	var found bool
+	err := metadataStore.View(func(tx MetadataTx) error {
+		var err error
+		meta, found, err = tx.Get(filename)
+		return err
+	})
+	if err != nil {
+		log.Printf("Açıklama okunamadı (%s): %v", filename, err)
+		return "", false
+	}
 	return meta.Description, found
 }
 
-// removeDescription, bir dosyanın açıklamasını siler.
+// removeDescription, bir dosyanın açıklamasını siler. Dosyanın etiketleri
+// varsa kayıt etiketlerle birlikte korunur.
 func removeDescription(filename string) error {
-	metadataMutex.Lock()
-	defer metadataMutex.Unlock()
+	return metadataStore.Update(func(tx MetadataTx) error {
+		meta, found, err := tx.Get(filename)
+		if err != nil {
+			return err
+		} else if !found || meta.Description == "" {
+			return fmt.Errorf("açıklama bulunamadı: %s", filename)
+		}
+		meta.Description = ""
+		if !meta.empty() {
+			meta.Updated = time.Now().Format(time.RFC3339)
+			return tx.Put(filename, meta)
+		}
+		return tx.Delete(filename)
+	})
+}
+
+// removeFileMetadata, silinen bir dosyanın açıklamasını ve etiketlerini siler.
+func removeFileMetadata(filename string) error {
+	return metadataStore.Update(func(tx MetadataTx) error {
+		return tx.Delete(filename)
+	})
+}
 
-	if _, found := fileMetadata[filename]; found {
-		delete(fileMetadata, filename)
-		return saveMetadata()
+// takeFileMetadata, bir dosyanın açıklama ve etiket kaydını siler ve silinen
+// kaydı döndürür. Kayıt yoksa `nil` döner. Çöp kutusu, kaydı dosya geri
+// alındığında `restoreFileMetadata` ile geri yüklemek için kullanır.
+func takeFileMetadata(filename string) (*FileMetadata, error) {
+	var taken *FileMetadata
+	err := metadataStore.Update(func(tx MetadataTx) error {
+		meta, found, err := tx.Get(filename)
+		if err != nil || !found {
+			return err
+		}
+		taken = &meta
+		return tx.Delete(filename)
+	})
+	return taken, err
+}
+
+// recordFileOrigin, bota gönderilen veya indirilen bir dosyanın kaynağını
+// ve gönderen kullanıcıyı kaydeder. Dosyanın açıklaması ve etiketleri korunur.
+func recordFileOrigin(filename, source string, sender int64) error {
+	return metadataStore.Update(func(tx MetadataTx) error {
+		meta, _, err := tx.Get(filename)
+		if err != nil {
+			return err
+		}
+		meta.Source, meta.Sender = source, sender
+		meta.Updated = time.Now().Format(time.RFC3339)
+		return tx.Put(filename, meta)
+	})
+}
+
+// getFileMetadata, bir dosyanın kaydını döndürür. Kayıt yoksa boş döner.
+func getFileMetadata(filename string) FileMetadata {
+	var meta FileMetadata
+	err := metadataStore.View(func(tx MetadataTx) error {
+		var err error
+		meta, _, err = tx.Get(filename)
+		return err
+	})
+	if err != nil {
+		log.Printf("Dosya kaydı okunamadı (%s): %v", filename, err)
 	}
-	return fmt.Errorf("açıklama bulunamadı: %s", filename)
+	return meta
+}
+
+// restoreFileMetadata, daha önce alınan bir kaydı geri yazar.
+func restoreFileMetadata(filename string, meta FileMetadata) error {
+	return metadataStore.Update(func(tx MetadataTx) error {
+		return tx.Put(filename, meta)
+	})
+}
+
+// mergeFileMetadata, başka dosyalardan gelen kayıtları bir dosyanın kaydıyla
+// birleştirir: farklı açıklamalar ` | ` ile eklenir, etiketler birleştirilir,
+// kaynak bilgisi yalnızca dosyada yoksa alınır. Kopyaları temizlerken silinen
+// kopyaların notlarının kaybolmaması için kullanılır.
+func mergeFileMetadata(filename string, others []FileMetadata) error {
+	return metadataStore.Update(func(tx MetadataTx) error {
+		meta, _, err := tx.Get(filename)
+		if err != nil {
+			return err
+		}
+		descriptions := []string{}
+		if meta.Description != "" {
+			descriptions = append(descriptions, meta.Description)
+		}
+		for _, other := range others {
+			if other.Description != "" && !containsString(descriptions, other.Description) {
+				descriptions = append(descriptions, other.Description)
+			}
+			for _, tag := range other.Tags {
+				if !containsString(meta.Tags, tag) {
+					meta.Tags = append(meta.Tags, tag)
+				}
+			}
+			if meta.Source == "" {
+				meta.Source, meta.Sender = other.Source, other.Sender
+			}
+		}
+		meta.Description = strings.Join(descriptions, " | ")
+		sort.Strings(meta.Tags)
+		if meta.empty() {
+			return nil
+		}
+		meta.Updated = time.Now().Format(time.RFC3339)
+		return tx.Put(filename, meta)
+	})
+}
+
+// moveFileMetadata, bir dosyanın açıklamasını ve etiketlerini tek bir işlemde
+// yeni anahtara taşır. Kayıt yoksa hiçbir şey yapmaz.
+func moveFileMetadata(oldName, newName string) error {
+	return metadataStore.Update(func(tx MetadataTx) error {
+		meta, found, err := tx.Get(oldName)
+		if err != nil || !found {
+			return err
+		}
+		if err := tx.Put(newName, meta); err != nil {
+			return err
+		}
+		return tx.Delete(oldName)
+	})
+}
+
+// listDescriptions, kayıtlı tüm açıklamaları dosya adı -> açıklama olarak döndürür.
+func listDescriptions() map[string]string {
+	results := make(map[string]string)
+	err := metadataStore.View(func(tx MetadataTx) error {
+		return tx.ForEach(func(filename string, meta FileMetadata) error {
+			if meta.Description != "" {
+				results[filename] = meta.Description
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Printf("Açıklamalar listelenemedi: %v", err)
+	}
+	return results
+}
+
+// countDescriptions, kayıtlı açıklama sayısını döndürür. Yalnızca etiket
+// veya kaynak bilgisi tutan kayıtlar sayılmaz.
+func countDescriptions() int {
+	count := 0
+	metadataStore.View(func(tx MetadataTx) error {
+		return tx.ForEach(func(filename string, meta FileMetadata) error {
+			if meta.Description != "" {
+				count++
+			}
+			return nil
+		})
+	})
+	return count
 }
 
 // searchDescriptions, verilen anahtar kelimeyi hem dosya adlarında hem de
 // açıklamalarda (büyük/küçük harf duyarsız) arar.
 func searchDescriptions(keyword string) map[string]string {
-	metadataMutex.Lock()
-	defer metadataMutex.Unlock()
-
 	results := make(map[string]string)
 	keywordLower := strings.ToLower(keyword)
 
-	for filename, meta := range fileMetadata {
-		if strings.Contains(strings.ToLower(filename), keywordLower) || strings.Contains(strings.ToLower(meta.Description), keywordLower) {
-			results[filename] = meta.Description
+	err := metadataStore.View(func(tx MetadataTx) error {
+		return tx.ForEach(func(filename string, meta FileMetadata) error {
+			if meta.Description == "" {
+				return nil
+			}
+			if strings.Contains(strings.ToLower(filename), keywordLower) || strings.Contains(strings.ToLower(meta.Description), keywordLower) {
+				results[filename] = meta.Description
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Printf("Açıklamalarda arama yapılamadı: %v", err)
+	}
+	return results
+}
+
+// getTags, bir dosyanın etiketlerini döndürür.
+func getTags(filename string) []string {
+	var tags []string
+	err := metadataStore.View(func(tx MetadataTx) error {
+		meta, _, err := tx.Get(filename)
+		tags = meta.Tags
+		return err
+	})
+	if err != nil {
+		log.Printf("Etiketler okunamadı (%s): %v", filename, err)
+	}
+	return tags
+}
+
+// addTags, bir dosyaya etiketler ekler ve dosyanın güncel etiketlerini
+// döndürür. Zaten var olan etiketler tekrar eklenmez.
+func addTags(filename string, tags []string) ([]string, error) {
+	var result []string
+	err := metadataStore.Update(func(tx MetadataTx) error {
+		meta, _, err := tx.Get(filename)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if !containsString(meta.Tags, tag) {
+				meta.Tags = append(meta.Tags, tag)
+			}
+		}
+		sort.Strings(meta.Tags)
+		meta.Updated = time.Now().Format(time.RFC3339)
+		result = meta.Tags
+		return tx.Put(filename, meta)
+	})
+	return result, err
+}
+
+// removeTags, bir dosyadan etiketleri kaldırır ve kalan etiketleri döndürür.
+// Açıklaması ve etiketi kalmayan kayıtlar silinir.
+func removeTags(filename string, tags []string) ([]string, error) {
+	var result []string
+	err := metadataStore.Update(func(tx MetadataTx) error {
+		meta, found, err := tx.Get(filename)
+		if err != nil {
+			return err
+		} else if !found || len(meta.Tags) == 0 {
+			return fmt.Errorf("dosyanın etiketi yok: %s", filename)
+		}
+		var kept []string
+		for _, tag := range meta.Tags {
+			if !containsString(tags, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		meta.Tags = kept
+		result = kept
+		if meta.empty() {
+			return tx.Delete(filename)
 		}
+		meta.Updated = time.Now().Format(time.RFC3339)
+		return tx.Put(filename, meta)
+	})
+	return result, err
+}
+
+// listFileTags, etiketi olan tüm dosyaları göreli yol -> etiketler olarak döndürür.
+func listFileTags() map[string][]string {
+	results := make(map[string][]string)
+	err := metadataStore.View(func(tx MetadataTx) error {
+		return tx.ForEach(func(filename string, meta FileMetadata) error {
+			if len(meta.Tags) > 0 {
+				results[filename] = meta.Tags
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Printf("Etiketler listelenemedi: %v", err)
 	}
 	return results
-}
\ No newline at end of file
+}
+
+// containsString, bir dilimde verilen değerin olup olmadığını kontrol eder.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+// metadata_store.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// #############################################################################
+// #                           METADATA DEPOLAMA KATMANI
+// #############################################################################
+// Bu dosya, dosya açıklamalarının kalıcı olarak nerede ve nasıl saklanacağını
+// soyutlayan `MetadataStore` arayüzünü içerir. İki arka uç vardır:
+//   - bolt (varsayılan): Gömülü, işlemsel (transactional) bir anahtar-değer
+//     veritabanı (`metadata.db`). Her değişiklik yalnızca ilgili kaydı yazar
+//     ve çökme durumunda veri bozulmaz.
+//   - json: Eski `metadata.json` biçimi. Her değişiklikte dosya yeniden
+//     yazılır, ancak artık geçici dosya + yeniden adlandırma ile atomiktir.
+// Arka uç `.env` içindeki `METADATA_BACKEND` ile seçilir. Bolt ilk kez
+// açıldığında mevcut `metadata.json` bir kez içeri aktarılır.
+
+// MetadataTx, tek bir işlem (transaction) içindeki metadata okuma/yazma
+// işlemlerini tanımlar. `Update` içindeki tüm değişiklikler ya birlikte
+// uygulanır ya da hiçbiri uygulanmaz.
+type MetadataTx interface {
+	Get(key string) (FileMetadata, bool, error)
+	Put(key string, meta FileMetadata) error
+	Delete(key string) error
+	ForEach(fn func(key string, meta FileMetadata) error) error
+	Count() int
+}
+
+// MetadataStore, açıklamaların saklandığı arka ucu temsil eder.
+type MetadataStore interface {
+	View(fn func(tx MetadataTx) error) error
+	Update(fn func(tx MetadataTx) error) error
+	Close() error
+}
+
+// Desteklenen metadata arka uçları.
+const (
+	metadataBackendBolt = "bolt"
+	metadataBackendJSON = "json"
+)
+
+// openMetadataStore, yapılandırmadaki arka ucu açar.
+func openMetadataStore() (MetadataStore, error) {
+	switch config.MetadataBackend {
+	case metadataBackendJSON:
+		return openJSONMetadataStore(config.MetadataFilePath)
+	case metadataBackendBolt, "":
+		store, err := openBoltMetadataStore(config.MetadataDBPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := importLegacyMetadata(store, config.MetadataFilePath); err != nil {
+			store.Close()
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("bilinmeyen METADATA_BACKEND: %s", config.MetadataBackend)
+	}
+}
+
+// importLegacyMetadata, eski `metadata.json` dosyasını tek bir işlemde
+// veritabanına aktarır ve dosyayı `.imported` uzantısıyla yedekler. Böylece
+// aktarım yalnızca bir kez yapılır; yedek silinmediği sürece veri kaybolmaz.
+func importLegacyMetadata(store MetadataStore, jsonPath string) error {
+	data, err := os.ReadFile(jsonPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("eski metadata dosyası okunamadı: %w", err)
+	}
+
+	legacy := make(map[string]FileMetadata)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return fmt.Errorf("eski metadata dosyası çözümlenemedi: %w", err)
+		}
+	}
+
+	imported := 0
+	err = store.Update(func(tx MetadataTx) error {
+		for key, meta := range legacy {
+			// * Eski dosya adı anahtarları göreli yollara çevrilir (bkz. `file_identity.go`).
+			key = legacyMetadataKey(key)
+			// * Veritabanında daha yeni bir kayıt varsa üzerine yazılmaz.
+			if _, exists, err := tx.Get(key); err != nil {
+				return err
+			} else if exists {
+				continue
+			}
+			if err := tx.Put(key, meta); err != nil {
+				return err
+			}
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("eski metadata içeri aktarılamadı: %w", err)
+	}
+
+	if err := os.Rename(jsonPath, jsonPath+".imported"); err != nil {
+		return fmt.Errorf("eski metadata dosyası yedeklenemedi: %w", err)
+	}
+	log.Printf("%s içinden %d açıklama veritabanına aktarıldı (yedek: %s.imported).", jsonPath, imported, jsonPath)
+	return nil
+}
+
+// #############################################################################
+// #                               JSON ARKA UCU
+// #############################################################################
+
+// jsonMetadataStore, tüm açıklamaları bellekte tutar ve her değişiklikte
+// dosyayı atomik olarak yeniden yazar.
+type jsonMetadataStore struct {
+	path string
+	mu   sync.RWMutex
+	data map[string]FileMetadata
+}
+
+// jsonMetadataTx, bir harita üzerinde çalışan işlemdir. `Update` sırasında
+// haritanın bir kopyası kullanılır; hata olursa kopya atılır.
+type jsonMetadataTx struct {
+	data     map[string]FileMetadata
+	writable bool
+}
+
+func openJSONMetadataStore(path string) (*jsonMetadataStore, error) {
+	store := &jsonMetadataStore{path: path, data: make(map[string]FileMetadata)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Printf("%s bulunamadı, boş olarak oluşturuluyor.", path)
+		return store, store.write(store.data)
+	} else if err != nil {
+		return nil, fmt.Errorf("metadata dosyası okunamadı: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.data); err != nil {
+			return nil, fmt.Errorf("metadata dosyası çözümlenemedi: %w", err)
+		}
+	}
+	// * Yalnızca dosya adıyla tutulan eski kayıtlar göreli yollara taşınır.
+	err = store.Update(func(tx MetadataTx) error {
+		count, err := rekeyLegacyMetadata(tx)
+		if count > 0 {
+			log.Printf("%d açıklama göreli dosya yollarına taşındı.", count)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eski açıklama anahtarları dönüştürülemedi: %w", err)
+	}
+	return store, nil
+}
+
+func (s *jsonMetadataStore) View(fn func(tx MetadataTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&jsonMetadataTx{data: s.data})
+}
+
+func (s *jsonMetadataStore) Update(fn func(tx MetadataTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	working := make(map[string]FileMetadata, len(s.data))
+	for k, v := range s.data {
+		working[k] = v
+	}
+	if err := fn(&jsonMetadataTx{data: working, writable: true}); err != nil {
+		return err
+	}
+	if err := s.write(working); err != nil {
+		return err
+	}
+	s.data = working
+	return nil
+}
+
+func (s *jsonMetadataStore) Close() error { return nil }
+
+// write, haritayı önce geçici bir dosyaya yazar, ardından asıl dosyanın
+// yerine koyar. Yazma yarıda kesilirse eski dosya bozulmadan kalır.
+func (s *jsonMetadataStore) write(data map[string]FileMetadata) error {
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("metadata JSON'a çevrilemedi: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (tx *jsonMetadataTx) Get(key string) (FileMetadata, bool, error) {
+	meta, ok := tx.data[key]
+	return meta, ok, nil
+}
+
+func (tx *jsonMetadataTx) Put(key string, meta FileMetadata) error {
+	if !tx.writable {
+		return fmt.Errorf("salt okunur işlemde yazma yapılamaz")
+	}
+	tx.data[key] = meta
+	return nil
+}
+
+func (tx *jsonMetadataTx) Delete(key string) error {
+	if !tx.writable {
+		return fmt.Errorf("salt okunur işlemde silme yapılamaz")
+	}
+	delete(tx.data, key)
+	return nil
+}
+
+func (tx *jsonMetadataTx) ForEach(fn func(key string, meta FileMetadata) error) error {
+	keys := make([]string, 0, len(tx.data))
+	for k := range tx.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(k, tx.data[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *jsonMetadataTx) Count() int { return len(tx.data) }
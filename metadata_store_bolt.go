@@ -0,0 +1,169 @@
+// metadata_store_bolt.go
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// #############################################################################
+// #                               BOLT ARKA UCU
+// #############################################################################
+// Açıklamalar `files` kovasında (bucket) göreli dosya yolu -> JSON kaydı olarak
+// tutulur. `meta` kovası şema sürümünü saklar. Şema değiştiğinde
+// `metadataMigrations` listesine yeni bir adım eklenir; veritabanı açılırken
+// eksik adımlar sırayla ve her biri kendi işleminde uygulanır.
+
+var (
+	boltFilesBucket = []byte("files")
+	boltMetaBucket  = []byte("meta")
+	boltSchemaKey   = []byte("schema_version")
+)
+
+// metadataMigration, şemayı bir sonraki sürüme taşıyan adımdır.
+type metadataMigration struct {
+	description string
+	apply       func(tx *bolt.Tx) error
+}
+
+// metadataMigrations, sırayla uygulanan şema adımlarıdır. i. eleman şemayı
+// i sürümünden i+1 sürümüne taşır. Mevcut adımlar değiştirilmemelidir.
+var metadataMigrations = []metadataMigration{
+	{
+		description: "files kovasını oluştur",
+		apply: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(boltFilesBucket)
+			return err
+		},
+	},
+	{
+		description: "dosya adı anahtarlarını göreli yollara taşı",
+		apply: func(tx *bolt.Tx) error {
+			_, err := rekeyLegacyMetadata(&boltMetadataTx{bucket: tx.Bucket(boltFilesBucket)})
+			return err
+		},
+	},
+}
+
+// boltMetadataStore, bbolt veritabanı üzerinde çalışan `MetadataStore`'dur.
+type boltMetadataStore struct {
+	db *bolt.DB
+}
+
+// boltMetadataTx, bir bolt işlemi içindeki `files` kovasını sarmalar.
+type boltMetadataTx struct {
+	bucket *bolt.Bucket
+}
+
+// openBoltMetadataStore, veritabanını açar ve bekleyen şema adımlarını uygular.
+func openBoltMetadataStore(path string) (*boltMetadataStore, error) {
+	// * Veritabanı dosyası kilitlenir; bot iki kez çalıştırılırsa ikincisi
+	// * sonsuza kadar beklemek yerine hata verir.
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("metadata veritabanı açılamadı (%s): %w", path, err)
+	}
+	if err := migrateMetadataDB(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltMetadataStore{db: db}, nil
+}
+
+// migrateMetadataDB, veritabanının şema sürümünü okur ve eksik adımları uygular.
+func migrateMetadataDB(db *bolt.DB) error {
+	var version uint64
+	err := db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+		if err != nil {
+			return err
+		}
+		if v := meta.Get(boltSchemaKey); len(v) == 8 {
+			version = binary.BigEndian.Uint64(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("şema sürümü okunamadı: %w", err)
+	}
+	if version > uint64(len(metadataMigrations)) {
+		return fmt.Errorf("metadata veritabanı bu sürümden daha yeni (şema %d, desteklenen %d)", version, len(metadataMigrations))
+	}
+
+	for i := version; i < uint64(len(metadataMigrations)); i++ {
+		migration := metadataMigrations[i]
+		err := db.Update(func(tx *bolt.Tx) error {
+			if err := migration.apply(tx); err != nil {
+				return err
+			}
+			buf := make([]byte, 8)
+			binary.BigEndian.PutUint64(buf, i+1)
+			return tx.Bucket(boltMetaBucket).Put(boltSchemaKey, buf)
+		})
+		if err != nil {
+			return fmt.Errorf("şema adımı %d (%s) uygulanamadı: %w", i+1, migration.description, err)
+		}
+		log.Printf("Metadata şeması %d sürümüne yükseltildi: %s", i+1, migration.description)
+	}
+	return nil
+}
+
+func (s *boltMetadataStore) View(fn func(tx MetadataTx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltMetadataTx{bucket: tx.Bucket(boltFilesBucket)})
+	})
+}
+
+func (s *boltMetadataStore) Update(fn func(tx MetadataTx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltMetadataTx{bucket: tx.Bucket(boltFilesBucket)})
+	})
+}
+
+func (s *boltMetadataStore) Close() error {
+	return s.db.Close()
+}
+
+func (tx *boltMetadataTx) Get(key string) (FileMetadata, bool, error) {
+	var meta FileMetadata
+	raw := tx.bucket.Get([]byte(key))
+	if raw == nil {
+		return meta, false, nil
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return meta, false, fmt.Errorf("`%s` kaydı çözümlenemedi: %w", key, err)
+	}
+	return meta, true, nil
+}
+
+func (tx *boltMetadataTx) Put(key string, meta FileMetadata) error {
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return tx.bucket.Put([]byte(key), raw)
+}
+
+func (tx *boltMetadataTx) Delete(key string) error {
+	return tx.bucket.Delete([]byte(key))
+}
+
+func (tx *boltMetadataTx) ForEach(fn func(key string, meta FileMetadata) error) error {
+	return tx.bucket.ForEach(func(k, v []byte) error {
+		var meta FileMetadata
+		if err := json.Unmarshal(v, &meta); err != nil {
+			log.Printf("Bozuk metadata kaydı atlanıyor: %s (%v)", k, err)
+			return nil
+		}
+		return fn(string(k), meta)
+	})
+}
+
+func (tx *boltMetadataTx) Count() int {
+	return tx.bucket.Stats().KeyN
+}
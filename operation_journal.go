@@ -0,0 +1,570 @@
+// operation_journal.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                              İŞLEM GÜNLÜĞÜ
+// #############################################################################
+// Bu dosya, botun dosya sisteminde yaptığı değişiklikleri (otomatik düzenleme,
+// `/tasi`, `/yenidenadlandir`, çöp kutusuna taşınan dosyalar ve mevcut bir
+// dosyanın yerine kaydedilen gelen dosyalar) bir işlem günlüğüne yazar ve
+// bunların geri alınmasını sağlar. Tek bir düzenleme birden fazla dosyayı
+// taşıyabildiği için her işlem bir veya daha fazla adımdan oluşur ve tek
+// seferde geri alınır.
+//
+// Günlük `operations.json` dosyasında tutulur ve yalnızca son
+// `maxJournalOperations` işlem saklanır. Geri alınan işlemler silinmez,
+// kimin ve ne zaman geri aldığıyla birlikte işaretlenir.
+
+// İşlem türleri.
+const (
+	operationOrganize = "duzenle"
+	operationMove     = "tasi"
+	operationRename   = "yenidenadlandir"
+	operationDelete   = "sil"
+	operationDedupe   = "kopyalar"
+	operationReplace  = "degistir"
+)
+
+// maxJournalOperations, günlükte saklanacak en fazla işlem sayısıdır.
+const maxJournalOperations = 1000
+
+// systemUserID, zamanlayıcı gibi bir kullanıcıya bağlı olmayan işlemlerde
+// kullanılan kullanıcı kimliğidir.
+const systemUserID int64 = 0
+
+// OperationStep, bir işlemdeki tek bir dosya değişikliğidir. Silme adımlarında
+// `To` boştur ve dosya `TrashID` ile çöp kutusundan geri alınır. Taşıma
+// hedefinde bulunan dosya sürüm olarak saklandıysa `Version` sürümün yoludur;
+// adım geri alınınca sürüm yeniden yerine konur. `Replaced` adımlarında gelen
+// bir dosya `From` yolundaki (taşıma adımlarında `To` yolundaki) dosyanın
+// yerine geçmiştir; eski dosya `TrashID` ile çöp kutusunda veya `Version` ile
+// sürümlerde bekler.
+type OperationStep struct {
+	From     string `json:"from"` // Ana klasöre göre eski yol
+	To       string `json:"to,omitempty"`
+	TrashID  int    `json:"trash_id,omitempty"`
+	Version  string `json:"version,omitempty"` // Sürüm klasörüne göre yol
+	Replaced bool   `json:"replaced,omitempty"`
+	Undone   bool   `json:"undone,omitempty"`
+}
+
+// Operation, işlem günlüğündeki tek bir kaydı temsil eder.
+type Operation struct {
+	ID       int             `json:"id"`
+	Kind     string          `json:"kind"`
+	UserID   int64           `json:"user_id"`
+	Time     string          `json:"time"` // RFC3339
+	Steps    []OperationStep `json:"steps"`
+	UndoneBy int64           `json:"undone_by,omitempty"`
+	UndoneAt string          `json:"undone_at,omitempty"`
+}
+
+// undone, işlemin tüm adımları geri alındıysa true döner.
+func (op *Operation) undone() bool {
+	for _, step := range op.Steps {
+		if !step.Undone {
+			return false
+		}
+	}
+	return true
+}
+
+// operationJournal, `operations.json` dosyasının yapısıdır.
+type operationJournal struct {
+	NextID     int         `json:"next_id"`
+	Operations []Operation `json:"operations"`
+}
+
+// journalMutex, işlem günlüğü dosyasına erişimi korur. Geri alma sırasında
+// dosya işlemleri bu kilit tutulmadan yapılır; aksi halde çöp kutusu kilidiyle
+// birlikte kilitlenme (deadlock) oluşabilir.
+var journalMutex = &sync.Mutex{}
+
+// loadJournalLocked, günlüğü okur. Çağıran `journalMutex` kilidini tutmalıdır.
+func loadJournalLocked() (*operationJournal, error) {
+	journal := &operationJournal{NextID: 1}
+	data, err := os.ReadFile(config.JournalPath)
+	if os.IsNotExist(err) {
+		return journal, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("işlem günlüğü okunamadı: %w", err)
+	}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("işlem günlüğü çözümlenemedi: %w", err)
+	}
+	if journal.NextID < 1 {
+		journal.NextID = 1
+	}
+	return journal, nil
+}
+
+// saveJournalLocked, günlüğü atomik olarak yazar. Çağıran `journalMutex`
+// kilidini tutmalıdır.
+func saveJournalLocked(journal *operationJournal) error {
+	if len(journal.Operations) > maxJournalOperations {
+		journal.Operations = journal.Operations[len(journal.Operations)-maxJournalOperations:]
+	}
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := config.JournalPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, config.JournalPath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// recordOperation, tamamlanmış bir dosya işlemini günlüğe ekler. Yazma hatası
+// işlemi geri almaz, yalnızca loglanır.
+func recordOperation(userID int64, kind string, steps ...OperationStep) {
+	if len(steps) == 0 || config.JournalPath == "" {
+		return
+	}
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	journal, err := loadJournalLocked()
+	if err != nil {
+		log.Printf("İşlem günlüğe yazılamadı: %v", err)
+		return
+	}
+	journal.Operations = append(journal.Operations, Operation{
+		ID:     journal.NextID,
+		Kind:   kind,
+		UserID: userID,
+		Time:   time.Now().Format(time.RFC3339),
+		Steps:  steps,
+	})
+	journal.NextID++
+	if err := saveJournalLocked(journal); err != nil {
+		log.Printf("İşlem günlüğe yazılamadı: %v", err)
+	}
+}
+
+// recordMove, tek dosyalık bir taşıma veya yeniden adlandırma işlemini kaydeder.
+func recordMove(userID int64, kind, fromPath, toPath string) {
+	recordOperation(userID, kind, OperationStep{From: fileKey(fromPath), To: fileKey(toPath)})
+}
+
+// markTrashRestored, `/geri_al` ile çöp kutusundan geri alınan dosyanın silme
+// adımını geri alınmış olarak işaretler.
+func markTrashRestored(userID int64, trashID int) {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	journal, err := loadJournalLocked()
+	if err != nil {
+		log.Printf("İşlem günlüğü güncellenemedi: %v", err)
+		return
+	}
+	for i := len(journal.Operations) - 1; i >= 0; i-- {
+		op := &journal.Operations[i]
+		for j := range op.Steps {
+			if op.Steps[j].TrashID == trashID && !op.Steps[j].Undone {
+				op.Steps[j].Undone = true
+				if op.undone() {
+					op.UndoneBy, op.UndoneAt = userID, time.Now().Format(time.RFC3339)
+				}
+				if err := saveJournalLocked(journal); err != nil {
+					log.Printf("İşlem günlüğü güncellenemedi: %v", err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// canSeeOperation, kullanıcının işlemi görüp geri alabileceğini belirler.
+// Yöneticiler tüm işlemleri, diğer kullanıcılar kendi işlemlerini ve
+// zamanlayıcının yaptığı otomatik işlemleri görür.
+func canSeeOperation(userID int64, op *Operation) bool {
+	return op.UserID == userID || op.UserID == systemUserID || isUserAdmin(userID)
+}
+
+// stepAccessible, kullanıcının adımdaki her iki konuma da erişebildiğini kontrol eder.
+func stepAccessible(userID int64, step OperationStep) bool {
+	for _, key := range []string{step.From, step.To} {
+		if key == "" {
+			continue
+		}
+		path, ok := pathOfKey(key)
+		if !ok || !canAccessPath(userID, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// undoStep, tek bir adımı geri alır. Taşınan dosya yeni yerinde yoksa veya
+// eski yerinde başka bir dosya varsa hiçbir şeyin üzerine yazılmaz.
+func undoStep(userID int64, step OperationStep) error {
+	if step.Replaced && step.To == "" {
+		return undoReplace(userID, step)
+	}
+	if step.To == "" {
+		if _, err := restoreFromTrash(userID, step.TrashID); err != nil {
+			return fmt.Errorf("`%s`: %v", step.From, err)
+		}
+		return nil
+	}
+	fromPath, okFrom := pathOfKey(step.From)
+	toPath, okTo := pathOfKey(step.To)
+	if !okFrom || !okTo {
+		return fmt.Errorf("`%s`: geçersiz yol", step.From)
+	}
+	if _, err := os.Stat(toPath); err != nil {
+		return fmt.Errorf("`%s` artık yerinde değil", step.To)
+	}
+	if _, err := os.Stat(fromPath); err == nil {
+		return fmt.Errorf("`%s` yolunda zaten bir dosya var", step.From)
+	}
+	if err := os.MkdirAll(filepath.Dir(fromPath), os.ModePerm); err != nil {
+		return fmt.Errorf("`%s`: klasör oluşturulamadı: %v", step.From, err)
+	}
+	if err := os.Rename(toPath, fromPath); err != nil {
+		return fmt.Errorf("`%s` geri taşınamadı: %v", step.To, err)
+	}
+	if err := moveFileMetadata(step.To, step.From); err != nil {
+		log.Printf("Açıklama eski konumuna taşınamadı: %v", err)
+	}
+	// Taşıma sırasında sürüm olarak saklanan dosya yerine konur; sürüm
+	// geçmişi o dosyada kalır. Aksi halde sürümler dosyayla birlikte döner.
+	if step.Version != "" {
+		if err := restoreVersion(step.Version, toPath); err != nil {
+			return fmt.Errorf("`%s`: önceki dosya sürümlerden geri konamadı: %v", step.To, err)
+		}
+	} else if step.Replaced {
+		if _, err := restoreFromTrash(userID, step.TrashID); err != nil {
+			return fmt.Errorf("`%s`: önceki dosya çöp kutusundan geri alınamadı: %v", step.To, err)
+		}
+	} else if err := moveFileVersions(step.To, step.From); err != nil {
+		log.Printf("Sürümler eski konumuna taşınamadı: %v", err)
+	}
+	return nil
+}
+
+// undoReplace, gelen dosyanın yerine geçtiği eski dosyayı geri koyar. Yeni
+// dosya kaybolmaz: eskisi nasıl saklandıysa (çöp kutusu veya sürüm) o da
+// öyle saklanır. Eski dosya geri konamazsa yeni dosya yerine döner.
+func undoReplace(userID int64, step OperationStep) error {
+	path, ok := pathOfKey(step.From)
+	if !ok {
+		return fmt.Errorf("`%s`: geçersiz yol", step.From)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("`%s` artık yerinde değil", step.From)
+	}
+	if step.Version != "" {
+		current, err := archiveVersion(path)
+		if err != nil {
+			return fmt.Errorf("`%s`: %v", step.From, err)
+		}
+		if err := restoreVersion(step.Version, path); err != nil {
+			if rerr := restoreVersion(versionKeyOf(current), path); rerr != nil {
+				log.Printf("Yeni dosya yerine geri konamadı (%s): %v", step.From, rerr)
+			}
+			return fmt.Errorf("`%s`: önceki dosya sürümlerden geri konamadı: %v", step.From, err)
+		}
+		return nil
+	}
+	current, err := moveToTrash(userID, path)
+	if err != nil {
+		return fmt.Errorf("`%s`: %v", step.From, err)
+	}
+	if _, err := restoreFromTrash(userID, step.TrashID); err != nil {
+		if _, rerr := restoreFromTrash(userID, current.ID); rerr != nil {
+			log.Printf("Yeni dosya yerine geri konamadı (%s): %v", step.From, rerr)
+		}
+		return fmt.Errorf("`%s`: %v", step.From, err)
+	}
+	return nil
+}
+
+// undoResult, geri alınan bir işlemin özetidir.
+type undoResult struct {
+	Op       Operation
+	Restored int
+	Errors   []string
+}
+
+// undoLastOperations, kullanıcının geri alabileceği son `n` işlemi yeniden
+// eskiye doğru geri alır. Bir işlemin bazı adımları başarısız olursa bu
+// adımlar bekler ve işlem bir sonraki denemede yeniden seçilebilir.
+func undoLastOperations(userID int64, n int) ([]undoResult, error) {
+	journalMutex.Lock()
+	journal, err := loadJournalLocked()
+	journalMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Operation
+	for i := len(journal.Operations) - 1; i >= 0 && len(pending) < n; i-- {
+		op := journal.Operations[i]
+		if !canSeeOperation(userID, &op) {
+			continue
+		}
+		for _, step := range op.Steps {
+			if !step.Undone && stepAccessible(userID, step) {
+				pending = append(pending, op)
+				break
+			}
+		}
+	}
+
+	var results []undoResult
+	for _, op := range pending {
+		result := undoResult{Op: op}
+		// * Adımlar ters sırada geri alınır; düzenleme sırasında ad çakışması
+		// * nedeniyle yeniden adlandırılan dosyalar böylece doğru yere döner.
+		for i := len(op.Steps) - 1; i >= 0; i-- {
+			step := op.Steps[i]
+			if step.Undone || !stepAccessible(userID, step) {
+				continue
+			}
+			if err := undoStep(userID, step); err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				continue
+			}
+			result.Op.Steps[i].Undone = true
+			result.Restored++
+		}
+		results = append(results, result)
+	}
+
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+	journal, err = loadJournalLocked()
+	if err != nil {
+		return results, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	for _, result := range results {
+		for i := range journal.Operations {
+			op := &journal.Operations[i]
+			if op.ID != result.Op.ID {
+				continue
+			}
+			for j := range op.Steps {
+				if j < len(result.Op.Steps) && result.Op.Steps[j].Undone {
+					op.Steps[j].Undone = true
+				}
+			}
+			if op.undone() {
+				op.UndoneBy, op.UndoneAt = userID, now
+			}
+		}
+	}
+	return results, saveJournalLocked(journal)
+}
+
+// recentOperations, kullanıcının görebildiği son `limit` işlemi yeniden
+// eskiye doğru döndürür. `filterUser` sıfırdan farklıysa yalnızca o
+// kullanıcının işlemleri döner. İşlemlerde yalnızca kullanıcının erişebildiği
+// adımlar bulunur.
+func recentOperations(userID, filterUser int64, limit int) ([]Operation, error) {
+	journalMutex.Lock()
+	journal, err := loadJournalLocked()
+	journalMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	for i := len(journal.Operations) - 1; i >= 0 && len(ops) < limit; i-- {
+		op := journal.Operations[i]
+		if !canSeeOperation(userID, &op) || (filterUser != 0 && op.UserID != filterUser) {
+			continue
+		}
+		// * Erişilemeyen kategorilerdeki dosya adları listede gösterilmez.
+		var steps []OperationStep
+		for _, step := range op.Steps {
+			if stepAccessible(userID, step) {
+				steps = append(steps, step)
+			}
+		}
+		if len(steps) == 0 {
+			continue
+		}
+		op.Steps = steps
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// operationTitle, işlem türünün okunabilir adını döndürür.
+func operationTitle(op Operation) string {
+	switch op.Kind {
+	case operationOrganize:
+		return fmt.Sprintf("🗂️ Düzenleme (%d dosya)", len(op.Steps))
+	case operationMove:
+		return "📦 Taşıma"
+	case operationRename:
+		return "✏️ Yeniden adlandırma"
+	case operationDelete:
+		return "🗑️ Silme"
+	case operationDedupe:
+		return fmt.Sprintf("♻️ Kopya temizleme (%d dosya)", len(op.Steps))
+	case operationReplace:
+		return "📥 Gelen dosyayla değiştirme"
+	}
+	return op.Kind
+}
+
+// operationActor, işlemi yapan kullanıcıyı okunabilir biçimde döndürür.
+func operationActor(userID int64) string {
+	if userID == systemUserID {
+		return "⏰ otomatik"
+	}
+	if account, _ := lookupAccount(userID); account != nil && account.Name != "" {
+		return fmt.Sprintf("%s (%d)", account.Name, userID)
+	}
+	return strconv.FormatInt(userID, 10)
+}
+
+// formatOperationStep, bir adımı tek satır olarak yazar.
+func formatOperationStep(step OperationStep) string {
+	mark := ""
+	if step.Undone {
+		mark = " ↩️"
+	}
+	if step.Replaced && step.To == "" {
+		if step.Version != "" {
+			return fmt.Sprintf("   `%s` yerine yenisi kaydedildi (önceki dosya sürüm olarak saklandı)%s\n", step.From, mark)
+		}
+		return fmt.Sprintf("   `%s` yerine yenisi kaydedildi (önceki dosya çöp kutusunda, 🆔 %d)%s\n", step.From, step.TrashID, mark)
+	}
+	if step.To == "" {
+		return fmt.Sprintf("   `%s` → çöp kutusu (🆔 %d)%s\n", step.From, step.TrashID, mark)
+	}
+	if step.Version != "" {
+		mark = " (önceki dosya sürüm olarak saklandı)" + mark
+	} else if step.Replaced {
+		mark = fmt.Sprintf(" (önceki dosya çöp kutusunda, 🆔 %d)", step.TrashID) + mark
+	}
+	return fmt.Sprintf("   `%s` → `%s`%s\n", step.From, step.To, mark)
+}
+
+// #############################################################################
+// #                           İŞLEM GÜNLÜĞÜ KOMUTLARI
+// #############################################################################
+
+// operationListLimit, /islemler komutunda gösterilecek en fazla işlem sayısıdır.
+const operationListLimit = 20
+
+// operationStepsShown, listede işlem başına gösterilecek en fazla adım sayısıdır.
+const operationStepsShown = 5
+
+// handleOperationsCommand, /islemler komutuyla son dosya işlemlerini listeler.
+// Yöneticiler bir kullanıcı kimliği vererek listeyi süzebilir.
+func handleOperationsCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+	var filterUser int64
+	if args := strings.TrimSpace(message.CommandArguments()); args != "" {
+		id, err := parseUserID(args)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/islemler [kullanıcı_id]`"))
+			return
+		}
+		if id != userID && !isUserAdmin(userID) {
+			bot.Send(tgbotapi.NewMessage(chatID, "🚫 Başka kullanıcıların işlemlerini yalnızca yöneticiler görebilir."))
+			return
+		}
+		filterUser = id
+	}
+
+	ops, err := recentOperations(userID, filterUser, operationListLimit)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	if len(ops) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "📜 Kayıtlı dosya işlemi yok."))
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString("📜 Son Dosya İşlemleri\n\n")
+	for _, op := range ops {
+		status := ""
+		if op.undone() {
+			status = fmt.Sprintf(" · ↩️ geri alındı (%s)", formatStoredTime(op.UndoneAt))
+		}
+		builder.WriteString(fmt.Sprintf("#%d %s · %s · 👤 %s%s\n", op.ID, operationTitle(op),
+			formatStoredTime(op.Time), operationActor(op.UserID), status))
+		for i, step := range op.Steps {
+			if i == operationStepsShown {
+				builder.WriteString(fmt.Sprintf("   … ve %d dosya daha\n", len(op.Steps)-operationStepsShown))
+				break
+			}
+			builder.WriteString(formatOperationStep(step))
+		}
+	}
+	builder.WriteString("\n💡 Son işlemleri geri almak için: `/geri_al_son [sayı]`")
+	for _, chunk := range splitMessageSmart(builder.String()) {
+		bot.Send(tgbotapi.NewMessage(chatID, chunk))
+	}
+}
+
+// maxUndoCount, /geri_al_son ile tek seferde geri alınabilecek en fazla işlem sayısıdır.
+const maxUndoCount = 20
+
+// handleUndoCommand, /geri_al_son [n] komutuyla son n işlemi geri alır.
+func handleUndoCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	n := 1
+	if args := strings.TrimSpace(message.CommandArguments()); args != "" {
+		value, err := strconv.Atoi(args)
+		if err != nil || value < 1 || value > maxUndoCount {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Kullanım: `/geri_al_son [sayı]` (1-%d)", maxUndoCount)))
+			return
+		}
+		n = value
+	}
+
+	results, err := undoLastOperations(message.From.ID, n)
+	if err != nil {
+		log.Printf("İşlem günlüğü güncellenemedi: %v", err)
+	}
+	if len(results) == 0 {
+		text := "ℹ️ Geri alınacak işlem bulunamadı."
+		if err != nil {
+			text = fmt.Sprintf("❌ %v", err)
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString("↩️ Geri Alınan İşlemler\n\n")
+	for _, result := range results {
+		builder.WriteString(fmt.Sprintf("#%d %s · %s: %d dosya geri alındı\n", result.Op.ID,
+			operationTitle(result.Op), formatStoredTime(result.Op.Time), result.Restored))
+		for _, e := range result.Errors {
+			builder.WriteString("   ⚠️ " + e + "\n")
+		}
+		log.Printf("İşlem geri alındı: #%d (%s) - %d dosya - Geri alan: %d", result.Op.ID, result.Op.Kind, result.Restored, message.From.ID)
+	}
+	for _, chunk := range splitMessageSmart(builder.String()) {
+		bot.Send(tgbotapi.NewMessage(chatID, chunk))
+	}
+}
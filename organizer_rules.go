@@ -0,0 +1,682 @@
+// organizer_rules.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                            DÜZENLEME KURALLARI
+// #############################################################################
+// Bu dosya, `organizeFiles` fonksiyonunun uzantı -> kategori eşlemesinin
+// ötesine geçmesini sağlar. `config.yaml` içindeki `rules` listesinde
+// tanımlanan kurallar dosya adı (glob veya regex), boyut, içerikten
+// algılanan MIME türü, kaynak (Telegram'dan yükleme veya `/indir`), gönderen
+// ve yaşa göre eşleşir; eşleşen dosya bir klasör şablonuna taşınabilir,
+// yeniden adlandırılabilir, etiketlenebilir veya açıklanabilir.
+//
+// Kurallar sırayla denenir ve ilk eşleşen kural uygulanır. Ana klasördeki
+// hiçbir kurala uymayan dosyalar eskisi gibi uzantısının kategorisine
+// taşınır. Alt klasörlerdeki dosyalar yalnızca `match.folder` ile o klasörü
+// hedefleyen kurallar tarafından düzenlenir.
+
+// Dosya kaynakları (bkz. `FileMetadata.Source`).
+const (
+	sourceTelegram = "telegram"
+	sourceDownload = "indir"
+	sourceLocal    = "yerel" // kaynağı kaydedilmemiş, klasöre elle konmuş dosyalar
+)
+
+// OrganizeRuleConfig, `config.yaml` içindeki tek bir düzenleme kuralıdır.
+type OrganizeRuleConfig struct {
+	Name  string `yaml:"name"`
+	Match struct {
+		Name      string        `yaml:"name"`   // dosya adı için glob (örn. IMG_*.jpg)
+		Regex     string        `yaml:"regex"`  // dosya adı için düzenli ifade
+		Folder    string        `yaml:"folder"` // dosyanın bulunduğu klasör (glob); boşsa ana klasör
+		MIME      string        `yaml:"mime"`   // içerikten algılanan tür (örn. image/*)
+		MinSize   string        `yaml:"min_size"`
+		MaxSize   string        `yaml:"max_size"`
+		Source    string        `yaml:"source"` // telegram, indir veya yerel
+		Senders   []int64       `yaml:"senders"`
+		OlderThan time.Duration `yaml:"older_than"`
+		NewerThan time.Duration `yaml:"newer_than"`
+	} `yaml:"match"`
+	Action struct {
+		Move     string   `yaml:"move"`   // klasör şablonu (örn. Resimler/{yyyy}/{mm})
+		Rename   string   `yaml:"rename"` // ad şablonu (örn. {yyyy}-{mm}-{dd}_{name}{ext})
+		Tags     []string `yaml:"tags"`
+		Describe string   `yaml:"describe"`
+	} `yaml:"action"`
+}
+
+// organizeRule, doğrulanmış ve derlenmiş bir düzenleme kuralıdır.
+type organizeRule struct {
+	OrganizeRuleConfig
+	regex   *regexp.Regexp
+	minSize int64
+	maxSize int64
+	tags    []string
+}
+
+// ruleTemplatePattern, şablonlardaki `{yer_tutucu}` ifadelerini bulur.
+var ruleTemplatePattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// ruleTemplateFields, şablonlarda kullanılabilen yer tutuculardır.
+var ruleTemplateFields = map[string]bool{
+	"yyyy": true, "yy": true, "mm": true, "dd": true,
+	"name": true, "ext": true, "category": true, "source": true, "sender": true,
+}
+
+// parseByteSize, `500KB`, `10MB` veya `2GB` biçimindeki boyutu bayta çevirir.
+func parseByteSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		value  int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.value
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("geçersiz boyut: %s (örn. 500KB, 10MB)", raw)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// checkRuleTemplate, şablondaki yer tutucuların bilinen alanlar olduğunu kontrol eder.
+func checkRuleTemplate(template string) error {
+	for _, m := range ruleTemplatePattern.FindAllStringSubmatch(template, -1) {
+		if !ruleTemplateFields[m[1]] {
+			return fmt.Errorf("bilinmeyen yer tutucu `{%s}`", m[1])
+		}
+	}
+	return nil
+}
+
+// compileOrganizeRules, yapılandırmadaki kuralları doğrular ve derler.
+// Bulunan tüm hatalar döndürülür; hatalı kurallar listeye alınmaz.
+func compileOrganizeRules(configs []OrganizeRuleConfig) ([]*organizeRule, []string) {
+	var rules []*organizeRule
+	var problems []string
+	seen := make(map[string]bool)
+	for i, cfg := range configs {
+		label := fmt.Sprintf("rules[%d]", i)
+		if cfg.Name != "" {
+			label = fmt.Sprintf("rules.%s", cfg.Name)
+		}
+		fail := func(format string, args ...any) {
+			problems = append(problems, label+": "+fmt.Sprintf(format, args...))
+		}
+		before := len(problems)
+		rule := &organizeRule{OrganizeRuleConfig: cfg}
+
+		if strings.TrimSpace(cfg.Name) == "" {
+			fail("kural adı (name) boş olamaz")
+		} else if seen[strings.ToLower(cfg.Name)] {
+			fail("aynı adla birden fazla kural var")
+		}
+		seen[strings.ToLower(cfg.Name)] = true
+
+		m := cfg.Match
+		if m.Name != "" {
+			if _, err := path.Match(strings.ToLower(m.Name), ""); err != nil {
+				fail("match.name geçersiz glob: %s", m.Name)
+			}
+		}
+		if m.Folder != "" {
+			if _, err := path.Match(m.Folder, ""); err != nil || strings.HasPrefix(path.Clean(m.Folder), "..") {
+				fail("match.folder geçersiz: %s", m.Folder)
+			}
+		}
+		if m.Regex != "" {
+			re, err := regexp.Compile("(?i)" + m.Regex)
+			if err != nil {
+				fail("match.regex derlenemedi: %v", err)
+			}
+			rule.regex = re
+		}
+		if m.MIME != "" && !strings.Contains(m.MIME, "/") {
+			fail("match.mime `tür/alt_tür` biçiminde olmalı (örn. image/*)")
+		}
+		var err error
+		if m.MinSize != "" {
+			if rule.minSize, err = parseByteSize(m.MinSize); err != nil {
+				fail("match.min_size: %v", err)
+			}
+		}
+		if m.MaxSize != "" {
+			if rule.maxSize, err = parseByteSize(m.MaxSize); err != nil {
+				fail("match.max_size: %v", err)
+			} else if rule.maxSize < rule.minSize {
+				fail("match.max_size, min_size değerinden küçük")
+			}
+		}
+		switch m.Source {
+		case "", sourceTelegram, sourceDownload, sourceLocal:
+		default:
+			fail("match.source `%s` geçersiz (telegram, indir veya yerel)", m.Source)
+		}
+		if m.OlderThan < 0 || m.NewerThan < 0 {
+			fail("match.older_than ve match.newer_than negatif olamaz")
+		}
+
+		a := cfg.Action
+		if a.Move != "" {
+			clean := path.Clean(strings.ReplaceAll(a.Move, `\`, "/"))
+			if strings.HasPrefix(clean, "..") || path.IsAbs(clean) || filepath.IsAbs(a.Move) {
+				fail("action.move ana klasörün dışına çıkamaz")
+			}
+			if err := checkRuleTemplate(a.Move); err != nil {
+				fail("action.move: %v", err)
+			}
+		}
+		if a.Rename != "" {
+			if strings.ContainsAny(a.Rename, `/\`) {
+				fail("action.rename klasör içeremez; klasör için action.move kullanın")
+			}
+			if err := checkRuleTemplate(a.Rename); err != nil {
+				fail("action.rename: %v", err)
+			}
+		}
+		for _, raw := range a.Tags {
+			tag, err := normalizeTag(raw)
+			if err != nil {
+				fail("action.tags: %v", err)
+				continue
+			}
+			rule.tags = append(rule.tags, tag)
+		}
+
+		if len(problems) == before {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, problems
+}
+
+// fileFacts, kuralların eşleştiği bir dosyanın özellikleridir.
+type fileFacts struct {
+	Path    string
+	Key     string
+	Name    string
+	Folder  string // ana klasöre göre klasör; ana klasör için "."
+	Size    int64
+	ModTime time.Time
+	MIME    string
+	Source  string
+	Sender  int64
+
+	// DetectedExt, içerikten algılanan türün uzantısıdır (bkz. `file_type.go`).
+	// Uzantısız dosyaların kategorisi ve yeni adı bununla belirlenir.
+	DetectedExt string
+}
+
+// category, dosyanın kategorisini döndürür. Uzantısız dosyalarda içerikten
+// algılanan uzantı kullanılır.
+func (f fileFacts) category() string {
+	if filepath.Ext(f.Name) == "" && f.DetectedExt != "" {
+		return getFileCategory(f.Name + f.DetectedExt)
+	}
+	return getFileCategory(f.Name)
+}
+
+// sniffMIME, `detectFileType` tarafından tanınmayan dosyaların içerik türünü
+// Go'nun `http.DetectContentType` fonksiyonuyla belirler.
+func sniffMIME(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, _ := file.Read(head)
+	mimeType := http.DetectContentType(head[:n])
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType
+}
+
+// gatherFileFacts, bir dosyanın kurallar için gereken özelliklerini toplar.
+func gatherFileFacts(filePath string, info os.FileInfo) fileFacts {
+	key := fileKey(filePath)
+	meta := getFileMetadata(key)
+	source := meta.Source
+	if source == "" {
+		source = sourceLocal
+	}
+	detected, known := detectFileType(filePath)
+	mimeType := detected.MIME
+	if !known {
+		mimeType = sniffMIME(filePath)
+	}
+	return fileFacts{
+		Path:    filePath,
+		Key:     key,
+		Name:    info.Name(),
+		Folder:  path.Dir(key),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		MIME:    mimeType,
+		Source:  source,
+		Sender:  meta.Sender,
+
+		DetectedExt: detected.Ext(),
+	}
+}
+
+// mismatch, dosya kurala uyuyorsa boş, uymuyorsa uymayan koşulu döndürür.
+func (r *organizeRule) mismatch(f fileFacts, now time.Time) string {
+	m := r.Match
+	if m.Folder == "" {
+		if f.Folder != "." {
+			return "dosya ana klasörde değil"
+		}
+	} else if ok, _ := path.Match(strings.ToLower(m.Folder), strings.ToLower(f.Folder)); !ok {
+		return fmt.Sprintf("klasör `%s` ile eşleşmiyor", m.Folder)
+	}
+	if m.Name != "" {
+		if ok, _ := path.Match(strings.ToLower(m.Name), strings.ToLower(f.Name)); !ok {
+			return fmt.Sprintf("ad `%s` ile eşleşmiyor", m.Name)
+		}
+	}
+	if r.regex != nil && !r.regex.MatchString(f.Name) {
+		return fmt.Sprintf("ad `%s` ifadesine uymuyor", m.Regex)
+	}
+	if m.MIME != "" {
+		if ok, _ := path.Match(strings.ToLower(m.MIME), f.MIME); !ok {
+			return fmt.Sprintf("tür `%s`, `%s` değil", f.MIME, m.MIME)
+		}
+	}
+	if m.MinSize != "" && f.Size < r.minSize {
+		return fmt.Sprintf("boyut %s değerinden küçük", m.MinSize)
+	}
+	if m.MaxSize != "" && f.Size > r.maxSize {
+		return fmt.Sprintf("boyut %s değerinden büyük", m.MaxSize)
+	}
+	if m.Source != "" && f.Source != m.Source {
+		return fmt.Sprintf("kaynak `%s`, `%s` değil", f.Source, m.Source)
+	}
+	if len(m.Senders) > 0 && !containsInt64(m.Senders, f.Sender) {
+		return "gönderen listede değil"
+	}
+	age := now.Sub(f.ModTime)
+	if m.OlderThan > 0 && age < m.OlderThan {
+		return fmt.Sprintf("dosya %s süresinden yeni", m.OlderThan)
+	}
+	if m.NewerThan > 0 && age > m.NewerThan {
+		return fmt.Sprintf("dosya %s süresinden eski", m.NewerThan)
+	}
+	return ""
+}
+
+// containsInt64, listede değerin olup olmadığını kontrol eder.
+func containsInt64(list []int64, value int64) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// expandRuleTemplate, şablondaki yer tutucuları dosyanın bilgileriyle doldurur.
+func expandRuleTemplate(template string, f fileFacts) string {
+	ext := filepath.Ext(f.Name)
+	name := strings.TrimSuffix(f.Name, ext)
+	if ext == "" {
+		ext = f.DetectedExt
+	}
+	values := map[string]string{
+		"yyyy":     f.ModTime.Format("2006"),
+		"yy":       f.ModTime.Format("06"),
+		"mm":       f.ModTime.Format("01"),
+		"dd":       f.ModTime.Format("02"),
+		"name":     name,
+		"ext":      ext,
+		"category": f.category(),
+		"source":   f.Source,
+		"sender":   strconv.FormatInt(f.Sender, 10),
+	}
+	return ruleTemplatePattern.ReplaceAllStringFunc(template, func(m string) string {
+		return values[m[1:len(m)-1]]
+	})
+}
+
+// ruleTarget, kuralın (veya kural yoksa kategori eşlemesinin) dosya için
+// belirlediği hedef yolu döndürür. Şablonlardan üretilen klasör ve adlar
+// temizlenir; hedef ana klasörün dışına çıkıyorsa dosya yerinde bırakılır.
+func ruleTarget(rule *organizeRule, f fileFacts) string {
+	dir := f.category()
+	name := f.Name
+	if filepath.Ext(name) == "" && f.DetectedExt != "" {
+		// * Uzantısız dosyalar içerikten algılanan uzantıyla taşınır.
+		name += f.DetectedExt
+	}
+	if rule != nil && rule.Action.Move != "" {
+		dir = filepath.FromSlash(path.Clean(strings.ReplaceAll(expandRuleTemplate(rule.Action.Move, f), `\`, "/")))
+	} else if rule != nil && f.Folder != "." {
+		// * Alt klasördeki bir dosya için `move` yoksa dosya klasöründe kalır.
+		dir = filepath.FromSlash(f.Folder)
+	}
+	if rule != nil && rule.Action.Rename != "" {
+		if renamed, err := sanitizeFileName(expandRuleTemplate(rule.Action.Rename, f)); err == nil {
+			name = renamed
+		}
+	}
+	cleanDir, err := safeRelativePath(dir)
+	if err != nil {
+		log.Printf("Uyarı: `%s` için kural hedefi geçersiz (%s): %v", f.Key, dir, err)
+		return f.Path
+	}
+	target, err := resolveInBase(path.Join(cleanDir, name))
+	if err != nil {
+		log.Printf("Uyarı: `%s` için kural hedefi ana klasörün dışında: %s", f.Key, dir)
+		return f.Path
+	}
+	return target
+}
+
+// organizePlan, düzenleme sırasında bir dosya için verilen karardır.
+type organizePlan struct {
+	Facts  fileFacts
+	Rule   *organizeRule // nil ise uzantı -> kategori eşlemesi kullanılır
+	Target string
+}
+
+// planOrganize, dosyaları taşımadan hangi dosyanın nereye gideceğini
+// hesaplar. Hem `organizeFiles` hem de `/kurallar deneme` tarafından kullanılır.
+func planOrganize(now time.Time) []organizePlan {
+	rules := organizeRules()
+	var plans []organizePlan
+
+	entries, err := os.ReadDir(config.BaseDir)
+	if err != nil {
+		log.Printf("Ana klasör okunamadı: %v", err)
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue // Alt klasörler aşağıda, yalnızca kurallar için taranır.
+		}
+		if isIncomingTemp(entry.Name()) {
+			continue // Yazılması süren yükleme; tamamlanınca ayrıca düzenlenir.
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		facts := gatherFileFacts(filepath.Join(config.BaseDir, entry.Name()), info)
+		plan := organizePlan{Facts: facts}
+		for _, rule := range rules {
+			if rule.mismatch(facts, now) == "" {
+				plan.Rule = rule
+				break
+			}
+		}
+		plan.Target = ruleTarget(plan.Rule, facts)
+		plans = append(plans, plan)
+	}
+
+	var folderRules []*organizeRule
+	for _, rule := range rules {
+		if rule.Match.Folder != "" {
+			folderRules = append(folderRules, rule)
+		}
+	}
+	if len(folderRules) == 0 {
+		return plans
+	}
+	magicFolder := filepath.Join(config.BaseDir, "TelegramaGonder")
+	filepath.Walk(config.BaseDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if p == magicFolder {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Dir(p) == config.BaseDir || isIncomingTemp(info.Name()) {
+			return nil
+		}
+		facts := gatherFileFacts(p, info)
+		for _, rule := range folderRules {
+			if rule.mismatch(facts, now) == "" {
+				plans = append(plans, organizePlan{Facts: facts, Rule: rule, Target: ruleTarget(rule, facts)})
+				break
+			}
+		}
+		return nil
+	})
+	return plans
+}
+
+// applyRuleActions, kuralın etiket ve açıklama işlemlerini uygular. Dosyanın
+// mevcut açıklaması varsa üzerine yazılmaz.
+func applyRuleActions(rule *organizeRule, key string, f fileFacts) {
+	if rule == nil {
+		return
+	}
+	if len(rule.tags) > 0 {
+		if _, err := addTags(key, rule.tags); err != nil {
+			log.Printf("Kural etiketleri eklenemedi (%s): %v", key, err)
+		}
+	}
+	if rule.Action.Describe != "" {
+		if _, exists := getDescription(key); !exists {
+			if err := addDescription(key, expandRuleTemplate(rule.Action.Describe, f)); err != nil {
+				log.Printf("Kural açıklaması eklenemedi (%s): %v", key, err)
+			}
+		}
+	}
+}
+
+// #############################################################################
+// #                           KURAL KOMUTLARI
+// #############################################################################
+
+// maxRulePlansShown, `/kurallar deneme` çıktısında gösterilecek en fazla dosya sayısıdır.
+const maxRulePlansShown = 50
+
+// describeRule, bir kuralın koşullarını ve işlemlerini okunabilir biçimde yazar.
+func describeRule(rule *organizeRule) string {
+	var conds, actions []string
+	m, a := rule.Match, rule.Action
+	add := func(list *[]string, label, value string) {
+		if value != "" {
+			*list = append(*list, label+"="+value)
+		}
+	}
+	folder := m.Folder
+	if folder == "" {
+		folder = "ana klasör"
+	}
+	add(&conds, "klasör", folder)
+	add(&conds, "ad", m.Name)
+	add(&conds, "regex", m.Regex)
+	add(&conds, "tür", m.MIME)
+	add(&conds, "en az", m.MinSize)
+	add(&conds, "en çok", m.MaxSize)
+	add(&conds, "kaynak", m.Source)
+	if len(m.Senders) > 0 {
+		var ids []string
+		for _, id := range m.Senders {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		add(&conds, "gönderen", strings.Join(ids, ","))
+	}
+	if m.OlderThan > 0 {
+		add(&conds, "daha eski", m.OlderThan.String())
+	}
+	if m.NewerThan > 0 {
+		add(&conds, "daha yeni", m.NewerThan.String())
+	}
+	add(&actions, "taşı", a.Move)
+	add(&actions, "ad", a.Rename)
+	if len(rule.tags) > 0 {
+		add(&actions, "etiket", formatTags(rule.tags))
+	}
+	add(&actions, "açıklama", a.Describe)
+	if a.Move == "" && m.Folder == "" {
+		actions = append(actions, "taşı=kategori")
+	}
+	return fmt.Sprintf("🔎 %s\n⚙️ %s", strings.Join(conds, " · "), strings.Join(actions, " · "))
+}
+
+// handleRulesCommand, /kurallar komutunu işler: argümansız kuralları listeler,
+// `test <dosya>` bir dosyanın hangi kurala uyduğunu açıklar, `deneme` ise
+// dosyaları taşımadan düzenlemenin sonucunu gösterir.
+func handleRulesCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	fields := strings.Fields(message.CommandArguments())
+	action := ""
+	if len(fields) > 0 {
+		action = strings.ToLower(fields[0])
+	}
+	switch action {
+	case "":
+		handleRulesList(bot, chatID)
+	case "test":
+		if len(fields) < 2 {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/kurallar test <dosya>`"))
+			return
+		}
+		handleRulesTest(bot, message, strings.Join(fields[1:], " "))
+	case "deneme":
+		handleRulesDryRun(bot, message)
+	default:
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/kurallar [test <dosya> | deneme]`"))
+	}
+}
+
+// handleRulesList, tanımlı kuralları sırasıyla listeler.
+func handleRulesList(bot Messenger, chatID int64) {
+	rules := organizeRules()
+	if len(rules) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "📐 Tanımlı düzenleme kuralı yok. Dosyalar uzantılarına göre kategorilere taşınıyor.\n\n💡 Kurallar `config.yaml` içindeki `rules` listesinde tanımlanır."))
+		return
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("📐 Düzenleme Kuralları (%d)\nİlk eşleşen kural uygulanır; hiçbirine uymayan dosyalar kategorisine taşınır.\n\n", len(rules)))
+	for i, rule := range rules {
+		builder.WriteString(fmt.Sprintf("%d. %s\n%s\n\n", i+1, rule.Name, describeRule(rule)))
+	}
+	builder.WriteString("💡 `/kurallar test <dosya>` · `/kurallar deneme`")
+	for _, chunk := range splitMessageSmart(builder.String()) {
+		bot.Send(tgbotapi.NewMessage(chatID, chunk))
+	}
+}
+
+// handleRulesTest, bir dosyayı tüm kurallara karşı dener ve her kural için
+// sonucu gösterir.
+func handleRulesTest(bot Messenger, message *tgbotapi.Message, name string) {
+	chatID := message.Chat.ID
+	var accessible []string
+	for _, p := range findFileMatches(name) {
+		if canAccessPath(message.From.ID, p) {
+			accessible = append(accessible, fileKey(p))
+		}
+	}
+	if len(accessible) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dosya bulunamadı: `%s`", name)))
+		return
+	} else if len(accessible) > 1 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔀 Bu adla birden fazla dosya var, lütfen tam yolu yazın:\n\n`%s`", strings.Join(accessible, "`\n`"))))
+		return
+	}
+	filePath, _ := pathOfKey(accessible[0])
+	info, err := os.Stat(filePath)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Dosya okunamadı: %v", err)))
+		return
+	}
+
+	now := time.Now()
+	facts := gatherFileFacts(filePath, info)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🧪 `%s`\n📏 %.1f KB · 🧬 %s · 📥 %s", facts.Key, float64(facts.Size)/1024, facts.MIME, facts.Source))
+	if facts.Sender != 0 {
+		builder.WriteString(fmt.Sprintf(" · 👤 %d", facts.Sender))
+	}
+	builder.WriteString(fmt.Sprintf(" · 🕒 %s\n\n", facts.ModTime.Format("02.01.2006 15:04")))
+
+	var matched *organizeRule
+	for _, rule := range organizeRules() {
+		if matched != nil {
+			builder.WriteString(fmt.Sprintf("⏭️ %s: denenmedi\n", rule.Name))
+			continue
+		}
+		if reason := rule.mismatch(facts, now); reason != "" {
+			builder.WriteString(fmt.Sprintf("❌ %s: %s\n", rule.Name, reason))
+			continue
+		}
+		matched = rule
+		builder.WriteString(fmt.Sprintf("✅ %s: eşleşti\n", rule.Name))
+	}
+
+	switch {
+	case matched == nil && facts.Folder != ".":
+		builder.WriteString("\n➡️ Hiçbir kural eşleşmedi; dosya yerinde kalır.")
+	default:
+		target := ruleTarget(matched, facts)
+		if target == filePath {
+			builder.WriteString("\n➡️ Dosya zaten hedefte, taşınmaz.")
+		} else {
+			builder.WriteString(fmt.Sprintf("\n➡️ `%s`", fileKey(target)))
+		}
+		if matched == nil {
+			builder.WriteString(" (kategori eşlemesi)")
+		}
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, builder.String()))
+}
+
+// handleRulesDryRun, düzenlemenin taşıyacağı dosyaları taşımadan listeler.
+func handleRulesDryRun(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	var lines []string
+	for _, plan := range planOrganize(time.Now()) {
+		if plan.Target == plan.Facts.Path {
+			continue
+		}
+		if !canAccessPath(message.From.ID, plan.Facts.Path) || !canAccessPath(message.From.ID, plan.Target) {
+			continue
+		}
+		ruleName := "kategori"
+		if plan.Rule != nil {
+			ruleName = plan.Rule.Name
+		}
+		lines = append(lines, fmt.Sprintf("`%s` → `%s` (%s)", plan.Facts.Key, fileKey(plan.Target), ruleName))
+	}
+	if len(lines) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "🧪 Deneme: taşınacak dosya yok."))
+		return
+	}
+	text := fmt.Sprintf("🧪 Deneme: %d dosya taşınacak (hiçbir dosya değiştirilmedi)\n\n", len(lines))
+	if len(lines) > maxRulePlansShown {
+		text += strings.Join(lines[:maxRulePlansShown], "\n") + fmt.Sprintf("\n… ve %d dosya daha", len(lines)-maxRulePlansShown)
+	} else {
+		text += strings.Join(lines, "\n")
+	}
+	for _, chunk := range splitMessageSmart(text) {
+		bot.Send(tgbotapi.NewMessage(chatID, chunk))
+	}
+}
@@ -0,0 +1,424 @@
+// recycle_bin.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                               ÇÖP KUTUSU
+// #############################################################################
+// Bu dosya, silinen dosyaların kalıcı olarak silinmek yerine çöp kutusuna
+// taşınmasını sağlar. `/sil` onayı ve LLM'in `delete_file` aracı dosyayı
+// `TRASH_DIR` klasörüne taşır; dosyanın özgün yolu, açıklaması, etiketleri,
+// silinme zamanı ve silen kullanıcı `cop.json` içinde saklanır.
+//
+// Çöp kutusu ana klasörün dışında tutulur; böylece silinen dosyalar arama,
+// listeleme, içerik indeksi ve otomatik düzenleme tarafından görülmez.
+// Saklama süresi (`trash.retention`) dolan dosyalar zamanlayıcı tarafından
+// saatlik olarak kalıcı olarak silinir.
+
+// trashIndexFileName, çöp kutusu kayıtlarının tutulduğu dosyanın adıdır.
+const trashIndexFileName = "cop.json"
+
+// TrashEntry, çöp kutusundaki tek bir dosyayı temsil eder.
+type TrashEntry struct {
+	ID         int           `json:"id"`
+	Original   string        `json:"original"`    // Ana klasöre göre özgün yol
+	StoredName string        `json:"stored_name"` // Çöp kutusu klasöründeki dosya adı
+	Size       int64         `json:"size"`
+	DeletedAt  string        `json:"deleted_at"` // RFC3339
+	DeletedBy  int64         `json:"deleted_by"`
+	Metadata   *FileMetadata `json:"metadata,omitempty"` // Silinmeden önceki açıklama ve etiketler
+}
+
+// trashIndex, `cop.json` dosyasının yapısıdır.
+type trashIndex struct {
+	NextID  int          `json:"next_id"`
+	Entries []TrashEntry `json:"entries"`
+}
+
+// trashMutex, çöp kutusu klasörüne ve `cop.json` dosyasına erişimi korur.
+var trashMutex = &sync.Mutex{}
+
+// loadTrashIndexLocked, kayıtları okur. Çağıran `trashMutex` kilidini tutmalıdır.
+func loadTrashIndexLocked() (*trashIndex, error) {
+	index := &trashIndex{NextID: 1}
+	data, err := os.ReadFile(filepath.Join(config.TrashDir, trashIndexFileName))
+	if os.IsNotExist(err) {
+		return index, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("çöp kutusu kayıtları okunamadı: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("çöp kutusu kayıtları çözümlenemedi: %w", err)
+	}
+	if index.NextID < 1 {
+		index.NextID = 1
+	}
+	return index, nil
+}
+
+// saveTrashIndexLocked, kayıtları geçici dosya + yeniden adlandırma ile atomik
+// olarak yazar. Çağıran `trashMutex` kilidini tutmalıdır.
+func saveTrashIndexLocked(index *trashIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(config.TrashDir, trashIndexFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// moveFile, dosyayı taşır. Kaynak ve hedef farklı disklerdeyse `os.Rename`
+// başarısız olacağı için dosya kopyalanıp kaynağı silinir.
+func moveFile(src, dst string) error {
+	renameErr := os.Rename(src, dst)
+	if renameErr == nil {
+		return nil
+	}
+	if _, err := os.Stat(src); err != nil {
+		return renameErr
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return renameErr
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		in.Close()
+		return renameErr
+	}
+	_, copyErr := io.Copy(out, in)
+	in.Close()
+	if closeErr := out.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		os.Remove(dst)
+		return fmt.Errorf("dosya kopyalanamadı: %w", copyErr)
+	}
+	return os.Remove(src)
+}
+
+// moveToTrash, ana klasördeki bir dosyayı çöp kutusuna taşır. Dosyanın
+// açıklaması ve etiketleri kayıtla birlikte saklanır.
+func moveToTrash(userID int64, filePath string) (TrashEntry, error) {
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	if err := os.MkdirAll(config.TrashDir, os.ModePerm); err != nil {
+		return TrashEntry{}, fmt.Errorf("çöp kutusu klasörü oluşturulamadı: %w", err)
+	}
+	index, err := loadTrashIndexLocked()
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	key := fileKey(filePath)
+	entry := TrashEntry{
+		ID:         index.NextID,
+		Original:   key,
+		StoredName: fmt.Sprintf("%d_%s", index.NextID, filepath.Base(filePath)),
+		Size:       info.Size(),
+		DeletedAt:  time.Now().Format(time.RFC3339),
+		DeletedBy:  userID,
+	}
+	if err := moveFile(filePath, filepath.Join(config.TrashDir, entry.StoredName)); err != nil {
+		return TrashEntry{}, err
+	}
+
+	meta, err := takeFileMetadata(key)
+	if err != nil {
+		log.Printf("Çöp kutusu: `%s` açıklaması okunamadı: %v", key, err)
+	}
+	entry.Metadata = meta
+	index.NextID++
+	index.Entries = append(index.Entries, entry)
+	if err := saveTrashIndexLocked(index); err != nil {
+		// * Kayıt yazılamazsa dosya yerine geri konur; aksi halde geri alınamaz.
+		moveFile(filepath.Join(config.TrashDir, entry.StoredName), filePath)
+		if meta != nil {
+			restoreFileMetadata(key, *meta)
+		}
+		return TrashEntry{}, fmt.Errorf("çöp kutusu kaydı yazılamadı: %w", err)
+	}
+	log.Printf("Çöp kutusuna taşındı: %s (kimlik %d, silen %d)", key, entry.ID, userID)
+	return entry, nil
+}
+
+// restoreFromTrash, bir dosyayı özgün yerine geri taşır. Aynı yolda başka bir
+// dosya varsa üzerine yazılmaz.
+func restoreFromTrash(userID int64, id int) (TrashEntry, error) {
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+
+	index, err := loadTrashIndexLocked()
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	pos := -1
+	for i, entry := range index.Entries {
+		if entry.ID == id {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return TrashEntry{}, fmt.Errorf("çöp kutusunda %d kimlikli dosya yok", id)
+	}
+	entry := index.Entries[pos]
+	target, ok := pathOfKey(entry.Original)
+	if !ok {
+		return TrashEntry{}, fmt.Errorf("`%s` geçerli bir yol değil", entry.Original)
+	}
+	if !canAccessPath(userID, target) {
+		return TrashEntry{}, fmt.Errorf("%s", categoryDeniedText)
+	}
+	if _, err := os.Stat(target); err == nil {
+		return TrashEntry{}, fmt.Errorf("`%s` yolunda zaten bir dosya var; önce onu taşıyın veya yeniden adlandırın", entry.Original)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return TrashEntry{}, err
+	}
+	if err := moveFile(filepath.Join(config.TrashDir, entry.StoredName), target); err != nil {
+		return TrashEntry{}, fmt.Errorf("dosya geri taşınamadı: %w", err)
+	}
+	if entry.Metadata != nil {
+		if err := restoreFileMetadata(entry.Original, *entry.Metadata); err != nil {
+			log.Printf("Çöp kutusu: `%s` açıklaması geri yüklenemedi: %v", entry.Original, err)
+		}
+	}
+	index.Entries = append(index.Entries[:pos], index.Entries[pos+1:]...)
+	if err := saveTrashIndexLocked(index); err != nil {
+		log.Printf("Çöp kutusu kaydı yazılamadı: %v", err)
+	}
+	log.Printf("Çöp kutusundan geri alındı: %s (kimlik %d, geri alan %d)", entry.Original, id, userID)
+	return entry, nil
+}
+
+// purgeTrash, `keep` false döndüren kayıtları ve dosyalarını kalıcı olarak
+// siler. Silinen dosya sayısını ve toplam boyutu döndürür.
+func purgeTrash(keep func(entry TrashEntry) bool) (int, int64, error) {
+	trashMutex.Lock()
+	defer trashMutex.Unlock()
+
+	index, err := loadTrashIndexLocked()
+	if err != nil {
+		return 0, 0, err
+	}
+	var kept []TrashEntry
+	count, size := 0, int64(0)
+	for _, entry := range index.Entries {
+		if keep(entry) {
+			kept = append(kept, entry)
+			continue
+		}
+		err := os.Remove(filepath.Join(config.TrashDir, entry.StoredName))
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Çöp kutusundaki dosya silinemedi: %s - %v", entry.StoredName, err)
+			kept = append(kept, entry)
+			continue
+		}
+		count++
+		size += entry.Size
+	}
+	if count == 0 {
+		return 0, 0, nil
+	}
+	index.Entries = kept
+	return count, size, saveTrashIndexLocked(index)
+}
+
+// listTrash, kullanıcının özgün konumuna erişebildiği kayıtları yeniden eskiye
+// doğru sıralı döndürür.
+func listTrash(userID int64) ([]TrashEntry, error) {
+	trashMutex.Lock()
+	index, err := loadTrashIndexLocked()
+	trashMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var entries []TrashEntry
+	for _, entry := range index.Entries {
+		if path, ok := pathOfKey(entry.Original); ok && canAccessPath(userID, path) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	return entries, nil
+}
+
+// trashExpiry, bir kaydın kalıcı olarak silineceği zamanı döndürür.
+func trashExpiry(entry TrashEntry) time.Time {
+	deleted, err := time.Parse(time.RFC3339, entry.DeletedAt)
+	if err != nil {
+		return time.Now().Add(trashRetention())
+	}
+	return deleted.Add(trashRetention())
+}
+
+// purgeExpiredTrash, saklama süresi dolan dosyaları siler. Zamanlayıcı
+// tarafından saatlik olarak çağrılır.
+func purgeExpiredTrash() {
+	now := time.Now()
+	count, size, err := purgeTrash(func(entry TrashEntry) bool {
+		return now.Before(trashExpiry(entry))
+	})
+	if err != nil {
+		log.Printf("Çöp kutusu temizlenemedi: %v", err)
+	}
+	if count > 0 {
+		log.Printf("Saklama süresi dolan %d dosya çöp kutusundan kalıcı olarak silindi (%.1f MB).", count, float64(size)/1024/1024)
+	}
+}
+
+// #############################################################################
+// #                           ÇÖP KUTUSU KOMUTLARI
+// #############################################################################
+
+// handleTrashListCommand, /cop komutuyla çöp kutusundaki dosyaları listeler.
+func handleTrashListCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	entries, err := listTrash(message.From.ID)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	if len(entries) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "🗑️ Çöp kutusu boş."))
+		return
+	}
+	var builder strings.Builder
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	builder.WriteString(fmt.Sprintf("🗑️ *Çöp Kutusu* (%d dosya, %.1f MB)\n\n", len(entries), float64(total)/1024/1024))
+	for _, entry := range entries {
+		// * Dosya adı kod bloğu yerine kaçırılmış düz metin olarak yazılır;
+		// * adında ters tırnak olan bir dosya Markdown'ı bozamaz.
+		builder.WriteString(fmt.Sprintf("🆔 %d · %s\n   🕒 %s · %.1f MB · ⏳ %s tarihinde silinecek\n",
+			entry.ID, markdownEscaper.Replace(entry.Original), formatStoredTime(entry.DeletedAt), float64(entry.Size)/1024/1024,
+			trashExpiry(entry).Local().Format("02.01.2006")))
+	}
+	builder.WriteString("\n💡 Geri almak için: `/geri_al <id>`")
+	for _, chunk := range splitMessageSmart(builder.String()) {
+		msg := tgbotapi.NewMessage(chatID, chunk)
+		msg.ParseMode = "Markdown"
+		if _, err := bot.Send(msg); err != nil {
+			log.Printf("Çöp kutusu listesi gönderilirken Markdown hatası (fallback denenecek): %v", err)
+			msg.ParseMode = ""
+			bot.Send(msg)
+		}
+	}
+}
+
+// handleTrashRestoreCommand, /geri_al komutuyla bir dosyayı çöp kutusundan geri alır.
+func handleTrashRestoreCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	id, err := strconv.Atoi(strings.TrimSpace(message.CommandArguments()))
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/geri_al <id>`\nKimlikleri görmek için: /cop"))
+		return
+	}
+	entry, err := restoreFromTrash(message.From.ID, id)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	markTrashRestored(message.From.ID, entry.ID)
+	text := fmt.Sprintf("♻️ `%s` geri alındı.", entry.Original)
+	if entry.Metadata != nil {
+		text += "\n📝 Açıklama ve etiketleri de geri yüklendi."
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// handleTrashPurgeCommand, /cop_bosalt komutuyla çöp kutusunu boşaltmak için onay ister.
+func handleTrashPurgeCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	entries, err := listTrash(message.From.ID)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	if len(entries) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "🗑️ Çöp kutusu zaten boş."))
+		return
+	}
+	// * Onayı yalnızca komutu gönderen yönetici verebilir ve onay bir kez
+	// * kullanılabilir.
+	id := newCallbackPayload(message.From.ID, "bosalt")
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ *Emin misiniz?*\n\nÇöp kutusundaki %d dosya kalıcı olarak silinecek. Bu işlem geri alınamaz.", len(entries)))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Evet, Boşalt", callbackData("cop", "evet", id)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ İptal", callbackData("cop", "iptal", id)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleTrashCallback, /cop_bosalt onay düğmelerini işler.
+func handleTrashCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	chatID := callbackQuery.Message.Chat.ID
+	messageID := callbackQuery.Message.MessageID
+	userID := callbackQuery.From.ID
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 {
+		return
+	}
+	action, id := parts[1], parts[2]
+
+	_, err := takeCallbackPayload(id, userID)
+	if errors.Is(err, errPayloadForeign) {
+		// * Onayı yalnızca /cop_bosalt komutunu gönderen kullanıcı verebilir.
+		return
+	}
+	var newText string
+	if action != "evet" {
+		newText = "👍 Çöp kutusunu boşaltma iptal edildi."
+	} else if err != nil {
+		newText = "⌛ Bu onayın süresi dolmuş. Lütfen `/cop_bosalt` komutunu yeniden gönderin."
+	} else {
+		// * Yalnızca kullanıcının erişebildiği kategorilerdeki dosyalar silinir.
+		count, size, err := purgeTrash(func(entry TrashEntry) bool {
+			path, ok := pathOfKey(entry.Original)
+			return ok && !canAccessPath(userID, path)
+		})
+		if err != nil {
+			newText = fmt.Sprintf("❌ Çöp kutusu boşaltılamadı: %v", err)
+		} else {
+			log.Printf("Çöp kutusu boşaltıldı: %d dosya - Boşaltan: %d", count, userID)
+			newText = fmt.Sprintf("🗑️ Çöp kutusu boşaltıldı: %d dosya kalıcı olarak silindi (%.1f MB).", count, float64(size)/1024/1024)
+		}
+	}
+	bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, newText))
+}
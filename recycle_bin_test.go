@@ -0,0 +1,79 @@
+// recycle_bin_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// trashTestFile, ana klasörde bir dosya oluşturup çöp kutusuna taşır.
+func trashTestFile(t *testing.T, userID int64, name string) TrashEntry {
+	t.Helper()
+	path := filepath.Join(config.BaseDir, name)
+	if err := os.WriteFile(path, []byte("içerik"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := moveToTrash(userID, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestTrashListEscapesNames(t *testing.T) {
+	bot := setupTestEnv(t)
+	trashTestFile(t, testOperatorID, "rapor_`son`*.txt")
+
+	handleCommand(bot, newCommandMessage(testOperatorID, "/cop"))
+	msg, ok := bot.Sent[len(bot.Sent)-1].(tgbotapi.MessageConfig)
+	if !ok {
+		t.Fatalf("son gönderim mesaj değil: %T", bot.Sent[len(bot.Sent)-1])
+	}
+	if msg.ParseMode != "Markdown" {
+		t.Errorf("ParseMode = %q, Markdown bekleniyordu", msg.ParseMode)
+	}
+	if !strings.Contains(msg.Text, "rapor\\_\\`son\\`\\*.txt") {
+		t.Errorf("dosya adı kaçırılmamış: %q", msg.Text)
+	}
+}
+
+func TestTrashPurgeConfirmationIsBoundToUser(t *testing.T) {
+	bot := setupTestEnv(t)
+	trashTestFile(t, testAdminID, "eski.txt")
+
+	handleCommand(bot, newCommandMessage(testAdminID, "/cop_bosalt"))
+	msg := bot.Sent[len(bot.Sent)-1].(tgbotapi.MessageConfig)
+	markup := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	confirm := *markup.InlineKeyboard[0][0].CallbackData
+	if !strings.HasPrefix(confirm, "cop_evet_") {
+		t.Fatalf("onay düğmesi verisi = %q", confirm)
+	}
+
+	// Başka bir yönetici aynı düğmeye bassa bile çöp kutusu boşaltılmaz.
+	if err := addUser(2001, roleAdmin, "ikinci"); err != nil {
+		t.Fatal(err)
+	}
+	bot.Reset()
+	handleCallbackQuery(bot, newCallbackQuery(2001, confirm))
+	if entries, _ := listTrash(testAdminID); len(entries) != 1 {
+		t.Fatalf("yabancı onayla çöp kutusu boşaltıldı: %d dosya kaldı", len(entries))
+	}
+
+	handleCallbackQuery(bot, newCallbackQuery(testAdminID, confirm))
+	if got := lastText(t, bot); !strings.Contains(got, "boşaltıldı") {
+		t.Fatalf("yanıt = %q", got)
+	}
+	if entries, _ := listTrash(testAdminID); len(entries) != 0 {
+		t.Errorf("çöp kutusunda %d dosya kaldı", len(entries))
+	}
+
+	// Onay bir kez kullanılabilir.
+	handleCallbackQuery(bot, newCallbackQuery(testAdminID, confirm))
+	if got := lastText(t, bot); !strings.Contains(got, "süresi dolmuş") {
+		t.Errorf("ikinci onay yanıtı = %q", got)
+	}
+}
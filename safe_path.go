@@ -0,0 +1,201 @@
+// safe_path.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// #############################################################################
+// #                          GÜVENLİ DOSYA YOLLARI
+// #############################################################################
+// Bu dosya, kullanıcıdan, Telegram'dan, indirilen sunuculardan veya LLM
+// araçlarından gelen dosya adlarının ve yolların tek çözümleme katmanıdır.
+// Adlar Unicode NFC biçimine getirilir; kontrol karakterleri, yön değiştirme
+// işaretleri, klasör ayraçları ve Windows'ta geçersiz karakterler ayıklanır;
+// `CON`, `NUL`, `COM1` gibi ayrılmış adlar değiştirilir. Çözümlenen her yolun,
+// sembolik bağlantılar takip edildikten sonra da ana klasörün (`BaseDir`)
+// içinde kaldığı doğrulanır.
+
+// maxFileNameBytes, çoğu dosya sisteminin kabul ettiği en uzun dosya adıdır.
+const maxFileNameBytes = 255
+
+var (
+	// errInvalidFileName, addan geriye kullanılabilir bir şey kalmadığını belirtir.
+	errInvalidFileName = errors.New("geçersiz dosya adı")
+	// errOutsideBaseDir, yolun ana klasörün dışına çıktığını belirtir.
+	errOutsideBaseDir = errors.New("yol ana klasörün dışına çıkıyor")
+)
+
+// windowsReservedNames, Windows'ta uzantısı ne olursa olsun dosya adı olarak
+// kullanılamayan aygıt adlarıdır.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true, "CONIN$": true, "CONOUT$": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// isUnsafeNameRune, dosya adında bulunmaması gereken karakterleri belirler:
+// kontrol karakterleri, metnin yönünü değiştiren görünmez işaretler ve
+// Windows'ta geçersiz olan karakterler (ayraçlar dahil).
+func isUnsafeNameRune(r rune) bool {
+	switch {
+	case unicode.IsControl(r), r == utf8.RuneError:
+		return true
+	case r >= '\u202a' && r <= '\u202e', r >= '\u2066' && r <= '\u2069', r == '\u200e', r == '\u200f':
+		return true
+	}
+	return strings.ContainsRune(`<>:"/\|?*`, r)
+}
+
+// sanitizeFileName, bir dosya adını diske güvenle yazılabilecek hale getirir.
+// Ayraçlar ve geçersiz karakterler `_` ile değiştirilir, sondaki nokta ve
+// boşluklar silinir (Windows bunları yok sayar), ayrılmış adların önüne `_`
+// eklenir ve ad uzantısı korunarak 255 bayta kısaltılır. Geriye kullanılabilir
+// bir ad kalmazsa `errInvalidFileName` döner.
+func sanitizeFileName(name string) (string, error) {
+	name = norm.NFC.String(name)
+	name = strings.Map(func(r rune) rune {
+		if isUnsafeNameRune(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+	if strings.Trim(name, "_") == "" {
+		return "", errInvalidFileName
+	}
+
+	stem := name
+	if dot := strings.IndexByte(name, '.'); dot > 0 {
+		stem = name[:dot]
+	}
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		name = "_" + name
+	}
+
+	if len(name) > maxFileNameBytes {
+		ext := filepath.Ext(name)
+		if len(ext) > 32 {
+			ext = ""
+		}
+		base := name[:maxFileNameBytes-len(ext)]
+		// * Çok baytlı bir karakterin ortasından kesilmemesi için geri gidilir.
+		for len(base) > 0 && !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = strings.TrimRight(base, ". ") + ext
+	}
+	return name, nil
+}
+
+// safeRelativePath, kullanıcının yazdığı göreli bir klasör veya dosya yolunu
+// (`Dokümanlar/2024`) bileşen bileşen temizler ve `/` ayraçlı olarak
+// döndürür. `..` içeren yollar ile sürücü harfli (`C:\x`) veya UNC yollar
+// reddedilir; baştaki `/` ana klasörü ifade eder. Boş yol ana klasördür.
+func safeRelativePath(input string) (string, error) {
+	input = norm.NFC.String(strings.TrimSpace(input))
+	// * Sürücü harfi her platformda reddedilir; Windows dışında
+	// * `filepath.VolumeName` bunu tanımaz.
+	if filepath.VolumeName(input) != "" || hasDriveLetter(input) {
+		return "", errOutsideBaseDir
+	}
+	var parts []string
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool { return r == '/' || r == '\\' }) {
+		switch strings.TrimSpace(part) {
+		case "", ".":
+			continue
+		case "..":
+			return "", errOutsideBaseDir
+		}
+		clean, err := sanitizeFileName(part)
+		if err != nil {
+			return "", fmt.Errorf("%w: `%s`", err, part)
+		}
+		parts = append(parts, clean)
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// hasDriveLetter, yolun `C:` gibi bir sürücü harfiyle başlayıp başlamadığını
+// belirler.
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// resolveInBase, ana klasöre göre göreli bir yolu mutlak yola çevirir. Yol
+// sözcüksel olarak veya sembolik bağlantılar üzerinden ana klasörün dışına
+// çıkıyorsa `errOutsideBaseDir` döner. Yol henüz var olmak zorunda değildir.
+func resolveInBase(rel string) (string, error) {
+	rel = norm.NFC.String(rel)
+	// * `:` Windows'ta sürücü adını veya NTFS alternatif veri akışını belirtir.
+	if strings.ContainsAny(rel, "\x00:") || filepath.VolumeName(rel) != "" {
+		return "", errOutsideBaseDir
+	}
+	// * `\` her platformda ayraç sayılır; Windows'tan gelen yollar Linux'ta da
+	// * aynı şekilde çözülür.
+	rel = strings.ReplaceAll(rel, `\`, "/")
+	clean := filepath.Clean(filepath.FromSlash(strings.TrimLeft(rel, "/")))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) || filepath.IsAbs(clean) {
+		return "", errOutsideBaseDir
+	}
+	path := filepath.Join(config.BaseDir, clean)
+	if !isInsideBase(path) {
+		return "", errOutsideBaseDir
+	}
+	return path, nil
+}
+
+// isInsideBase, mutlak bir yolun sembolik bağlantılar (ve Windows'ta
+// bağlantı noktaları) çözüldükten sonra ana klasörün içinde kalıp kalmadığını
+// belirler. Var olmayan yollar için en yakın var olan üst klasör çözülür.
+func isInsideBase(path string) bool {
+	base, okBase := resolveExistingPath(config.BaseDir)
+	target, okTarget := resolveExistingPath(path)
+	if !okBase || !okTarget {
+		return false
+	}
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolveExistingPath, yolun var olan en uzun önekindeki sembolik
+// bağlantıları çözer ve kalan bileşenleri sonuna ekler. Hedefi olmayan veya
+// okunamayan bir bağlantıya rastlanırsa yol çözülemez sayılır; böyle bir
+// bağlantıya yazmak dosyayı bilinmeyen bir yere oluşturabilir.
+func resolveExistingPath(path string) (string, bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rest := ""
+	for current := path; ; {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(resolved, rest), true
+		}
+		if info, lerr := os.Lstat(current); !os.IsNotExist(err) || (lerr == nil && info.Mode()&os.ModeSymlink != 0) {
+			return "", false
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path, true
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
@@ -0,0 +1,222 @@
+// safe_path_test.go
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"düz ad", "rapor.pdf", "rapor.pdf", false},
+		{"üst klasör", "../../disari.txt", ".._.._disari.txt", false},
+		{"ters bölü", `klasor\dosya.txt`, "klasor_dosya.txt", false},
+		{"mutlak yol", "/etc/passwd", "_etc_passwd", false},
+		{"sürücü yolu", `C:\Windows\win.ini`, "C__Windows_win.ini", false},
+		{"NUL karakteri", "a\x00b.txt", "a_b.txt", false},
+		{"alternatif veri akışı", "dosya.txt:gizli", "dosya.txt_gizli", false},
+		{"ayrılmış ad", "CON", "_CON", false},
+		{"ayrılmış ad küçük harf", "nul.txt", "_nul.txt", false},
+		{"ayrılmış ad çift uzantı", "COM1.tar.gz", "_COM1.tar.gz", false},
+		{"ayrılmış ada benzeyen", "CONSOLE.txt", "CONSOLE.txt", false},
+		{"yön değiştirme", "fatura\u202Efdp.exe", "fatura_fdp.exe", false},
+		{"görünmez yön işareti", "a\u200Fb\u2066c.txt", "a_b_c.txt", false},
+		{"kontrol karakteri", "satir\nsonu\t.txt", "satir_sonu_.txt", false},
+		{"NFD", "cafe\u0301.txt", "caf\u00e9.txt", false},
+		{"sondaki nokta ve boşluk", "rapor. . ", "rapor", false},
+		{"boş", "", "", true},
+		{"yalnızca nokta", "..", "", true},
+		{"yalnızca ayraç", "///", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sanitizeFileName(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidFileName) {
+					t.Errorf("sanitizeFileName(%q) = %q, %v; errInvalidFileName bekleniyordu", tt.input, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("sanitizeFileName(%q) = %q, %v; %q bekleniyordu", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFileNameTruncatesLongNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantExt string
+	}{
+		{"iki baytlı", strings.Repeat("ş", 200) + ".txt", ".txt"},
+		{"üç baytlı tek bayt kaydırmalı", "a" + strings.Repeat("€", 120) + ".pdf", ".pdf"},
+		{"dört baytlı", strings.Repeat("😀", 80) + ".jpeg", ".jpeg"},
+		{"uzantısız", strings.Repeat("ğ", 300), ""},
+		{"çok uzun uzantı", "a." + strings.Repeat("ü", 200), ""},
+		{"NFD", strings.Repeat("e\u0301", 150) + ".md", ".md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sanitizeFileName(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) > maxFileNameBytes {
+				t.Errorf("ad %d bayt, en fazla %d bekleniyordu", len(got), maxFileNameBytes)
+			}
+			if len(got) < maxFileNameBytes-4 {
+				t.Errorf("ad gereğinden fazla kısaltıldı: %d bayt", len(got))
+			}
+			if !utf8.ValidString(got) || strings.ContainsRune(got, utf8.RuneError) {
+				t.Errorf("ad çok baytlı bir karakterin ortasından kesildi: %q", got)
+			}
+			if tt.wantExt != "" && filepath.Ext(got) != tt.wantExt {
+				t.Errorf("uzantı = %q, %q bekleniyordu", filepath.Ext(got), tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestSafeRelativePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"boş", "", "", nil},
+		{"klasör", "Dokümanlar/2024", "Dokümanlar/2024", nil},
+		{"ters bölü", `Dokümanlar\2024\rapor.pdf`, "Dokümanlar/2024/rapor.pdf", nil},
+		{"gereksiz bileşenler", "./a//b/./", "a/b", nil},
+		{"baştaki ayraç ana klasördür", "/etc", "etc", nil},
+		{"üst klasör", "..", "", errOutsideBaseDir},
+		{"içeride başlayıp dışarı çıkan", "a/../../x", "", errOutsideBaseDir},
+		{"geri dönen", "a/../b", "", errOutsideBaseDir},
+		{"ters bölü ile üst klasör", `a\..\..\x`, "", errOutsideBaseDir},
+		{"sürücü yolu", `C:\x`, "", errOutsideBaseDir},
+		{"göreli sürücü yolu", "c:x", "", errOutsideBaseDir},
+		{"NUL karakteri", "a\x00b/c", "a_b/c", nil},
+		{"alternatif veri akışı", "a/dosya.txt:gizli", "a/dosya.txt_gizli", nil},
+		{"ayrılmış ad", "CON/nul.txt", "_CON/_nul.txt", nil},
+		{"yön değiştirme", "a/\u202Eb", "a/_b", nil},
+		{"NFD", "Belgeler/cafe\u0301", "Belgeler/caf\u00e9", nil},
+		{"geçersiz bileşen", "a/...", "", errInvalidFileName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := safeRelativePath(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("safeRelativePath(%q) = %q, %v; %v bekleniyordu", tt.input, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("safeRelativePath(%q) = %q, %v; %q bekleniyordu", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveInBase(t *testing.T) {
+	setupTestEnv(t)
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(config.BaseDir, "Belgeler"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	links := true
+	for name, target := range map[string]string{
+		"disari":  outside,
+		"icerisi": filepath.Join(config.BaseDir, "Belgeler"),
+		"kopuk":   filepath.Join(outside, "yok"),
+	} {
+		if err := os.Symlink(target, filepath.Join(config.BaseDir, name)); err != nil {
+			links = false
+		}
+	}
+
+	tests := []struct {
+		name    string
+		rel     string
+		want    string // ana klasöre göre, `/` ayraçlı
+		outside bool   // yol reddedilmeli
+		symlink bool
+	}{
+		{"dosya", "a/b.txt", "a/b.txt", false, false},
+		{"ana klasör", "", "", false, false},
+		{"geri dönen", "a/../b.txt", "b.txt", false, false},
+		{"baştaki ayraç", "/etc/passwd", "etc/passwd", false, false},
+		{"ters bölü", `a\b.txt`, "a/b.txt", false, false},
+		{"NFD", "cafe\u0301.txt", "caf\u00e9.txt", false, false},
+		{"üst klasör", "..", "", true, false},
+		{"üst klasördeki dosya", "../x", "", true, false},
+		{"içeride başlayıp dışarı çıkan", "a/../../x", "", true, false},
+		{"ters bölü ile üst klasör", `a\..\..\x`, "", true, false},
+		{"sürücü yolu", `C:\x`, "", true, false},
+		{"NUL karakteri", "a\x00b", "", true, false},
+		{"alternatif veri akışı", "dosya.txt:gizli", "", true, false},
+		{"dışarıyı gösteren bağlantı", "disari/x.txt", "", true, true},
+		{"dışarıyı gösteren bağlantının kendisi", "disari", "", true, true},
+		{"içeriyi gösteren bağlantı", "icerisi/x.txt", "icerisi/x.txt", false, true},
+		{"hedefi olmayan bağlantı", "kopuk", "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.symlink && !links {
+				t.Skip("sembolik bağlantı oluşturulamıyor")
+			}
+			got, err := resolveInBase(tt.rel)
+			if tt.outside {
+				if !errors.Is(err, errOutsideBaseDir) {
+					t.Errorf("resolveInBase(%q) = %q, %v; errOutsideBaseDir bekleniyordu", tt.rel, got, err)
+				}
+				return
+			}
+			want := filepath.Join(config.BaseDir, filepath.FromSlash(tt.want))
+			if err != nil || got != want {
+				t.Errorf("resolveInBase(%q) = %q, %v; %q bekleniyordu", tt.rel, got, err, want)
+			}
+		})
+	}
+}
+
+func TestFileKeyRoundTrip(t *testing.T) {
+	setupTestEnv(t)
+	tests := []struct {
+		name string
+		rel  string
+	}{
+		{"düz ad", "rapor.pdf"},
+		{"alt klasör", "Belgeler/2024/rapor.pdf"},
+		{"iki noktayla başlayan ad", ".._.._disari.txt"},
+		{"iki noktayla başlayan klasör", "..yedek/rapor.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			abs := filepath.Join(config.BaseDir, filepath.FromSlash(tt.rel))
+			key := fileKey(abs)
+			if key != tt.rel {
+				t.Fatalf("fileKey(%q) = %q, %q bekleniyordu", abs, key, tt.rel)
+			}
+			if got, ok := pathOfKey(key); !ok || got != abs {
+				t.Errorf("pathOfKey(%q) = %q, %v; %q bekleniyordu", key, got, ok, abs)
+			}
+		})
+	}
+
+	outside := filepath.Join(filepath.Dir(config.BaseDir), "disari.txt")
+	if key := fileKey(outside); key != filepath.ToSlash(outside) {
+		t.Errorf("ana klasör dışındaki yolun kimliği = %q, mutlak yol bekleniyordu", key)
+	}
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -19,13 +20,19 @@ import (
 // Bu dosya, belirli aralıklarla tekrarlanan (saatlik rapor gibi) ve anlık
 // olaylara tepki veren (dosya sistemindeki değişiklikler gibi) görevleri yönetir.
 
+// organizeDebounce, ana klasöre gelen dosyalar düzenlenmeden önce son dosya
+// olayından sonra beklenecek süredir. Yazılmakta olan dosyalar yarım halde
+// taşınmaz ve dosyanın kaynağı (bkz. `recordFileOrigin`) kaydedilmiş olur.
+const organizeDebounce = 5 * time.Second
+
 var (
 	magicFilesProcessed = make(map[string]bool)
 	magicFilesMutex     = &sync.Mutex{}
 )
 
 // runScheduler, botun uzun vadeli ve olay bazlı görev döngüsünü başlatır.
-func runScheduler(bot *tgbotapi.BotAPI) {
+// `ctx` iptal edildiğinde (bkz. `lifecycle.go`) döngü ve dosya izleyici kapanır.
+func runScheduler(ctx context.Context, bot Messenger) {
 	if config.AdminChatID == 0 {
 		log.Println("Uyarı: Yönetici Chat ID'si ayarlanmadığı için zamanlayıcı başlatılamıyor.")
 		return
@@ -46,14 +53,28 @@ func runScheduler(bot *tgbotapi.BotAPI) {
 	watcher.Add(config.BaseDir)
 	watcher.Add(magicFolderPath)
 
-	go organizeFiles()
+	goBackground(func() { organizeFiles(bot, systemUserID) })
 
+	var organizeDelay <-chan time.Time
 	for {
 		select {
+		case <-ctx.Done():
+			log.Println("Zamanlayıcı ve Dosya İzleyici durduruldu.")
+			return
+
 		case <-hourlyTicker.C:
 			log.Println("Saatlik görevler çalışıyor...")
-			organizeFiles()
+			organizeFiles(bot, systemUserID)
 			sendAutomaticSystemInfo(bot)
+			expireTemporaryAccess(bot)
+			purgeExpiredTrash()
+			purgeStaleSplitParts()
+			purgeStaleIncoming()
+			purgeExpiredVersions()
+
+		case <-organizeDelay:
+			organizeDelay = nil
+			goBackground(func() { organizeFiles(bot, systemUserID) })
 
 		case event, ok := <-watcher.Events:
 			if !ok {
@@ -64,6 +85,11 @@ func runScheduler(bot *tgbotapi.BotAPI) {
 				if err != nil || info.IsDir() {
 					continue
 				}
+				// * Yazılmakta olan yüklemeler izlenmez; tamamlanınca asıl adına
+				// * taşınır ve bu da ayrı bir olay olarak gelir.
+				if isIncomingTemp(info.Name()) {
+					continue
+				}
 				if filepath.Dir(event.Name) == magicFolderPath {
 					magicFilesMutex.Lock()
 					if _, processing := magicFilesProcessed[event.Name]; processing {
@@ -73,10 +99,11 @@ func runScheduler(bot *tgbotapi.BotAPI) {
 					magicFilesProcessed[event.Name] = true
 					magicFilesMutex.Unlock()
 					log.Printf("[Magic Folder] Yeni dosya işlem kuyruğuna alındı: %s", event.Name)
-					go sendAndDeleteFile(bot, event.Name)
+					filePath := event.Name
+					goBackground(func() { sendAndDeleteFile(bot, filePath) })
 				} else if filepath.Dir(event.Name) == config.BaseDir {
 					log.Printf("[Gelenler] Yeni dosya algılandı: %s", event.Name)
-					go organizeFiles()
+					organizeDelay = time.After(organizeDebounce)
 				}
 			}
 
@@ -90,7 +117,7 @@ func runScheduler(bot *tgbotapi.BotAPI) {
 }
 
 // sendAndDeleteFile, "Magic Folder" içine atılan dosyayı gönderir ve siler.(silmesini istemiyorsanız fonksiyonu değiştirebilirsiniz.)
-func sendAndDeleteFile(bot *tgbotapi.BotAPI, filePath string) {
+func sendAndDeleteFile(bot Messenger, filePath string) {
 	defer func() {
 		magicFilesMutex.Lock()
 		delete(magicFilesProcessed, filePath)
@@ -116,7 +143,7 @@ func sendAndDeleteFile(bot *tgbotapi.BotAPI, filePath string) {
 }
 
 // sendAutomaticSystemInfo, saatlik olarak sistem durumu ve hız testi raporu gönderir.
-func sendAutomaticSystemInfo(bot *tgbotapi.BotAPI) {
+func sendAutomaticSystemInfo(bot Messenger) {
 	if config.AdminChatID == 0 {
 		return
 	}
@@ -114,15 +114,13 @@ func getFileStats() (int, int) {
 		}
 		return nil
 	})
-	metadataMutex.Lock()
-	descriptionCount := len(fileMetadata)
-	metadataMutex.Unlock()
+	descriptionCount := countDescriptions()
 	return fileCount, descriptionCount
 }
 
 // runSpeedTest, `speedtest.exe` komut satırı aracını çalıştırır,
 func runSpeedTest() (*SpeedTestResult, error) {
-	cmd := exec.Command("speedtest", "--format", "json", "--accept-license", "--accept-gdpr")
+	cmd := exec.CommandContext(jobsCtx, "speedtest", "--format", "json", "--accept-license", "--accept-gdpr")
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 
 	var out, stderr bytes.Buffer
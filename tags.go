@@ -0,0 +1,490 @@
+// tags.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                                ETİKETLER
+// #############################################################################
+// Bu dosya, dosyalara birden fazla etiket eklenmesini ve etiketlere göre
+// arama yapılmasını sağlar. Etiketler açıklamalarla birlikte metadata
+// deposunda saklanır (bkz. `metadata_manager.go`).
+//
+// Etiket ifadeleri şu sözdizimini kullanır:
+//   fatura 2024          -> ikisi de (yan yana yazılan etiketler VE ile bağlanır)
+//   fatura VEYA makbuz   -> en az biri (OR, | da kullanılabilir)
+//   fatura DEĞİL eski    -> fatura olup eski olmayan (NOT, -etiket, !etiket)
+//   (fatura VEYA makbuz) VE 2024
+// `/liste`, `/klasor` ve `/ara` komutlarında `#` ile başlayan ilk kelimeden
+// itibaren yazılanlar etiket filtresi olarak yorumlanır: `/ara rapor #2024 -#eski`
+
+// maxTagLength, bir etiketin en fazla karakter sayısıdır.
+const maxTagLength = 32
+
+// normalizeTag, bir etiketi küçük harfe çevirir ve baştaki `#` işaretini
+// kaldırır. Harf, rakam, `-` ve `_` dışındaki karakterler içeren etiketler
+// geçersizdir.
+func normalizeTag(raw string) (string, error) {
+	tag := lowerTagWord(strings.TrimPrefix(strings.TrimSpace(raw), "#"))
+	if tag == "" {
+		return "", fmt.Errorf("boş etiket")
+	}
+	if len([]rune(tag)) > maxTagLength {
+		return "", fmt.Errorf("`%s` etiketi çok uzun (en fazla %d karakter)", tag, maxTagLength)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", fmt.Errorf("`%s` etiketi geçersiz karakter içeriyor", tag)
+		}
+	}
+	if isTagOperator(tag) {
+		return "", fmt.Errorf("`%s` ayrılmış bir kelimedir", tag)
+	}
+	return tag, nil
+}
+
+// parseTagList, boşluk veya virgülle ayrılmış etiketleri normalleştirir.
+func parseTagList(raw string) ([]string, error) {
+	var tags []string
+	for _, field := range strings.FieldsFunc(raw, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		tag, err := normalizeTag(field)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// suggestTags, dosyanın kategorisinden ve uzantısından etiket önerileri üretir.
+func suggestTags(path string) []string {
+	var suggestions []string
+	if category := categoryOfPath(path); category != rootCategoryName {
+		if tag, err := normalizeTag(category); err == nil {
+			suggestions = append(suggestions, tag)
+		}
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext != "" {
+		if tag, err := normalizeTag(ext); err == nil && !containsString(suggestions, tag) {
+			suggestions = append(suggestions, tag)
+		}
+	}
+	return suggestions
+}
+
+// formatTags, etiketleri `#etiket` biçiminde yan yana yazar.
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+	return "#" + strings.Join(tags, " #")
+}
+
+// #############################################################################
+// #                             ETİKET İFADELERİ
+// #############################################################################
+
+// tagExpr, bir dosyanın etiket kümesine karşı değerlendirilen ifadedir.
+type tagExpr interface {
+	match(tags map[string]bool) bool
+}
+
+type tagTerm string
+type tagNot struct{ expr tagExpr }
+type tagAnd struct{ left, right tagExpr }
+type tagOr struct{ left, right tagExpr }
+
+func (t tagTerm) match(tags map[string]bool) bool { return tags[string(t)] }
+func (n tagNot) match(tags map[string]bool) bool  { return !n.expr.match(tags) }
+func (a tagAnd) match(tags map[string]bool) bool  { return a.left.match(tags) && a.right.match(tags) }
+func (o tagOr) match(tags map[string]bool) bool   { return o.left.match(tags) || o.right.match(tags) }
+
+// isTagOperator, kelimenin bir ifade operatörü olup olmadığını belirler.
+func isTagOperator(word string) bool {
+	switch lowerTagWord(word) {
+	case "ve", "and", "veya", "or", "değil", "degil", "not":
+		return true
+	}
+	return false
+}
+
+// lowerTagWord, kelimeyi küçük harfe çevirir. `DEĞİL` yazımında `İ` harfinin
+// noktalı `i̇` yerine `i` olması için önce ayrıca dönüştürülür.
+func lowerTagWord(word string) string {
+	return strings.ToLower(strings.ReplaceAll(word, "İ", "i"))
+}
+
+// tagParser, etiket ifadelerini özyinelemeli iniş (recursive descent) ile çözümler.
+// Öncelik sırası: DEĞİL > VE > VEYA.
+type tagParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseTagExpr, bir etiket ifadesini çözümler.
+func parseTagExpr(input string) (tagExpr, error) {
+	p := &tagParser{tokens: tokenizeTagExpr(input)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("boş etiket ifadesi")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("beklenmeyen ifade: `%s`", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// tokenizeTagExpr, ifadeyi kelimelere, parantezlere ve ön ek operatörlere ayırır.
+func tokenizeTagExpr(input string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(input) {
+		for strings.HasPrefix(field, "(") || strings.HasPrefix(field, "-") || strings.HasPrefix(field, "!") {
+			tokens = append(tokens, field[:1])
+			field = field[1:]
+		}
+		closing := 0
+		for strings.HasSuffix(field, ")") {
+			field = field[:len(field)-1]
+			closing++
+		}
+		if field == "&" || field == "&&" {
+			field = "ve"
+		} else if field == "|" || field == "||" {
+			field = "veya"
+		}
+		if field != "" {
+			tokens = append(tokens, field)
+		}
+		for ; closing > 0; closing-- {
+			tokens = append(tokens, ")")
+		}
+	}
+	return tokens
+}
+
+func (p *tagParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return lowerTagWord(p.tokens[p.pos])
+	}
+	return ""
+}
+
+func (p *tagParser) parseOr() (tagExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok == "veya" || tok == "or"; tok = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = tagOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *tagParser) parseAnd() (tagExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok == "ve" || tok == "and" {
+			p.pos++
+		} else if tok == "" || tok == ")" || tok == "veya" || tok == "or" {
+			return left, nil
+		}
+		// * Operatörsüz yan yana yazılan etiketler VE ile bağlanır.
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = tagAnd{left, right}
+	}
+}
+
+func (p *tagParser) parseUnary() (tagExpr, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("ifade eksik bitti")
+	case tok == "-" || tok == "!" || tok == "değil" || tok == "degil" || tok == "not":
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return tagNot{expr}, nil
+	case tok == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("kapanmayan parantez")
+		}
+		p.pos++
+		return expr, nil
+	case tok == ")" || isTagOperator(tok):
+		return nil, fmt.Errorf("beklenmeyen `%s`", p.tokens[p.pos])
+	}
+	p.pos++
+	tag, err := normalizeTag(tok)
+	if err != nil {
+		return nil, err
+	}
+	return tagTerm(tag), nil
+}
+
+// splitTagFilter, komut argümanlarını normal argümanlar ve `#` ile başlayan
+// ilk kelimeden itibaren gelen etiket ifadesi olarak ikiye ayırır.
+func splitTagFilter(args string) (string, string) {
+	fields := strings.Fields(args)
+	for i, field := range fields {
+		if strings.Contains(field, "#") {
+			return strings.Join(fields[:i], " "), strings.Join(fields[i:], " ")
+		}
+	}
+	return strings.TrimSpace(args), ""
+}
+
+// tagFilter, dosya yollarını bir etiket ifadesine göre süzer.
+type tagFilter struct {
+	expr     tagExpr
+	fileTags map[string][]string
+}
+
+// newTagFilter, ifade boşsa `nil` döndürür; `nil` filtre her dosyayı kabul eder.
+func newTagFilter(expression string) (*tagFilter, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+	expr, err := parseTagExpr(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &tagFilter{expr: expr, fileTags: listFileTags()}, nil
+}
+
+// Match, mutlak yolu verilen dosyanın ifadeyle eşleşip eşleşmediğini döndürür.
+func (f *tagFilter) Match(absPath string) bool {
+	if f == nil {
+		return true
+	}
+	return f.matchKey(fileKey(absPath))
+}
+
+func (f *tagFilter) matchKey(key string) bool {
+	set := make(map[string]bool)
+	for _, tag := range f.fileTags[key] {
+		set[tag] = true
+	}
+	return f.expr.match(set)
+}
+
+// #############################################################################
+// #                              ETİKET KOMUTLARI
+// #############################################################################
+
+// handleAddTagsCommand, /etiket_ekle komutuyla bir dosyaya etiket ekler.
+// Etiket verilmezse dosya için önerilen etiketleri gösterir.
+func handleAddTagsCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	args := strings.TrimSpace(message.CommandArguments())
+	if args == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/etiket_ekle <dosya> [etiket...]`\nEtiket verilmezse önerilen etiketler gösterilir."))
+		return
+	}
+	parts := strings.SplitN(args, " ", 2)
+	rawTags, rest := "", ""
+	if len(parts) == 2 {
+		rawTags = parts[1]
+		rest = " " + rawTags
+	}
+	filePath, ok := resolveFileForCommand(bot, message, parts[0], rest, fmt.Sprintf("❌ `%s` dosyası bulunamadı!", parts[0]))
+	if !ok {
+		return
+	}
+	key := fileKey(filePath)
+	suggestions := suggestTags(filePath)
+
+	if strings.TrimSpace(rawTags) == "" {
+		current := getTags(key)
+		var fresh []string
+		for _, tag := range suggestions {
+			if !containsString(current, tag) {
+				fresh = append(fresh, tag)
+			}
+		}
+		text := fmt.Sprintf("🏷️ `%s`\nMevcut etiketler: %s", key, formatTags(current))
+		if len(fresh) > 0 {
+			text += fmt.Sprintf("\n\n💡 Önerilen etiketler: %s\nEklemek için: `/etiket_ekle %s %s`", formatTags(fresh), key, strings.Join(fresh, " "))
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	tags, err := parseTagList(rawTags)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+	current, err := addTags(key, tags)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Etiketler eklenirken bir hata oluştu."))
+		return
+	}
+	text := fmt.Sprintf("✅ *Etiketler güncellendi!*\n\n📄 *Dosya:* `%s`\n🏷️ *Etiketler:* %s", key, formatTags(current))
+	var fresh []string
+	for _, tag := range suggestions {
+		if !containsString(current, tag) {
+			fresh = append(fresh, tag)
+		}
+	}
+	if len(fresh) > 0 {
+		text += fmt.Sprintf("\n\n💡 Önerilen: %s", formatTags(fresh))
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// handleRemoveTagsCommand, /etiket_sil komutuyla bir dosyadan etiket kaldırır.
+func handleRemoveTagsCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	parts := strings.SplitN(strings.TrimSpace(message.CommandArguments()), " ", 2)
+	if len(parts) < 2 {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Kullanım: `/etiket_sil <dosya> <etiket...>`"))
+		return
+	}
+	tags, err := parseTagList(parts[1])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+		return
+	}
+
+	// Dosya artık diskte yoksa etiketler doğrudan verilen anahtarla silinir.
+	key := parts[0]
+	if len(findFileMatches(parts[0])) > 0 {
+		filePath, ok := resolveFileForCommand(bot, message, parts[0], " "+parts[1], "")
+		if !ok {
+			return
+		}
+		key = fileKey(filePath)
+	} else if path, ok := pathOfKey(key); ok && !canAccessPath(message.From.ID, path) {
+		bot.Send(tgbotapi.NewMessage(chatID, categoryDeniedText))
+		return
+	}
+
+	remaining, err := removeTags(key, tags)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` için etiket bulunamadı.", key)))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ `%s` etiketleri güncellendi.\n🏷️ Kalan etiketler: %s", key, formatTags(remaining))))
+}
+
+// handleListTagsCommand, /etiketler komutunu işler:
+//   - argümansız: tüm etiketleri kullanım sayılarıyla listeler,
+//   - dosya adı veya yolu (nokta ya da `/` içerir): dosyanın etiketlerini gösterir,
+//   - diğer durumlarda: argümanı etiket ifadesi olarak yorumlayıp eşleşen dosyaları listeler.
+func handleListTagsCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+	args := strings.TrimSpace(message.CommandArguments())
+
+	// * Etiketler nokta içeremediği için nokta veya yol içeren argüman bir dosyadır.
+	if args != "" && (strings.Contains(args, ".") || isPathQuery(args)) {
+		filePath, ok := resolveFileForCommand(bot, message, args, "", fmt.Sprintf("❌ `%s` dosyası bulunamadı!", args))
+		if !ok {
+			return
+		}
+		key := fileKey(filePath)
+		text := fmt.Sprintf("🏷️ `%s`\nEtiketler: %s", key, formatTags(getTags(key)))
+		if suggestions := suggestTags(filePath); len(suggestions) > 0 {
+			text += fmt.Sprintf("\n💡 Önerilen: %s", formatTags(suggestions))
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	fileTags := listFileTags()
+	visible := make(map[string][]string)
+	for key, tags := range fileTags {
+		if path, ok := pathOfKey(key); !ok || canAccessPath(userID, path) {
+			visible[key] = tags
+		}
+	}
+
+	if args == "" {
+		counts := make(map[string]int)
+		for _, tags := range visible {
+			for _, tag := range tags {
+				counts[tag]++
+			}
+		}
+		if len(counts) == 0 {
+			bot.Send(tgbotapi.NewMessage(chatID, "🏷️ Henüz hiçbir dosyaya etiket eklenmemiş. Eklemek için: `/etiket_ekle <dosya> <etiket...>`"))
+			return
+		}
+		names := make([]string, 0, len(counts))
+		for tag := range counts {
+			names = append(names, tag)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if counts[names[i]] != counts[names[j]] {
+				return counts[names[i]] > counts[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("🏷️ Etiketler (%d adet):\n\n", len(names)))
+		for _, tag := range names {
+			builder.WriteString(fmt.Sprintf("#%s (%d)\n", tag, counts[tag]))
+		}
+		builder.WriteString("\n💡 Aramak için: /etiketler fatura VEYA makbuz")
+		bot.Send(tgbotapi.NewMessage(chatID, builder.String()))
+		return
+	}
+
+	expr, err := parseTagExpr(args)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Etiket ifadesi hatalı: %v", err)))
+		return
+	}
+	filter := &tagFilter{expr: expr, fileTags: visible}
+	var keys []string
+	for key := range visible {
+		if filter.matchKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ `%s` ifadesiyle eşleşen dosya bulunamadı.", args)))
+		return
+	}
+	sort.Strings(keys)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🏷️ `%s` için %d dosya:\n\n", args, len(keys)))
+	for _, key := range keys {
+		builder.WriteString(fmt.Sprintf("📄 `%s`\n   %s\n", key, formatTags(visible[key])))
+	}
+	for _, chunk := range splitMessageSmart(builder.String()) {
+		bot.Send(tgbotapi.NewMessage(chatID, chunk))
+	}
+}
@@ -2,164 +2,163 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"log"
 	"mime"
-	"net/http"
-	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// DÜZENLENDİ: Bu fonksiyon artık LLM modunu en öncelikli olarak kontrol eder.
-func handleUpdates(bot *tgbotapi.BotAPI, updates tgbotapi.UpdatesChannel) {
-	for update := range updates {
-		var userID int64
-		var chatID int64
-		var fromUserName string
+// handleUpdates, güncelleme kanalını okur ve her güncellemeyi sohbet bazında
+// sıralı işlenmek üzere dağıtıcıya (bkz. `dispatcher.go`) verir. Kanal
+// kapandığında veya `ctx` iptal edildiğinde yeni güncelleme almayı bırakır,
+// kuyruktaki işlerin bitmesini bekleyip döner.
+func handleUpdates(ctx context.Context, bot Messenger, updates tgbotapi.UpdatesChannel) {
+	dispatcher := newUpdateDispatcher(bot, config.DispatcherWorkers, config.DispatcherUserLimit, func(update tgbotapi.Update) {
+		processUpdate(bot, update)
+	})
+	defer dispatcher.Close()
 
-		if update.Message != nil {
-			userID = update.Message.From.ID
-			chatID = update.Message.Chat.ID
-			fromUserName = update.Message.From.UserName
-			// Loglama sadece LLM modu dışındayken veya komut ise yapılır.
-			if !isUserInLlmMode(userID) || update.Message.IsCommand() {
-				log.Printf("[%s] %s", fromUserName, update.Message.Text)
+	for {
+		var update tgbotapi.Update
+		select {
+		case <-ctx.Done():
+			return
+		case u, ok := <-updates:
+			if !ok {
+				return
 			}
-		} else if update.CallbackQuery != nil {
-			userID = update.CallbackQuery.From.ID
-			chatID = update.CallbackQuery.Message.Chat.ID
-			fromUserName = update.CallbackQuery.From.UserName
-			log.Printf("[%s] Callback: %s", fromUserName, update.CallbackQuery.Data)
-		} else {
-			continue
+			update = u
 		}
 
-		if !isUserAllowed(userID) {
-			log.Printf("⚠️ YETKİSİZ ERİŞİM DENEMESİ! Kullanıcı: %s (%d)", fromUserName, userID)
-			bot.Send(tgbotapi.NewMessage(chatID, "🚫 Bu botu kullanma yetkiniz bulunmuyor."))
+		var userID, chatID int64
+		switch {
+		case update.Message != nil:
+			userID, chatID = update.Message.From.ID, update.Message.Chat.ID
+		case update.CallbackQuery != nil:
+			userID, chatID = update.CallbackQuery.From.ID, update.CallbackQuery.Message.Chat.ID
+		default:
 			continue
 		}
+		if !dispatcher.Submit(update, chatID, userID) {
+			log.Printf("⚠️ Eş zamanlı istek sınırı aşıldı, güncelleme reddedildi. Kullanıcı: %d", userID)
+			go bot.Send(tgbotapi.NewMessage(chatID, "⏳ Çok fazla bekleyen isteğiniz var. Lütfen öncekilerin bitmesini bekleyin."))
+		}
+	}
+}
 
-		if update.Message != nil {
-			if isUserInLlmMode(userID) {
-				if update.Message.IsCommand() && update.Message.Command() == "llm_kapat" {
-					handleLlmOffCommand(bot, update.Message)
-				} else {
-					go handleLlmQuery(bot, update.Message)
-				}
-				continue 
-			}
+// processUpdate, tek bir güncellemeyi yetki kontrolünden geçirip ilgili
+// işleyiciye yönlendirir. Dağıtıcının worker'ları tarafından çağrılır.
+// LLM modu en öncelikli olarak kontrol edilir.
+func processUpdate(bot Messenger, update tgbotapi.Update) {
+	var userID int64
+	var chatID int64
+	var fromUserName string
+
+	if update.Message != nil {
+		userID = update.Message.From.ID
+		chatID = update.Message.Chat.ID
+		fromUserName = update.Message.From.UserName
+		// Loglama sadece LLM modu dışındayken veya komut ise yapılır.
+		if !isUserInLlmMode(userID) || update.Message.IsCommand() {
+			log.Printf("[%s] %s", fromUserName, update.Message.Text)
+		}
+	} else if update.CallbackQuery != nil {
+		userID = update.CallbackQuery.From.ID
+		chatID = update.CallbackQuery.Message.Chat.ID
+		fromUserName = update.CallbackQuery.From.UserName
+		log.Printf("[%s] Callback: %s", fromUserName, update.CallbackQuery.Data)
+	} else {
+		return
+	}
+
+	if !isUserAllowed(userID) {
+		// * Kayıtlı olmayan kullanıcılar yalnızca `/start <kod>` ile davet kullanabilir.
+		if update.Message != nil && update.Message.IsCommand() && update.Message.Command() == "start" && update.Message.CommandArguments() != "" {
+			handleInviteRedeem(bot, update.Message)
+			return
+		}
+		log.Printf("⚠️ YETKİSİZ ERİŞİM DENEMESİ! Kullanıcı: %s (%d)", fromUserName, userID)
+		bot.Send(tgbotapi.NewMessage(chatID, "🚫 Bu botu kullanma yetkiniz bulunmuyor."))
+		return
+	}
 
-			if update.Message.IsCommand() {
-				handleCommand(bot, update.Message)
+	if update.Message != nil {
+		// * Gezginin beklediği yanıtlar (açıklama, yeni ad, hedef klasör)
+		// LLM modunda da önce işlenir.
+		if handleBrowserReply(bot, update.Message) {
+			return
+		}
+		if isUserInLlmMode(userID) {
+			if update.Message.IsCommand() && update.Message.Command() == "llm_kapat" {
+				handleLlmOffCommand(bot, update.Message)
 			} else {
-				handleFile(bot, update.Message)
+				handleLlmQuery(bot, update.Message)
 			}
-		} else if update.CallbackQuery != nil {
-			handleCallbackQuery(bot, update.CallbackQuery)
+			return
 		}
+
+		if update.Message.IsCommand() {
+			handleCommand(bot, update.Message)
+		} else {
+			handleFile(bot, update.Message)
+		}
+	} else if update.CallbackQuery != nil {
+		handleCallbackQuery(bot, update.CallbackQuery)
 	}
 }
 
-func handleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+// handleCommand, gelen komutu kayıttan (bkz. `command_registry.go`) bulur,
+// yetkiyi ve argümanları doğrular, ardından komutun işleyicisini çağırır.
+func handleCommand(bot Messenger, message *tgbotapi.Message) {
 	command := message.Command()
+	args := message.CommandArguments()
+	start := time.Now()
 
-	adminOnlyCommands := map[string]bool{
-		"calistir":          true,
-		"kapat":             true,
-		"sistem_bilgisi":    true,
-		"kayit_al":          true,
-		"kayit_durdur":      true,
-		"ss":                true,
-		"gorevler":          true,
-		"uygulama_calistir": true,
-		"calistir_dosya":    true,
+	spec, found := lookupCommand(command)
+	if !found {
+		auditMessage(message, auditKindCommand, command, args, auditResultUnknown, nil, start)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Anlaşılmayan komut. Yardım için `/help` yazabilirsiniz.")
+		bot.Send(msg)
+		return
 	}
 
-	if _, isProtected := adminOnlyCommands[command]; isProtected {
-		if !isUserAdmin(message.From.ID) {
-			log.Printf("⚠️ YETKİSİZ KOMUT DENEMESİ! Kullanıcı: %s (%d), Komut: /%s", message.From.UserName, message.From.ID, command)
+	if !canRunCommand(message.From.ID, spec) {
+		log.Printf("⚠️ YETKİSİZ KOMUT DENEMESİ! Kullanıcı: %s (%d), Komut: /%s", message.From.UserName, message.From.ID, command)
+		auditMessage(message, auditKindCommand, spec.Name, args, auditResultDenied, nil, start)
+		if spec.Role == roleAdmin {
 			bot.Send(tgbotapi.NewMessage(message.Chat.ID, "🚫 Bu komutu sadece yönetici kullanabilir."))
-			return
+		} else {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, "🚫 Bu komutu kullanma yetkiniz bulunmuyor."))
 		}
+		return
 	}
 
-	switch command {
-	case "llm":
-		handleLlmOnCommand(bot, message)
-	case "llm_kapat":
-		if !isUserInLlmMode(message.From.ID) {
-			bot.Send(tgbotapi.NewMessage(message.Chat.ID, "ℹ️ Zaten LLM modunda değilsiniz."))
-		} else {
-			handleLlmOffCommand(bot, message)
-		}
-	case "start", "help", "duzenle", "sistem_bilgisi", "durum":
-		handleGeneralCommands(bot, message)
-	case "hiz_testi":
-		handleSpeedTestCommand(bot, message)
-	case "gorevler":
-		handleListProcessesCommand(bot, message)
-	case "calistir":
-		handleRunCommand(bot, message)
-	case "kapat":
-		handleKillCommand(bot, message)
-	case "ss":
-		handleScreenshotCommand(bot, message)
-	case "kayit_al":
-		handleStartRecordingCommand(bot, message)
-	case "kayit_durdur":
-		handleStopRecordingCommand(bot, message)
-	case "kes":
-		handleClipCommand(bot, message)
-	case "gif_yap":
-		handleGifCommand(bot, message)
-	case "portlar":
-		handlePortsCommand(bot, message)
-	case "getir":
-		handleGetFileCommand(bot, message)
-	case "aciklama_ekle":
-		handleAddDescriptionCommand(bot, message)
-	case "aciklama_sil":
-		handleRemoveDescriptionCommand(bot, message)
-	case "aciklamalar":
-		handleListDescriptionsCommand(bot, message)
-	case "aciklama_ara":
-		handleSearchDescriptionsCommand(bot, message)
-	case "liste":
-		handleListFilesCommand(bot, message)
-	case "klasor":
-		handleListCategoryCommand(bot, message)
-	case "ara":
-		handleSearchFilesCommand(bot, message)
-	case "sil":
-		handleDeleteFileCommand(bot, message)
-	case "yenidenadlandir":
-		handleRenameFileCommand(bot, message)
-	case "tasi":
-		handleMoveFileCommand(bot, message)
-	case "izle":
-		handleToggleInternetMonitorCommand(bot, message)
-	case "indir":
-		handleDownloadCommand(bot, message)
-	case "indir_ses":
-		handleAudioDownloadCommand(bot, message)
-	case "uygulama_calistir":
-		handleRunApplicationCommand(bot, message)
-	case "calistir_dosya":
-		handleRunPathCommand(bot, message)
-	default:
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Anlaşılmayan komut. Yardım için `/help` yazabilirsiniz.")
-		bot.Send(msg)
+	if err := spec.validateArgs(args); err != nil {
+		auditMessage(message, auditKindCommand, spec.Name, args, auditResultUsage, err, start)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Kullanım: `%s`", spec.Usage())))
+		return
 	}
+
+	// Sonuç, işleyicinin kullanıcıya verdiği yanıttan çıkarılır. Panik
+	// durumunda da kayıt yazılır; panik ardından yeniden fırlatılarak
+	// dispatcher'ın yığın izini loglayıp kullanıcıyı bilgilendirmesi sağlanır.
+	watcher := &auditingMessenger{Messenger: bot}
+	defer func() {
+		if r := recover(); r != nil {
+			auditMessage(message, auditKindCommand, spec.Name, args, auditResultError, fmt.Errorf("panik: %v", r), start)
+			panic(r)
+		}
+		result, err := watcher.outcome()
+		auditMessage(message, auditKindCommand, spec.Name, args, result, err, start)
+	}()
+	spec.Handler(watcher, message)
 }
 
-func handleFile(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleFile(bot Messenger, message *tgbotapi.Message) {
 	var fileID, fileName, mimeType string
 	var fileSize int64
 
@@ -182,117 +181,94 @@ func handleFile(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 		return
 	}
 
-	if fileName == "" {
-		exts, _ := mime.ExtensionsByType(mimeType)
-		if len(exts) > 0 {
-			fileName = fmt.Sprintf("file_%d%s", time.Now().Unix(), exts[0])
-		} else {
-			fileName = fmt.Sprintf("file_%d", time.Now().Unix())
+	// Telegram'ın bildirdiği ad olduğu gibi kullanılmaz; klasör ayraçları,
+	// geçersiz karakterler ve ayrılmış adlar temizlenir (bkz. `safe_path.go`).
+	if clean, err := sanitizeFileName(fileName); err == nil {
+		fileName = clean
+	} else {
+		// Uzantı, dosya kaydedildikten sonra içeriğinden belirlenir.
+		fileName = fmt.Sprintf("file_%d", time.Now().Unix())
+	}
+
+	// Genel Bot API sunucusu 20 MB'tan büyük dosyaları bota vermez; yerel
+	// sunucuda bu limit 2 GB'tır (bkz. `messenger.go`).
+	if fileSize > downloadLimit() {
+		text := fmt.Sprintf("❌ `%s` çok büyük (%s). Bot Telegram'dan en fazla %s indirebilir.", fileName, formatFileSize(fileSize), formatFileSize(downloadLimit()))
+		if !usingLocalBotAPI() {
+			text += "\n💡 Daha büyük dosyalar için yerel Bot API sunucusu kullanılabilir (`BOT_API_URL`, `BOT_API_LOCAL`)."
 		}
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+		return
+	}
+
+	body, err := openTelegramFile(bot, fileID)
+	if err != nil {
+		log.Printf("Dosya indirilemedi: %v", err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` Telegram'dan alınamadı: %v", fileName, err)))
+		return
 	}
+	defer body.Close()
 
-	fileURL, err := bot.GetFileDirectURL(fileID)
+	// Parçalı gönderilen dosyaların parçaları ana klasöre değil, birleştirilene
+	// kadar bekleme klasörüne kaydedilir (bkz. `file_split.go`).
+	if _, _, isPart := parseSplitName(fileName); isPart && message.Document != nil {
+		receiveSplitPart(bot, message, fileName, body)
+		return
+	}
+
+	savePath, err := resolveInBase(fileName)
 	if err != nil {
-		log.Printf("Dosya URL'si alınamadı: %v", err)
+		log.Printf("Dosya kaydedilemedi (%s): %v", fileName, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` kaydedilemedi: %v", fileName, err)))
 		return
 	}
-	resp, err := http.Get(fileURL)
+	// İçerik önce geçici dosyaya yazılır ve Telegram'ın bildirdiği boyutla
+	// karşılaştırılır; yarım kalan yükleme asıl adıyla hiç görünmez.
+	tempPath, err := receiveToTemp(filepath.Dir(savePath), body, fileSize)
 	if err != nil {
-		log.Printf("Dosya indirilemedi: %v", err)
+		log.Printf("Dosya kaydedilemedi (%s): %v", fileName, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` kaydedilemedi: %v", fileName, err)))
 		return
 	}
-	defer resp.Body.Close()
 
-	savePath := filepath.Join(config.BaseDir, fileName)
-	file, err := os.Create(savePath)
+	// Türü içerikten belirlenemeyen uzantısız dosyalarda Telegram'ın
+	// bildirdiği MIME türüne düşülür.
+	typed := detectTypeForName(tempPath, savePath)
+	if !typed.Known && filepath.Ext(savePath) == "" {
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			typed.Path = savePath + exts[0]
+		}
+	}
+
+	// Aynı adda dosya varsa `INCOMING_COLLISION` politikası uygulanır.
+	stored, asked, err := storeIncomingFile(bot, message.Chat.ID, message.From.ID, tempPath, typed.Path, sourceTelegram)
 	if err != nil {
-		log.Printf("Dosya oluşturulamadı: %v", err)
+		log.Printf("Dosya kaydedilemedi (%s): %v", fileName, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ `%s` kaydedilemedi: %v", fileName, err)))
 		return
+	} else if asked {
+		return
+	}
+	savePath = stored.Path
+	typed.Path = stored.Path
+	fileName = filepath.Base(savePath)
+	if err := recordFileOrigin(fileKey(savePath), sourceTelegram, message.From.ID); err != nil {
+		log.Printf("Dosyanın kaynağı kaydedilemedi: %v", err)
 	}
-	defer file.Close()
-	io.Copy(file, resp.Body)
 
 	log.Printf("Dosya kaydedildi: %s", fileName)
 	replyText := fmt.Sprintf(
 		"✅ *Dosya kaydedildi!*\n\n"+
 			"📄 *Ad:* `%s`\n"+
 			"📏 *Boyut:* %.1f KB\n"+
+			"%s%s"+
 			"📁 *Kategori:* %s\n\n"+
+			"%s"+
 			"💡 `/aciklama_ekle \"%s\" Açıklama...` ile not ekleyebilirsiniz.",
-		fileName, float64(fileSize)/1024, getFileCategory(fileName), fileName,
+		fileName, float64(fileSize)/1024, detectedTypeText(typed), incomingCollisionText(stored), getFileCategory(fileName),
+		duplicateWarningText(message.From.ID, savePath), fileName,
 	)
 	reply := tgbotapi.NewMessage(message.Chat.ID, replyText)
 	reply.ParseMode = "Markdown"
 	bot.Send(reply)
 }
-
-func handleCallbackQuery(bot *tgbotapi.BotAPI, callbackQuery *tgbotapi.CallbackQuery) {
-	callback := tgbotapi.NewCallback(callbackQuery.ID, "")
-	bot.Request(callback)
-
-	data := callbackQuery.Data
-	parts := strings.SplitN(data, "_", 2)
-	if len(parts) < 1 {
-		return
-	}
-
-	command := parts[0]
-	chatID := callbackQuery.Message.Chat.ID
-	messageID := callbackQuery.Message.MessageID
-
-	if command == "sil" {
-		silParts := strings.SplitN(data, "_", 3)
-		if len(silParts) < 3 {
-			return
-		}
-		action, payload := silParts[1], silParts[2]
-		var newText string
-		if action == "evet" {
-			filename := payload
-			filePath, found := findFile(filename)
-			if !found {
-				newText = fmt.Sprintf("❌ Hata: `%s` dosyası zaten silinmiş veya taşınmış.", filename)
-			} else if err := os.Remove(filePath); err != nil {
-				newText = fmt.Sprintf("❌ `%s` dosyası silinirken bir hata oluştu.", filename)
-			} else {
-				removeDescription(filename)
-				newText = fmt.Sprintf("🗑️ `%s` dosyası başarıyla silindi.", filename)
-			}
-		} else {
-			newText = "👍 Silme işlemi iptal edildi."
-		}
-		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-		editMsg.ParseMode = "Markdown"
-		bot.Send(editMsg)
-
-	} else if command == "gorevler" {
-		gorevParts := strings.Split(data, "_")
-		if len(gorevParts) != 5 {
-			return
-		}
-
-		action := gorevParts[1]
-		sortKey := gorevParts[2]
-		sortDir := gorevParts[3]
-		page, _ := strconv.Atoi(gorevParts[4])
-
-		if action == "sirala" {
-			page = 1
-		}
-
-		updatedMessage := createProcessListMessage(chatID, sortKey, sortDir, page)
-
-		editMsg := tgbotapi.NewEditMessageText(
-			chatID,
-			messageID,
-			updatedMessage.Text,
-		)
-		editMsg.ParseMode = "Markdown"
-		if updatedMessage.ReplyMarkup != nil {
-			markup := updatedMessage.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
-			editMsg.ReplyMarkup = &markup
-		}
-
-		bot.Request(editMsg)
-	}
-}
\ No newline at end of file
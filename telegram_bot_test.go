@@ -0,0 +1,320 @@
+// telegram_bot_test.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Testlerde kullanılan kullanıcılar; her biri farklı bir roldedir.
+const (
+	testAdminID    int64 = 1001
+	testOperatorID int64 = 1002
+	testViewerID   int64 = 1003
+	testStrangerID int64 = 1999
+)
+
+// setupTestEnv, her testi geçici bir klasörde, kendi kullanıcı, metadata ve
+// denetim dosyalarıyla çalıştırır. Test bitince genel durum eski haline
+// döner.
+func setupTestEnv(t *testing.T) *recordingMessenger {
+	t.Helper()
+	dir := t.TempDir()
+	savedConfig, savedStore := config, metadataStore
+	t.Cleanup(func() {
+		if metadataStore != nil && metadataStore != savedStore {
+			metadataStore.Close()
+		}
+		config, metadataStore = savedConfig, savedStore
+	})
+
+	config.BaseDir = filepath.Join(dir, "Gelenler")
+	config.UsersFilePath = filepath.Join(dir, "users.json")
+	config.AuditLogPath = filepath.Join(dir, "audit.jsonl")
+	config.JournalPath = filepath.Join(dir, "operations.json")
+	config.MetadataBackend = metadataBackendJSON
+	config.MetadataFilePath = filepath.Join(dir, "metadata.json")
+	config.TrashDir = filepath.Join(dir, "Cop")
+	config.PartsDir = filepath.Join(dir, "Parcalar")
+	config.VersionsDir = filepath.Join(dir, "Surumler")
+	config.IncomingCollision = collisionRename
+	config.BotAPIURL, config.BotAPILocal = "", false
+	config.AdminChatID = testAdminID
+	config.AllowedIDs = []int64{testOperatorID}
+	if err := os.MkdirAll(config.BaseDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadUsers(); err != nil {
+		t.Fatal(err)
+	}
+	if err := addUser(testViewerID, roleViewer, "izleyici"); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadMetadata(); err != nil {
+		t.Fatal(err)
+	}
+	return newRecordingMessenger()
+}
+
+// newCommandMessage, Telegram'ın komut mesajlarındaki gibi bir `bot_command`
+// varlığı içeren mesaj oluşturur.
+func newCommandMessage(userID int64, text string) *tgbotapi.Message {
+	command, _, _ := strings.Cut(text, " ")
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: userID, UserName: "test"},
+		Chat:      &tgbotapi.Chat{ID: userID},
+		Text:      text,
+		Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: utf8.RuneCountInString(command)}},
+	}
+}
+
+// readAuditEntries, testin denetim dosyasındaki kayıtları okur.
+func readAuditEntries(t *testing.T) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(config.AuditLogPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// lastText, gönderilen son mesajın metnini döndürür.
+func lastText(t *testing.T, bot *recordingMessenger) string {
+	t.Helper()
+	texts := bot.Texts()
+	if len(texts) == 0 {
+		t.Fatal("hiç mesaj gönderilmedi")
+	}
+	return texts[len(texts)-1]
+}
+
+func TestHandleCommandDispatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     int64
+		text       string
+		wantText   string
+		wantAudit  string
+		wantLogged string
+	}{
+		{"bilinen komut", testViewerID, "/help", "Komut Seti", auditResultOK, "help"},
+		{"takma ad", testViewerID, "/yardim", "Komut Seti", auditResultOK, "help"},
+		{"bilinmeyen komut", testViewerID, "/yok_boyle", "Anlaşılmayan komut", auditResultUnknown, "yok_boyle"},
+		{"eksik argüman", testOperatorID, "/getir", "Kullanım", auditResultUsage, "getir"},
+		{"yönetici komutu", testViewerID, "/cop_bosalt", "sadece yönetici", auditResultDenied, "cop_bosalt"},
+		{"operatör komutu", testViewerID, "/sil rapor.pdf", "yetkiniz bulunmuyor", auditResultDenied, "sil"},
+		{"işleyici hatası", testOperatorID, "/getir yok.txt", "bulunamadı", auditResultError, "getir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := setupTestEnv(t)
+			handleCommand(bot, newCommandMessage(tt.userID, tt.text))
+
+			if got := lastText(t, bot); !strings.Contains(got, tt.wantText) {
+				t.Errorf("yanıt %q, %q içermiyor", got, tt.wantText)
+			}
+			entries := readAuditEntries(t)
+			if len(entries) != 1 {
+				t.Fatalf("%d denetim kaydı yazıldı, 1 bekleniyordu", len(entries))
+			}
+			if entries[0].Result != tt.wantAudit || entries[0].Name != tt.wantLogged || entries[0].UserID != tt.userID {
+				t.Errorf("denetim kaydı = %+v, sonuç %q ve ad %q bekleniyordu", entries[0], tt.wantAudit, tt.wantLogged)
+			}
+		})
+	}
+}
+
+func TestHandleCommandAuditsPanic(t *testing.T) {
+	bot := setupTestEnv(t)
+	commandIndex["test_panik"] = &CommandSpec{
+		Name:    "test_panik",
+		Handler: func(bot Messenger, message *tgbotapi.Message) { panic("beklenmeyen durum") },
+	}
+	t.Cleanup(func() { delete(commandIndex, "test_panik") })
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("panik dispatcher'a iletilmedi")
+			}
+		}()
+		handleCommand(bot, newCommandMessage(testViewerID, "/test_panik"))
+	}()
+
+	entries := readAuditEntries(t)
+	if len(entries) != 1 {
+		t.Fatalf("%d denetim kaydı yazıldı, 1 bekleniyordu", len(entries))
+	}
+	if entries[0].Result != auditResultError || !strings.Contains(entries[0].Error, "beklenmeyen durum") {
+		t.Errorf("denetim kaydı = %+v", entries[0])
+	}
+}
+
+func TestHelpListsOnlyPermittedCommands(t *testing.T) {
+	bot := setupTestEnv(t)
+	handleCommand(bot, newCommandMessage(testViewerID, "/help"))
+	viewerHelp := lastText(t, bot)
+	bot.Reset()
+	handleCommand(bot, newCommandMessage(testAdminID, "/help"))
+	adminHelp := lastText(t, bot)
+
+	if strings.Contains(viewerHelp, "/cop_bosalt") {
+		t.Error("izleyicinin yardım metninde yönetici komutu var")
+	}
+	if !strings.Contains(adminHelp, "/cop_bosalt") {
+		t.Error("yöneticinin yardım metninde /cop_bosalt yok")
+	}
+}
+
+func TestBotMenuListsOnlyPermittedCommands(t *testing.T) {
+	bot := setupTestEnv(t)
+	registerBotMenu(bot)
+
+	menus := map[int64][]string{}
+	var defaultMenu []string
+	for _, c := range bot.Requests {
+		cfg, ok := c.(tgbotapi.SetMyCommandsConfig)
+		if !ok {
+			continue
+		}
+		var names []string
+		for _, command := range cfg.Commands {
+			names = append(names, command.Command)
+		}
+		if cfg.Scope == nil {
+			defaultMenu = names
+		} else {
+			menus[cfg.Scope.ChatID] = names
+		}
+	}
+	has := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if has(defaultMenu, "start") || has(defaultMenu, "help") {
+		t.Error("varsayılan menüde gizli komut var")
+	}
+	if has(defaultMenu, "cop_bosalt") || has(menus[testViewerID], "cop_bosalt") {
+		t.Error("izleyicinin menüsünde yönetici komutu var")
+	}
+	if !has(menus[testAdminID], "cop_bosalt") {
+		t.Error("yöneticinin menüsünde /cop_bosalt yok")
+	}
+	if _, ok := menus[testStrangerID]; ok {
+		t.Error("kayıtsız kullanıcıya özel menü yüklendi")
+	}
+}
+
+func TestProcessUpdateRejectsUnknownUser(t *testing.T) {
+	bot := setupTestEnv(t)
+	processUpdate(bot, tgbotapi.Update{Message: newCommandMessage(testStrangerID, "/help")})
+
+	if got := lastText(t, bot); !strings.Contains(got, "Bu botu kullanma yetkiniz bulunmuyor") {
+		t.Errorf("yanıt = %q", got)
+	}
+	if entries := readAuditEntries(t); len(entries) != 0 {
+		t.Errorf("kayıtsız kullanıcının komutu çalıştırıldı: %+v", entries)
+	}
+}
+
+// newDocumentMessage, sahte Bot API sunucusuna eklenen bir dosyayı gönderen
+// belge mesajı oluşturur.
+func newDocumentMessage(t *testing.T, bot *recordingMessenger, server *fakeBotAPIServer, userID int64, fileID, name string, data []byte, size int) *tgbotapi.Message {
+	t.Helper()
+	server.AddFile(fileID, "documents/"+fileID, data)
+	bot.FileURLs[fileID] = server.URL + "/file/bot123456:TEST/documents/" + fileID
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: userID, UserName: "test"},
+		Chat:      &tgbotapi.Chat{ID: userID},
+		Document:  &tgbotapi.Document{FileID: fileID, FileName: name, FileSize: size, MimeType: "text/plain"},
+	}
+}
+
+func TestHandleFileSavesUpload(t *testing.T) {
+	bot := setupTestEnv(t)
+	server := newFakeBotAPIServer()
+	defer server.Close()
+
+	first := []byte("ilk sürüm\n")
+	handleFile(bot, newDocumentMessage(t, bot, server, testOperatorID, "f1", "notlar.txt", first, len(first)))
+	if got := lastText(t, bot); !strings.Contains(got, "Dosya kaydedildi") || !strings.Contains(got, "notlar.txt") {
+		t.Fatalf("yanıt = %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(config.BaseDir, "notlar.txt"))
+	if err != nil || string(data) != string(first) {
+		t.Fatalf("kaydedilen içerik = %q, %v", data, err)
+	}
+	if origin := getFileMetadata("notlar.txt").Source; origin != sourceTelegram {
+		t.Errorf("dosyanın kaynağı = %q, %q bekleniyordu", origin, sourceTelegram)
+	}
+
+	// Aynı adla gelen ikinci dosya varsayılan politikayla yeni adla kaydedilir.
+	second := []byte("ikinci sürüm\n")
+	handleFile(bot, newDocumentMessage(t, bot, server, testOperatorID, "f2", "notlar.txt", second, len(second)))
+	if data, err := os.ReadFile(filepath.Join(config.BaseDir, "notlar_1.txt")); err != nil || string(data) != string(second) {
+		t.Fatalf("ikinci dosya = %q, %v", data, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(config.BaseDir, "notlar.txt")); string(data) != string(first) {
+		t.Errorf("ilk dosyanın üzerine yazıldı: %q", data)
+	}
+}
+
+func TestHandleFileRejectsTruncatedUpload(t *testing.T) {
+	bot := setupTestEnv(t)
+	server := newFakeBotAPIServer()
+	defer server.Close()
+
+	data := []byte("yarım kalan içerik")
+	handleFile(bot, newDocumentMessage(t, bot, server, testOperatorID, "f1", "yarim.txt", data, len(data)+100))
+	if got := lastText(t, bot); !strings.Contains(got, "kaydedilemedi") {
+		t.Fatalf("yanıt = %q", got)
+	}
+	entries, err := os.ReadDir(config.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("yarım kalan yüklemeden dosya kaldı: %s", entry.Name())
+	}
+}
+
+func TestHandleFileSanitizesName(t *testing.T) {
+	bot := setupTestEnv(t)
+	server := newFakeBotAPIServer()
+	defer server.Close()
+
+	data := []byte("içerik")
+	handleFile(bot, newDocumentMessage(t, bot, server, testOperatorID, "f1", "../../disari.txt", data, len(data)))
+	if _, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(config.BaseDir)), "disari.txt")); err == nil {
+		t.Fatal("dosya ana klasörün dışına yazıldı")
+	}
+	if _, err := os.Stat(filepath.Join(config.BaseDir, ".._.._disari.txt")); err != nil {
+		t.Errorf("temizlenmiş adla kaydedilmedi: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// update_source.go
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                         GÜNCELLEME KAYNAKLARI
+// #############################################################################
+// Bu dosya, Telegram güncellemelerinin bota nasıl ulaşacağını belirler.
+// İki mod vardır ve ikisi de aynı `handleUpdates` akışını besler:
+//   - Uzun yoklama (long polling): Varsayılan moddur, `WEBHOOK_URL` boşsa
+//     kullanılır. Bot, Telegram'a sürekli "yeni bir şey var mı?" diye sorar.
+//   - Webhook: Telegram güncellemeleri botun gömülü HTTP(S) sunucusuna
+//     gönderir. Sunucu doğrudan TLS sertifikasıyla ya da TLS'i sonlandıran
+//     bir ters vekil sunucunun (reverse proxy) arkasında düz HTTP ile
+//     çalışabilir. Başlangıçta `setWebhook`, kapanışta `deleteWebhook`
+//     otomatik olarak çağrılır.
+
+// webhookSecretHeader, Telegram'ın `secret_token` değerini gönderdiği başlıktır.
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// pollingTimeout, uzun yoklamada tek bir isteğin en fazla bekleme süresidir (saniye).
+const pollingTimeout = 60
+
+// startUpdateSource, yapılandırmaya göre uzun yoklamayı veya webhook
+// sunucusunu başlatır. Dönen `stop` fonksiyonu kaynağı kapatır; kapanış
+// düzgün tamamlandığında güncelleme kanalı da kapanır. Kanal açık kalsa bile
+// `handleUpdates` kapanış bağlamı iptal edildiğinde döner.
+func startUpdateSource(api *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, func(), error) {
+	if config.WebhookURL == "" {
+		return startPolling(api)
+	}
+	return startWebhook(api)
+}
+
+// startPolling, uzun yoklama modunu başlatır. Daha önce bir webhook
+// tanımlanmışsa Telegram yoklamaya izin vermeyeceği için önce silinir.
+func startPolling(api *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, func(), error) {
+	if _, err := api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("Uyarı: Önceki webhook silinemedi: %v", err)
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = pollingTimeout
+	updates := api.GetUpdatesChan(u)
+	log.Println("Güncelleme modu: uzun yoklama (long polling).")
+
+	stop := func() {
+		log.Printf("Uzun yoklama durduruluyor (en fazla %d sn sürebilir)...", pollingTimeout)
+		api.StopReceivingUpdates()
+	}
+	return updates, stop, nil
+}
+
+// startWebhook, gömülü HTTP(S) sunucusunu başlatır ve Telegram'a webhook
+// adresini bildirir.
+func startWebhook(api *tgbotapi.BotAPI) (tgbotapi.UpdatesChannel, func(), error) {
+	pathSecret := config.WebhookPathSecret
+	if pathSecret == "" {
+		pathSecret = randomToken()
+	}
+	secretToken := config.WebhookSecretToken
+	if secretToken == "" {
+		// * Her başlangıçta `setWebhook` yeniden çağrıldığı için rastgele bir
+		// * değer yeterlidir; ters vekilde ayrıca tanımlanması gerekmez.
+		secretToken = randomToken()
+	}
+
+	updates := make(chan tgbotapi.Update, api.Buffer)
+	// stopped, kapanış başladığında kapatılır. Kapanışta `handleUpdates`
+	// kanalı okumayı bırakabileceği için işleyiciler kanala yazarken bunu da
+	// bekler; aksi halde dolu kanalda sonsuza kadar asılı kalırlardı.
+	stopped := make(chan struct{})
+	path := "/" + pathSecret
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got := r.Header.Get(webhookSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secretToken)) != 1 {
+			log.Printf("⚠️ Geçersiz webhook isteği reddedildi: %s", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		update, err := api.HandleUpdate(r)
+		if err != nil {
+			log.Printf("Webhook güncellemesi çözümlenemedi: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		select {
+		case updates <- *update:
+		case <-stopped:
+			// * Telegram 2xx dışındaki yanıtlarda güncellemeyi daha sonra
+			// * yeniden gönderir; bu sayede kapanışta güncelleme kaybolmaz.
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	})
+
+	server := &http.Server{
+		Addr:              config.WebhookListen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	useTLS := config.WebhookCertFile != "" && config.WebhookKeyFile != ""
+	go func() {
+		var err error
+		if useTLS {
+			log.Printf("Webhook sunucusu HTTPS ile dinliyor: %s", config.WebhookListen)
+			err = server.ListenAndServeTLS(config.WebhookCertFile, config.WebhookKeyFile)
+		} else {
+			log.Printf("Webhook sunucusu HTTP ile dinliyor (ters vekil modu): %s", config.WebhookListen)
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Webhook sunucusu başlatılamadı: %v", err)
+		}
+	}()
+
+	publicURL := strings.TrimRight(config.WebhookURL, "/") + path
+	if err := setWebhook(api, publicURL, secretToken); err != nil {
+		server.Close()
+		return nil, nil, err
+	}
+	log.Printf("Güncelleme modu: webhook (%s/…).", strings.TrimRight(config.WebhookURL, "/"))
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			log.Println("Webhook kapatılıyor...")
+			close(stopped)
+			if _, err := api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+				log.Printf("Uyarı: Webhook silinemedi: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := server.Shutdown(ctx); err != nil {
+				// * Süre dolduğunda hâlâ çalışan işleyiciler olabilir; kanal
+				// * kapatılırsa bunlar kapalı kanala yazıp panik oluşturur.
+				// * Kanal açık bırakılır, `handleUpdates` kapanış bağlamıyla döner.
+				log.Printf("Webhook sunucusu düzgün kapatılamadı: %v", err)
+				return
+			}
+			// * Shutdown hatasız döndüğünde tüm işleyiciler tamamlanmıştır;
+			// * artık kanala yazan kalmadığı için kapatmak güvenlidir.
+			close(updates)
+		})
+	}
+	return updates, stop, nil
+}
+
+// setWebhook, Telegram'a webhook adresini bildirir. Kütüphanenin
+// `WebhookConfig` yapısı `secret_token` alanını desteklemediği için istek
+// doğrudan oluşturulur. Kendinden imzalı sertifika kullanılıyorsa sertifika
+// dosyası da Telegram'a yüklenir.
+func setWebhook(api *tgbotapi.BotAPI, publicURL, secretToken string) error {
+	params := tgbotapi.Params{}
+	params["url"] = publicURL
+	params["secret_token"] = secretToken
+	params.AddNonZero("max_connections", config.WebhookMaxConnections)
+
+	var err error
+	if config.WebhookUploadCert && config.WebhookCertFile != "" {
+		_, err = api.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{
+			{Name: "certificate", Data: tgbotapi.FilePath(config.WebhookCertFile)},
+		})
+	} else {
+		_, err = api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return fmt.Errorf("setWebhook başarısız: %w", err)
+	}
+
+	info, err := api.GetWebhookInfo()
+	if err == nil && info.LastErrorDate != 0 {
+		log.Printf("Uyarı: Telegram son webhook hatasını bildirdi: %s", info.LastErrorMessage)
+	}
+	return nil
+}
+
+// randomToken, URL yolunda ve `secret_token` başlığında kullanılabilecek
+// rastgele bir değer üretir.
+func randomToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("Rastgele değer üretilemedi: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,379 @@
+// user_commands.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// #############################################################################
+// #                         KULLANICI VE ROL KOMUTLARI
+// #############################################################################
+// Bu dosya, yöneticinin kullanıcıları, rolleri ve izinleri Telegram
+// üzerinden yönetmesini sağlayan komutları içerir. Asıl veri işlemleri
+// `user_store.go` dosyasında yapılır.
+
+// parseUserID, bir metni Telegram kullanıcı ID'sine çevirir.
+func parseUserID(s string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+}
+
+// formatPermissions, bir hesabın veya rolün ek izinlerini tek satırda özetler.
+func formatPermissions(commands, tools, categories []string) string {
+	var parts []string
+	if len(commands) > 0 {
+		parts = append(parts, "komut: "+strings.Join(commands, ", "))
+	}
+	if len(tools) > 0 {
+		parts = append(parts, "araç: "+strings.Join(tools, ", "))
+	}
+	if len(categories) > 0 {
+		parts = append(parts, "kategori: "+strings.Join(categories, ", "))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " | ")
+}
+
+// handleAddUserCommand, /kullanici_ekle komutuyla yeni bir kullanıcı ekler.
+func handleAddUserCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	parts := strings.Fields(message.CommandArguments())
+	userID, err := parseUserID(parts[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Geçersiz kullanıcı ID'si: `%s`", parts[0])))
+		return
+	}
+	roleName := roleViewer
+	if len(parts) > 1 {
+		roleName = parts[1]
+	}
+	name := ""
+	if len(parts) > 2 {
+		name = strings.Join(parts[2:], " ")
+	}
+	if err := addUser(userID, roleName, name); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Kullanıcı eklenemedi: %v", err)))
+		return
+	}
+	log.Printf("Kullanıcı eklendi: %d (%s) - Ekleyen: %d", userID, roleName, message.From.ID)
+	refreshUserMenu(bot, userID)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Kullanıcı eklendi.\n\n👤 ID: `%d`\n🎭 Rol: `%s`", userID, roleName)))
+}
+
+// handleRemoveUserCommand, /kullanici_sil komutuyla bir kullanıcının erişimini kaldırır.
+func handleRemoveUserCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	arg := message.CommandArguments()
+	userID, err := parseUserID(arg)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Geçersiz kullanıcı ID'si: `%s`", arg)))
+		return
+	}
+	if err := removeUser(userID); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Kullanıcı silinemedi: %v", err)))
+		return
+	}
+	log.Printf("Kullanıcı silindi: %d - Silen: %d", userID, message.From.ID)
+	refreshUserMenu(bot, userID)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑️ `%d` kullanıcısının erişimi kaldırıldı.", userID)))
+}
+
+// handleSetRoleCommand, /rol_ver komutuyla bir kullanıcının rolünü değiştirir.
+func handleSetRoleCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	parts := strings.Fields(message.CommandArguments())
+	userID, err := parseUserID(parts[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Geçersiz kullanıcı ID'si: `%s`", parts[0])))
+		return
+	}
+	if err := setUserRole(userID, parts[1]); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Rol verilemedi: %v", err)))
+		return
+	}
+	log.Printf("Rol değiştirildi: %d -> %s - Değiştiren: %d", userID, parts[1], message.From.ID)
+	refreshUserMenu(bot, userID)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ `%d` kullanıcısının yeni rolü: `%s`", userID, parts[1])))
+}
+
+// handleListUsersCommand, /kullanicilar komutuyla kayıtlı kullanıcıları listeler.
+func handleListUsersCommand(bot Messenger, message *tgbotapi.Message) {
+	accounts := listAccounts()
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("👥 *Kayıtlı Kullanıcılar (%d adet):*\n\n", len(accounts)))
+	for _, account := range accounts {
+		name := account.Name
+		if name == "" {
+			name = "-"
+		}
+		builder.WriteString(fmt.Sprintf("👤 `%d` (%s)\n   🎭 Rol: `%s`\n   🔑 Ek izinler: %s\n",
+			account.ID, name, account.Role, formatPermissions(account.Commands, account.Tools, account.Categories)))
+		if account.Expires != "" {
+			builder.WriteString(fmt.Sprintf("   ⏳ Erişim bitişi: %s\n", formatStoredTime(account.Expires)))
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("💡 İzin vermek için: `/yetki_ver <id|rol> <komut:ad|arac:ad|kategori:ad>`")
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, builder.String()))
+}
+
+// handleListRolesCommand, /roller komutuyla tanımlı rolleri listeler.
+func handleListRolesCommand(bot Messenger, message *tgbotapi.Message) {
+	roles := listRoles()
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🎭 *Tanımlı Roller (%d adet):*\n\n", len(roles)))
+	for _, role := range roles {
+		builder.WriteString(fmt.Sprintf("🎭 `%s` (Seviye %d)\n   🔑 Ek izinler: %s\n\n",
+			role.Name, role.Level, formatPermissions(role.Commands, role.Tools, role.Categories)))
+	}
+	builder.WriteString("💡 Yeni rol için: `/rol_ekle <ad> <şablon_rol>`")
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, builder.String()))
+}
+
+// handleAddRoleCommand, /rol_ekle komutuyla mevcut bir rolü şablon alarak yeni bir rol oluşturur.
+func handleAddRoleCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	parts := strings.Fields(message.CommandArguments())
+	if err := addRole(parts[0], parts[1]); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Rol oluşturulamadı: %v", err)))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ `%s` rolü, `%s` şablonundan oluşturuldu.", parts[0], parts[1])))
+}
+
+// handleGrantCommand, /yetki_ver komutuyla bir kullanıcıya veya role izin verir.
+func handleGrantCommand(bot Messenger, message *tgbotapi.Message) {
+	handlePermissionChange(bot, message, true)
+}
+
+// handleRevokeCommand, /yetki_al komutuyla bir kullanıcıdan veya rolden izni geri alır.
+func handleRevokeCommand(bot Messenger, message *tgbotapi.Message) {
+	handlePermissionChange(bot, message, false)
+}
+
+func handlePermissionChange(bot Messenger, message *tgbotapi.Message, grant bool) {
+	chatID := message.Chat.ID
+	parts := strings.Fields(message.CommandArguments())
+	target, expr := parts[0], parts[1]
+	if err := updatePermission(target, expr, grant); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ İzin güncellenemedi: %v", err)))
+		return
+	}
+	action := "verildi"
+	if !grant {
+		action = "geri alındı"
+	}
+	log.Printf("İzin %s: %s -> %s - Yönetici: %d", action, expr, target, message.From.ID)
+	// * Hedef bir rol olabilir; o rolü taşıyan herkesin menüsü değişir.
+	refreshAllUserMenus(bot)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ `%s` izni `%s` için %s.", expr, target, action)))
+}
+
+// handleCreateInviteCommand, /davet komutuyla yeni bir davet kodu oluşturur.
+// Kullanım: /davet [rol] [kod_süresi] [erişim_süresi] [kullanım]
+// Varsayılanlar: izleyici rolü, 24 saat geçerli, kalıcı erişim, tek kullanım.
+func handleCreateInviteCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	parts := strings.Fields(message.CommandArguments())
+
+	roleName := roleViewer
+	validFor, accessFor := 24*time.Hour, time.Duration(0)
+	maxUses := 1
+	var err error
+
+	if len(parts) > 0 {
+		roleName = parts[0]
+	}
+	if len(parts) > 1 {
+		if validFor, err = parseDurationArg(parts[1]); err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Kod süresi: %v", err)))
+			return
+		}
+	}
+	if len(parts) > 2 {
+		if accessFor, err = parseDurationArg(parts[2]); err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Erişim süresi: %v", err)))
+			return
+		}
+	}
+	if len(parts) > 3 {
+		if maxUses, err = strconv.Atoi(parts[3]); err != nil || maxUses < 0 {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Geçersiz kullanım sayısı: `%s` (0: sınırsız)", parts[3])))
+			return
+		}
+	}
+
+	invite, err := createInvite(message.From.ID, roleName, validFor, accessFor, maxUses)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Davet oluşturulamadı: %v", err)))
+		return
+	}
+	log.Printf("Davet oluşturuldu: %s (%s) - Oluşturan: %d", invite.Code, invite.Role, message.From.ID)
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🎟️ *Davet Kodu Oluşturuldu*\n\n`/start %s`\n\n%s\n\n💡 Bu satırı davet ettiğiniz kişiye gönderin. İptal için: `/davet_iptal %s`",
+		invite.Code, formatInvite(invite), invite.Code))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// handleListInvitesCommand, /davetler komutuyla aktif davet kodlarını listeler.
+func handleListInvitesCommand(bot Messenger, message *tgbotapi.Message) {
+	invites := listInvites()
+	if len(invites) == 0 {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "🎟️ Aktif davet kodu bulunmuyor. Yeni kod için: `/davet [rol] [süre]`"))
+		return
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("🎟️ *Aktif Davetler (%d adet):*\n\n", len(invites)))
+	for _, invite := range invites {
+		builder.WriteString(fmt.Sprintf("🔑 `%s`\n%s\n\n", invite.Code, formatInvite(invite)))
+	}
+	builder.WriteString("💡 İptal için: `/davet_iptal <kod>`")
+	msg := tgbotapi.NewMessage(message.Chat.ID, builder.String())
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// handleRevokeInviteCommand, /davet_iptal komutuyla bir davet kodunu geçersiz kılar.
+func handleRevokeInviteCommand(bot Messenger, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	code := message.CommandArguments()
+	if err := revokeInvite(code); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Davet iptal edilemedi: %v", err)))
+		return
+	}
+	log.Printf("Davet iptal edildi: %s - İptal eden: %d", code, message.From.ID)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑️ `%s` davet kodu iptal edildi.", strings.ToUpper(code))))
+}
+
+// formatInvite, bir davetin rolünü, süresini ve kalan kullanım hakkını özetler.
+func formatInvite(invite Invite) string {
+	uses := "sınırsız"
+	if invite.MaxUses > 0 {
+		uses = fmt.Sprintf("%d/%d", invite.Uses, invite.MaxUses)
+	}
+	validUntil := "süresiz"
+	if invite.Expires != "" {
+		validUntil = formatStoredTime(invite.Expires)
+	}
+	access := "kalıcı"
+	if invite.AccessFor != "" {
+		access = invite.AccessFor
+	}
+	return fmt.Sprintf("   🎭 Rol: `%s`\n   ⏳ Geçerlilik: %s\n   🕒 Erişim: %s\n   🔁 Kullanım: %s", invite.Role, validUntil, access, uses)
+}
+
+// formatStoredTime, RFC3339 biçimindeki bir zamanı okunabilir hale getirir.
+func formatStoredTime(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.Local().Format("02.01.2006 15:04")
+}
+
+// handleInviteRedeem, kayıtlı olmayan bir kullanıcının `/start <kod>` ile
+// gönderdiği davet kodunu kullanır. Başarılı olursa yönetici bilgilendirilir
+// ve erişimi tek tıkla iptal edebilmesi için bir düğme gönderilir.
+func handleInviteRedeem(bot Messenger, message *tgbotapi.Message) {
+	from := message.From
+	name := strings.TrimSpace(from.FirstName + " " + from.LastName)
+	if from.UserName != "" {
+		name = "@" + from.UserName
+	}
+
+	start := time.Now()
+	account, err := redeemInvite(message.CommandArguments(), from.ID, name)
+	if err != nil {
+		log.Printf("⚠️ Geçersiz davet denemesi! Kullanıcı: %s (%d) - %v", name, from.ID, err)
+		auditMessage(message, auditKindInvite, "start", message.CommandArguments(), auditResultDenied, err, start)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "🚫 Davet kodu geçersiz veya süresi dolmuş."))
+		return
+	}
+	log.Printf("Davet kullanıldı: %s -> %s (%d), Rol: %s", account.Invite, name, from.ID, account.Role)
+	auditMessage(message, auditKindInvite, "start", account.Invite, auditResultOK, nil, start)
+	refreshUserMenu(bot, from.ID)
+
+	welcome := fmt.Sprintf("✅ Davet kabul edildi! `%s` rolüyle erişiminiz açıldı.", account.Role)
+	if account.Expires != "" {
+		welcome += fmt.Sprintf("\n⏳ Erişiminiz %s tarihinde sona erecek.", formatStoredTime(account.Expires))
+	}
+	bot.Send(tgbotapi.NewMessage(message.Chat.ID, welcome))
+	handleStartCommand(bot, message)
+
+	expires := "kalıcı"
+	if account.Expires != "" {
+		expires = formatStoredTime(account.Expires)
+	}
+	notice := tgbotapi.NewMessage(config.AdminChatID, fmt.Sprintf("🎟️ *Davet Kullanıldı*\n\n👤 `%s` (`%d`)\n🔑 Kod: `%s`\n🎭 Rol: `%s`\n⏳ Erişim: %s",
+		name, from.ID, account.Invite, account.Role, expires))
+	notice.ParseMode = "Markdown"
+	notice.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚫 Erişimi İptal Et", callbackData("erisim", "iptal", strconv.FormatInt(from.ID, 10))),
+		),
+	)
+	bot.Send(notice)
+}
+
+// handleRevokeAccessCallback, davet bildirimindeki "Erişimi İptal Et"
+// düğmesini işler. Düğmeye yalnızca kullanıcı silme yetkisi olanlar basabilir.
+func handleRevokeAccessCallback(bot Messenger, callbackQuery *tgbotapi.CallbackQuery) {
+	parts := strings.SplitN(callbackQuery.Data, "_", 3)
+	if len(parts) < 3 || parts[1] != "iptal" {
+		return
+	}
+	targetID, err := parseUserID(parts[2])
+	if err != nil {
+		return
+	}
+	newText := fmt.Sprintf("🚫 `%d` kullanıcısının erişimi iptal edildi.", targetID)
+	if err := removeUser(targetID); err != nil {
+		newText = fmt.Sprintf("❌ Erişim iptal edilemedi: %v", err)
+	} else {
+		log.Printf("Davetli kullanıcının erişimi iptal edildi: %d - İptal eden: %d", targetID, callbackQuery.From.ID)
+		refreshUserMenu(bot, targetID)
+	}
+	editMsg := tgbotapi.NewEditMessageText(callbackQuery.Message.Chat.ID, callbackQuery.Message.MessageID, newText)
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+}
+
+// expireTemporaryAccess, süresi dolan geçici erişimleri ve davetleri siler,
+// yöneticiyi bilgilendirir. `runScheduler` tarafından saatlik çağrılır.
+func expireTemporaryAccess(bot Messenger) {
+	removedUsers, removedInvites, err := cleanupExpiredAccess(time.Now())
+	if err != nil {
+		log.Printf("Süresi dolan erişimler kaydedilemedi: %v", err)
+	}
+	if len(removedUsers) == 0 && removedInvites == 0 {
+		return
+	}
+	log.Printf("Süresi dolan %d geçici erişim ve %d davet temizlendi.", len(removedUsers), removedInvites)
+	for _, account := range removedUsers {
+		refreshUserMenu(bot, account.ID)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("⏳ *Süresi Dolan Erişimler Temizlendi*\n")
+	for _, account := range removedUsers {
+		name := account.Name
+		if name == "" {
+			name = "-"
+		}
+		builder.WriteString(fmt.Sprintf("\n👤 `%s` (`%d`) - Rol: `%s`", name, account.ID, account.Role))
+	}
+	if removedInvites > 0 {
+		builder.WriteString(fmt.Sprintf("\n\n🎟️ %d davet kodunun süresi doldu.", removedInvites))
+	}
+	msg := tgbotapi.NewMessage(config.AdminChatID, builder.String())
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
@@ -0,0 +1,384 @@
+// user_store.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// #############################################################################
+// #                             KULLANICI VE ROL DEPOSU
+// #############################################################################
+// Bu dosya, botu kullanabilecek kişilerin ve rollerin kalıcı olarak
+// saklanmasından sorumludur. Veriler bellekte tutulur ve her değişiklikte
+// `users.json` dosyasına yazılır. Dosya ilk kez oluşturulurken `.env`
+// içindeki `ADMIN_CHAT_ID` ve `ALLOWED_IDS` değerleri içeri aktarılır;
+// sonrasında kullanıcılar Telegram üzerinden yönetilir.
+
+// Role, bir rolün seviyesini ve seviyeden bağımsız ek izinlerini tanımlar.
+type Role struct {
+	Name       string   `json:"name"`
+	Level      int      `json:"level"`
+	Commands   []string `json:"commands,omitempty"`
+	Tools      []string `json:"tools,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// UserAccount, bota erişimi olan bir kullanıcıyı ve ona özel izinleri tutar.
+type UserAccount struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Role       string   `json:"role"`
+	Commands   []string `json:"commands,omitempty"`
+	Tools      []string `json:"tools,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Added      string   `json:"added"`
+	Expires    string   `json:"expires,omitempty"` // geçici erişimde bitiş zamanı (RFC3339)
+	Invite     string   `json:"invite,omitempty"`  // kullanıcı bir davetle geldiyse davet kodu
+}
+
+// AccessData, `users.json` dosyasının tamamını temsil eder.
+type AccessData struct {
+	Roles   map[string]*Role       `json:"roles"`
+	Users   map[int64]*UserAccount `json:"users"`
+	Invites map[string]*Invite     `json:"invites,omitempty"`
+}
+
+var (
+	// accessData, tüm kullanıcıları ve rolleri bellekte tutan ana yapıdır.
+	accessData *AccessData
+
+	// accessMutex, `accessData` üzerindeki eş zamanlı erişimleri korur.
+	accessMutex = &sync.Mutex{}
+)
+
+// defaultRoles, `users.json` ilk kez oluşturulurken kullanılan rollerdir.
+func defaultRoles() map[string]*Role {
+	return map[string]*Role{
+		roleViewer:   {Name: roleViewer, Level: 1, Categories: []string{permissionWildcard}},
+		roleOperator: {Name: roleOperator, Level: 2, Categories: []string{permissionWildcard}},
+		roleAdmin:    {Name: roleAdmin, Level: 3, Categories: []string{permissionWildcard}},
+	}
+}
+
+// loadUsers, program başlangıcında `users.json` dosyasını okur. Dosya yoksa
+// `.env` içindeki yönetici ve izinli kullanıcı listesinden oluşturur.
+func loadUsers() error {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+
+	accessData = &AccessData{Roles: defaultRoles(), Users: make(map[int64]*UserAccount)}
+
+	data, err := os.ReadFile(config.UsersFilePath)
+	if os.IsNotExist(err) {
+		log.Printf("%s bulunamadı, .env içindeki kullanıcılar içeri aktarılıyor.", config.UsersFilePath)
+		now := time.Now().Format(time.RFC3339)
+		for _, id := range config.AllowedIDs {
+			accessData.Users[id] = &UserAccount{ID: id, Role: roleOperator, Added: now}
+		}
+	} else if err != nil {
+		return fmt.Errorf("kullanıcı dosyası okunamadı: %w", err)
+	} else if len(data) > 0 {
+		if err := json.Unmarshal(data, accessData); err != nil {
+			return fmt.Errorf("kullanıcı dosyası çözümlenemedi: %w", err)
+		}
+		if accessData.Roles == nil {
+			accessData.Roles = defaultRoles()
+		}
+		if accessData.Users == nil {
+			accessData.Users = make(map[int64]*UserAccount)
+		}
+	}
+	if accessData.Invites == nil {
+		accessData.Invites = make(map[string]*Invite)
+	}
+
+	// * ÖNEMLİ: `.env` içindeki yönetici her zaman yönetici rolündedir.
+	// * Bu, dosya elle bozulsa bile botun kilitlenmesini engeller.
+	if admin, ok := accessData.Users[config.AdminChatID]; ok {
+		admin.Role = roleAdmin
+		admin.Expires = ""
+	} else {
+		accessData.Users[config.AdminChatID] = &UserAccount{ID: config.AdminChatID, Role: roleAdmin, Added: time.Now().Format(time.RFC3339)}
+	}
+	if _, ok := accessData.Roles[roleAdmin]; !ok {
+		accessData.Roles[roleAdmin] = defaultRoles()[roleAdmin]
+	}
+	for name := range accessData.Roles {
+		if isNumericRoleName(name) {
+			log.Printf("Uyarı: `%s` rolünün adı yalnızca rakamlardan oluşuyor; izin komutları bu adı kullanıcı kimliği olarak yorumlar. Rolü yeniden adlandırın.", name)
+		}
+	}
+
+	log.Printf("%d kullanıcı ve %d rol yüklendi.", len(accessData.Users), len(accessData.Roles))
+	return saveUsers()
+}
+
+// saveUsers, `accessData` yapısını `users.json` dosyasına yazar. Yazma yarıda
+// kalırsa eski dosya bozulmasın diye önce geçici dosyaya yazılıp yeniden adlandırılır.
+// Çağıran tarafın `accessMutex` kilidini tutuyor olması gerekir.
+func saveUsers() error {
+	data, err := json.MarshalIndent(accessData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("kullanıcılar JSON'a çevrilemedi: %w", err)
+	}
+	tmp := config.UsersFilePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, config.UsersFilePath); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// lookupAccount, kullanıcının hesabının ve rolünün birer kopyasını döndürür.
+// Kullanıcı kayıtlı değilse veya geçici erişiminin süresi dolmuşsa hesap
+// `nil` olur; süresi dolan kayıtlar zamanlayıcı tarafından ayrıca silinir.
+func lookupAccount(userID int64) (*UserAccount, Role) {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if accessData == nil {
+		return nil, Role{}
+	}
+	account, ok := accessData.Users[userID]
+	if !ok || isExpired(account.Expires, time.Now()) {
+		return nil, Role{}
+	}
+	accountCopy := *account
+	var role Role
+	if r, ok := accessData.Roles[account.Role]; ok {
+		role = *r
+	}
+	return &accountCopy, role
+}
+
+// roleLevel, bir rolün seviyesini döndürür. Boş rol adı en düşük seviye
+// (izleyici) kabul edilir; bilinmeyen roller hiçbir zaman karşılanamaz.
+func roleLevel(name string) int {
+	if name == "" {
+		name = roleViewer
+	}
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if accessData != nil {
+		if r, ok := accessData.Roles[name]; ok {
+			return r.Level
+		}
+	}
+	if r, ok := defaultRoles()[name]; ok {
+		return r.Level
+	}
+	return 1 << 30
+}
+
+// addUser, yeni bir kullanıcı ekler veya mevcut kullanıcının rolünü ve adını günceller.
+func addUser(userID int64, roleName, name string) error {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if _, ok := accessData.Roles[roleName]; !ok {
+		return fmt.Errorf("bilinmeyen rol: %s", roleName)
+	}
+	account, ok := accessData.Users[userID]
+	if !ok {
+		account = &UserAccount{ID: userID, Added: time.Now().Format(time.RFC3339)}
+		accessData.Users[userID] = account
+	}
+	account.Role = roleName
+	account.Expires = ""
+	if name != "" {
+		account.Name = name
+	}
+	return saveUsers()
+}
+
+// removeUser, bir kullanıcının bota erişimini tamamen kaldırır.
+func removeUser(userID int64) error {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if userID == config.AdminChatID {
+		return fmt.Errorf("ana yönetici silinemez")
+	}
+	if _, ok := accessData.Users[userID]; !ok {
+		return fmt.Errorf("kullanıcı bulunamadı: %d", userID)
+	}
+	delete(accessData.Users, userID)
+	return saveUsers()
+}
+
+// setUserRole, kayıtlı bir kullanıcının rolünü değiştirir.
+func setUserRole(userID int64, roleName string) error {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	account, ok := accessData.Users[userID]
+	if !ok {
+		return fmt.Errorf("kullanıcı bulunamadı: %d", userID)
+	}
+	if _, ok := accessData.Roles[roleName]; !ok {
+		return fmt.Errorf("bilinmeyen rol: %s", roleName)
+	}
+	if userID == config.AdminChatID && roleName != roleAdmin {
+		return fmt.Errorf("ana yöneticinin rolü değiştirilemez")
+	}
+	account.Role = roleName
+	return saveUsers()
+}
+
+// addRole, mevcut bir rolü şablon alarak yeni bir rol oluşturur. Yalnızca
+// rakamlardan oluşan adlar reddedilir; `/yetki_ver` gibi komutlar sayısal
+// hedefleri kullanıcı kimliği olarak yorumlar ve böyle bir role ulaşamaz.
+func addRole(name, baseRole string) error {
+	if isNumericRoleName(name) {
+		return fmt.Errorf("rol adı yalnızca rakamlardan oluşamaz (sayısal değerler kullanıcı kimliği sayılır): %s", name)
+	}
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	if _, exists := accessData.Roles[name]; exists {
+		return fmt.Errorf("`%s` rolü zaten var", name)
+	}
+	base, ok := accessData.Roles[baseRole]
+	if !ok {
+		return fmt.Errorf("bilinmeyen rol: %s", baseRole)
+	}
+	role := *base
+	role.Name = name
+	role.Commands = append([]string(nil), base.Commands...)
+	role.Tools = append([]string(nil), base.Tools...)
+	role.Categories = append([]string(nil), base.Categories...)
+	accessData.Roles[name] = &role
+	return saveUsers()
+}
+
+// isNumericRoleName, adın kullanıcı kimliği olarak çözülüp çözülmediğini
+// belirler.
+func isNumericRoleName(name string) bool {
+	_, err := parseUserID(name)
+	return err == nil
+}
+
+// Permission türleri: bir izin "komut:ad", "arac:ad" veya "kategori:ad"
+// biçiminde yazılır. Önek verilmezse komut izni kabul edilir.
+const (
+	permissionCommand  = "komut"
+	permissionTool     = "arac"
+	permissionCategory = "kategori"
+)
+
+// parsePermission, "arac:delete_file" gibi bir izin ifadesini türüne ve
+// değerine ayırır.
+func parsePermission(expr string) (kind, value string, err error) {
+	kind, value, found := strings.Cut(expr, ":")
+	if !found {
+		kind, value = permissionCommand, expr
+	}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "/")
+	if value == "" {
+		return "", "", fmt.Errorf("izin değeri boş")
+	}
+	switch strings.ToLower(kind) {
+	case permissionCommand, permissionTool, permissionCategory:
+		return strings.ToLower(kind), value, nil
+	}
+	return "", "", fmt.Errorf("bilinmeyen izin türü: %s", kind)
+}
+
+// updatePermission, bir kullanıcıya (hedef sayısal ise) veya bir role izin
+// ekler ya da izni kaldırır.
+func updatePermission(target, expr string, grant bool) error {
+	kind, value, err := parsePermission(expr)
+	if err != nil {
+		return err
+	}
+
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+
+	var commands, tools, categories *[]string
+	if userID, convErr := parseUserID(target); convErr == nil {
+		account, ok := accessData.Users[userID]
+		if !ok {
+			return fmt.Errorf("kullanıcı bulunamadı: %d", userID)
+		}
+		commands, tools, categories = &account.Commands, &account.Tools, &account.Categories
+	} else {
+		role, ok := accessData.Roles[target]
+		if !ok {
+			return fmt.Errorf("bilinmeyen kullanıcı veya rol: %s", target)
+		}
+		commands, tools, categories = &role.Commands, &role.Tools, &role.Categories
+	}
+
+	list := commands
+	switch kind {
+	case permissionTool:
+		list = tools
+	case permissionCategory:
+		list = categories
+	}
+
+	if grant {
+		if !containsFold(*list, value) {
+			*list = append(*list, value)
+		}
+	} else {
+		filtered := (*list)[:0]
+		removed := false
+		for _, item := range *list {
+			if strings.EqualFold(item, value) {
+				removed = true
+				continue
+			}
+			filtered = append(filtered, item)
+		}
+		if !removed {
+			return fmt.Errorf("`%s` izni zaten tanımlı değil", expr)
+		}
+		*list = filtered
+	}
+	return saveUsers()
+}
+
+// listAccounts, kullanıcıları ID sırasıyla (kopya olarak) döndürür.
+func listAccounts() []UserAccount {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	var accounts []UserAccount
+	for _, account := range accessData.Users {
+		accounts = append(accounts, *account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	return accounts
+}
+
+// listRoles, rolleri seviye sırasıyla (kopya olarak) döndürür.
+func listRoles() []Role {
+	accessMutex.Lock()
+	defer accessMutex.Unlock()
+	var roles []Role
+	for _, role := range accessData.Roles {
+		roles = append(roles, *role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].Level != roles[j].Level {
+			return roles[i].Level < roles[j].Level
+		}
+		return roles[i].Name < roles[j].Name
+	})
+	return roles
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+// user_store_test.go
+package main
+
+import "testing"
+
+func TestAddRoleRejectsNumericNames(t *testing.T) {
+	setupTestEnv(t)
+	for _, name := range []string{"123", "0042", "-7", " 5 "} {
+		if err := addRole(name, roleViewer); err == nil {
+			t.Errorf("addRole(%q) kabul edildi", name)
+		}
+	}
+	if err := addRole("muhasebe2", roleViewer); err != nil {
+		t.Errorf("addRole(muhasebe2) = %v", err)
+	}
+}
+
+func TestUpdatePermissionTargets(t *testing.T) {
+	setupTestEnv(t)
+	if err := addRole("muhasebe", roleViewer); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		target  string
+		expr    string
+		wantErr bool
+	}{
+		{"muhasebe", "kategori:Faturalar", false},
+		{"1003", "komut:sil", false},
+		{"9999", "komut:sil", true}, // kayıtlı olmayan kullanıcı
+		{"yok", "komut:sil", true},  // bilinmeyen rol
+		{"muhasebe", "bilinmeyen:x", true},
+	}
+	for _, tt := range tests {
+		if err := updatePermission(tt.target, tt.expr, true); (err != nil) != tt.wantErr {
+			t.Errorf("updatePermission(%q, %q) = %v, hata bekleniyor mu: %v", tt.target, tt.expr, err, tt.wantErr)
+		}
+	}
+	if account, _ := lookupAccount(testViewerID); account == nil || !containsFold(account.Commands, "sil") {
+		t.Errorf("kullanıcıya izin verilmedi: %+v", account)
+	}
+}
@@ -42,7 +42,7 @@ func findVideoToClip(videoName string) (string, bool) {
 
 // handleClipCommand, /kes komutunu işler. Belirtilen videonun, belirtilen
 // başlangıç ve bitiş zamanları arasındaki bölümünü keser.
-func handleClipCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleClipCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	args := message.CommandArguments()
 	parts := strings.Fields(args)
@@ -72,7 +72,7 @@ func handleClipCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	// * ÖNEMLİ: `-c copy` argümanı, FFmpeg'e videoyu yeniden kodlamamasını,
 	// * sadece belirtilen bölümü kopyalamasını söyler. Bu, işlemi
 	// * inanılmaz derecede hızlandırır (saniyeler içinde tamamlanır).
-	cmd := exec.Command("ffmpeg",
+	cmd := exec.CommandContext(jobsCtx, "ffmpeg",
 		"-i", sourcePath,
 		"-ss", startTime,
 		"-to", endTime,
@@ -104,7 +104,7 @@ func handleClipCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 // handleGifCommand, /gif_yap komutunu işler. Bir videonun belirli bir
 // bölümünden yüksek kaliteli bir GIF oluşturur.
-func handleGifCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+func handleGifCommand(bot Messenger, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
 	args := message.CommandArguments()
 	parts := strings.Fields(args)
@@ -135,7 +135,7 @@ func handleGifCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	// * göre çok daha iyi renk doğruluğu ve daha az "beneklenme" (dithering) sağlar.
 	filter := "fps=15,scale=480:-1:flags=lanczos,split[s0][s1];[s0]palettegen[p];[s1][p]paletteuse"
 
-	cmd := exec.Command("ffmpeg",
+	cmd := exec.CommandContext(jobsCtx, "ffmpeg",
 		"-i", sourcePath,
 		"-ss", startTime,
 		"-to", endTime,
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os/exec"
@@ -34,42 +35,83 @@ var (
 )
 
 // startWorkers, tüm arka plan izleyicilerini başlatan ana fonksiyondur.
-func startWorkers(bot *tgbotapi.BotAPI) {
-	log.Printf("Worker'lar başlatılıyor: İnternet Kontrolü (%s), Port Kontrolü (%s)", config.WorkerIntervalInternet, config.WorkerIntervalPort)
+// İzleyiciler `ctx` iptal edildiğinde (bkz. `lifecycle.go`) durur.
+func startWorkers(ctx context.Context, bot Messenger) {
+	internetInterval, portInterval := workerIntervals()
+	log.Printf("Worker'lar başlatılıyor: İnternet Kontrolü (%s), Port Kontrolü (%s)", internetInterval, portInterval)
 
-	internetTicker := time.NewTicker(config.WorkerIntervalInternet)
-	portTicker := time.NewTicker(config.WorkerIntervalPort)
+	goBackground(func() { runPortWorker(ctx, bot) })
+	goBackground(func() { runInternetWorker(ctx, bot) })
+}
 
-	go runPortWorker(bot, portTicker)
-	go runInternetWorker(bot, internetTicker)
+// workerIntervalCheck, worker'ların yapılandırmadaki aralık değişikliklerini
+// ne sıklıkla kontrol ettiğini belirler.
+const workerIntervalCheck = 5 * time.Second
+
+// resetIfChanged, yapılandırma yeniden yüklendiğinde ticker'ı yeni aralığa
+// ayarlar ve güncel aralığı döndürür.
+func resetIfChanged(ticker *time.Ticker, current, wanted time.Duration, name string) time.Duration {
+	if wanted > 0 && wanted != current {
+		log.Printf("%s aralığı güncellendi: %s -> %s", name, current, wanted)
+		ticker.Reset(wanted)
+		return wanted
+	}
+	return current
 }
 
 // runPortWorker, port durumunu periyodik olarak kontrol eder.
-func runPortWorker(bot *tgbotapi.BotAPI, ticker *time.Ticker) {
+func runPortWorker(ctx context.Context, bot Messenger) {
+	_, interval := workerIntervals()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reload := time.NewTicker(workerIntervalCheck)
+	defer reload.Stop()
 	checkAndNotifyPortStatus(bot)
-	for range ticker.C {
-		if portMonitorEnabled {
-			checkAndNotifyPortStatus(bot)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload.C:
+			_, wanted := workerIntervals()
+			interval = resetIfChanged(ticker, interval, wanted, "Port kontrolü")
+		case <-ticker.C:
+			if portMonitorEnabled {
+				checkAndNotifyPortStatus(bot)
+			}
 		}
 	}
 }
 
 // runInternetWorker, internet bağlantısını periyodik olarak kontrol eder.
-func runInternetWorker(bot *tgbotapi.BotAPI, ticker *time.Ticker) {
+func runInternetWorker(ctx context.Context, bot Messenger) {
+	interval, _ := workerIntervals()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	reload := time.NewTicker(workerIntervalCheck)
+	defer reload.Stop()
 	checkInternetConnection(bot)
-	for range ticker.C {
-		checkInternetConnection(bot)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload.C:
+			wanted, _ := workerIntervals()
+			interval = resetIfChanged(ticker, interval, wanted, "İnternet kontrolü")
+		case <-ticker.C:
+			checkInternetConnection(bot)
+		}
 	}
 }
 
 // checkAndNotifyPortStatus, port durumunu kontrol eder ve değişiklikleri bildirir.
-func checkAndNotifyPortStatus(bot *tgbotapi.BotAPI) {
-	if len(config.MonitoredPorts) == 0 {
+func checkAndNotifyPortStatus(bot Messenger) {
+	ports := monitoredPorts()
+	if len(ports) == 0 {
 		return
 	}
 	
 	var portsToCheck []int
-	for port := range config.MonitoredPorts {
+	for port := range ports {
 		portsToCheck = append(portsToCheck, port)
 	}
 
@@ -85,7 +127,7 @@ func checkAndNotifyPortStatus(bot *tgbotapi.BotAPI) {
 	isInternetDownNow := internetDown
 	monitorMutex.Unlock()
 
-	for port, serviceName := range config.MonitoredPorts {
+	for port, serviceName := range ports {
 		_, isCurrentlyActive := activePorts[port]
 		wasActive, known := lastPortStatus[port]
 
@@ -107,7 +149,7 @@ func checkAndNotifyPortStatus(bot *tgbotapi.BotAPI) {
 }
 
 // sendMessageOrQueue, bir mesajı internet varsa gönderir, yoksa kuyruğa alır.
-func sendMessageOrQueue(bot *tgbotapi.BotAPI, msg tgbotapi.Chattable, isInternetDown bool) {
+func sendMessageOrQueue(bot Messenger, msg tgbotapi.Chattable, isInternetDown bool) {
 	if isInternetDown {
 		queueMutex.Lock()
 		notificationQueue = append(notificationQueue, msg)
@@ -125,7 +167,7 @@ func sendMessageOrQueue(bot *tgbotapi.BotAPI, msg tgbotapi.Chattable, isInternet
 }
 
 // checkInternetConnection, internet bağlantısını kontrol eder ve kuyruğu yönetir.
-func checkInternetConnection(bot *tgbotapi.BotAPI) {
+func checkInternetConnection(bot Messenger) {
 	cmd := exec.Command("ping", "-n", "1", "8.8.8.8")
 	if runtime.GOOS != "windows" {
 		cmd = exec.Command("ping", "-c", "1", "8.8.8.8")